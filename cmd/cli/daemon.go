@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/joho/godotenv"
+
+	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
+	"github.com/pardnchiu/agenvoy/internal/discord"
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+	"github.com/pardnchiu/agenvoy/internal/frontend"
+	"github.com/pardnchiu/agenvoy/internal/keychain"
+	"github.com/pardnchiu/agenvoy/internal/scheduler"
+	"github.com/pardnchiu/agenvoy/internal/skill"
+	"github.com/pardnchiu/agenvoy/internal/slack"
+	"github.com/pardnchiu/agenvoy/internal/telegram"
+	"github.com/pardnchiu/agenvoy/internal/tools/lsp"
+)
+
+// * runDaemon runs the scheduler alone, chat targets are reached with send-only clients
+func runDaemon() {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		slog.Warn("godotenv.Load",
+			slog.String("error", err.Error()))
+	}
+
+	if scheduler.Running() {
+		fmt.Println("scheduler is already running")
+		os.Exit(1)
+	}
+	if pid, ok := daemonPID(); ok {
+		fmt.Printf("daemon already running, pid %d\n", pid)
+		os.Exit(1)
+	}
+	if err := filesystem.WriteFile(filesystem.DaemonPIDPath, strconv.Itoa(os.Getpid()), 0644); err != nil {
+		slog.Error("filesystem.WriteFile",
+			slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer os.Remove(filesystem.DaemonPIDPath)
+
+	agentRegistry := getAgentRegistry()
+	var planner agentTypes.Agent
+	if cfg, err := keychain.Load(); err == nil && cfg.PlannerModel != "" {
+		planner = newAgentFromModel(cfg.PlannerModel)
+	}
+	if planner == nil {
+		planner = agentRegistry.Fallback
+	}
+	registerNotifiers(planner)
+
+	skill.SyncSkills(context.Background())
+	bot := frontend.Bot{
+		PlannerAgent:  planner,
+		AgentRegistry: agentRegistry,
+		SkillScanner:  skill.NewScanner(),
+	}
+	scheduler.RegisterPromptRunner(func(ctx context.Context, job scheduler.Job) (string, error) {
+		return frontend.RunJob(ctx, bot, job)
+	})
+
+	if err := scheduler.New(); err != nil {
+		slog.Error("scheduler.New",
+			slog.String("error", err.Error()))
+		return
+	}
+	defer scheduler.Stop()
+	defer lsp.Shutdown()
+	if err := scheduler.Get().Load(); err != nil {
+		slog.Warn("scheduler.Get().Load",
+			slog.String("error", err.Error()))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go scheduler.RetryDeadLetters()
+	go func() {
+		if err := scheduler.Serve(ctx); err != nil {
+			slog.Error("scheduler.Serve",
+				slog.String("error", err.Error()))
+			stop()
+		}
+	}()
+
+	slog.Info("daemon is running",
+		slog.Int("pid", os.Getpid()))
+	<-ctx.Done()
+	slog.Info("signal received, waiting for running jobs")
+}
+
+// * daemonPID reports a live process recorded in the PID file, a stale file is ignored
+func daemonPID() (int, bool) {
+	data, err := os.ReadFile(filesystem.DaemonPIDPath)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid == os.Getpid() {
+		return 0, false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return 0, false
+	}
+	// * signal 0 checks existence without touching the process
+	if err := process.Signal(syscall.Signal(0)); err != nil {
+		return 0, false
+	}
+	return pid, true
+}
+
+func registerNotifiers(planner agentTypes.Agent) {
+	if bot, err := discord.NewNotifier(planner); err != nil {
+		slog.Warn("discord.NewNotifier",
+			slog.String("error", err.Error()))
+	} else if bot != nil {
+		scheduler.RegisterHandler(scheduler.TargetDiscord, func(ctx context.Context, channelID, output string) error {
+			return discord.Notify(bot, channelID, output, !scheduler.IsFinal(ctx))
+		})
+	}
+	if bot, err := slack.NewNotifier(planner); err != nil {
+		slog.Warn("slack.NewNotifier",
+			slog.String("error", err.Error()))
+	} else if bot != nil {
+		scheduler.RegisterHandler(scheduler.TargetSlack, func(ctx context.Context, channelID, output string) error {
+			return slack.Notify(bot, channelID, output, !scheduler.IsFinal(ctx))
+		})
+	}
+	if bot, err := telegram.NewNotifier(planner); err != nil {
+		slog.Warn("telegram.NewNotifier",
+			slog.String("error", err.Error()))
+	} else if bot != nil {
+		scheduler.RegisterHandler(scheduler.TargetTelegram, func(ctx context.Context, chatID, output string) error {
+			return telegram.Notify(bot, chatID, output, !scheduler.IsFinal(ctx))
+		})
+	}
+}
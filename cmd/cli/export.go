@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/pardnchiu/agenvoy/internal/transcript"
+)
+
+// * runExport handles `export`, the CLI session unless a session ID is given
+func runExport(args []string) {
+	usage := func() {
+		fmt.Println("Usage: go run cmd/cli/main.go export [<session>] [--format md|html|jsonl] [--out <file>] [--redact] [--meta]")
+		os.Exit(1)
+	}
+
+	var positional []string
+	format := transcript.FormatMarkdown
+	var out string
+	var opts transcript.Options
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--format", "--out":
+			if i+1 >= len(args) {
+				usage()
+			}
+			if args[i] == "--format" {
+				format = args[i+1]
+			} else {
+				out = args[i+1]
+			}
+			i++
+		case "--redact":
+			opts.Redact = true
+		case "--meta":
+			opts.Meta = true
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+	if len(positional) > 1 || !slices.Contains([]string{transcript.FormatMarkdown, transcript.FormatHTML, transcript.FormatJSONL}, format) {
+		usage()
+	}
+
+	var sessionID string
+	if len(positional) == 1 {
+		sessionID = positional[0]
+	}
+	t, err := transcript.Load(memorySession(sessionID))
+	if err != nil {
+		exitWith(fmt.Errorf("transcript.Load: %w", err))
+	}
+
+	if out == "" {
+		if err := transcript.Write(os.Stdout, t, format, opts); err != nil {
+			exitWith(fmt.Errorf("transcript.Write: %w", err))
+		}
+		return
+	}
+	file, err := os.Create(out)
+	if err != nil {
+		exitWith(fmt.Errorf("os.Create: %w", err))
+	}
+	if err := transcript.Write(file, t, format, opts); err != nil {
+		file.Close()
+		exitWith(fmt.Errorf("transcript.Write: %w", err))
+	}
+	if err := file.Close(); err != nil {
+		exitWith(fmt.Errorf("file.Close: %w", err))
+	}
+	fmt.Printf("exported %d turns to %s\n", len(t.Turns), out)
+}
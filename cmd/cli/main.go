@@ -13,7 +13,9 @@ import (
 	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
 	"github.com/pardnchiu/agenvoy/internal/filesystem"
 	"github.com/pardnchiu/agenvoy/internal/keychain"
+	"github.com/pardnchiu/agenvoy/internal/scheduler"
 	"github.com/pardnchiu/agenvoy/internal/skill"
+	"github.com/pardnchiu/agenvoy/internal/tools/lsp"
 )
 
 func main() {
@@ -30,9 +32,17 @@ func main() {
 		fmt.Println("  go run cmd/cli/main.go add")
 		fmt.Println("  go run cmd/cli/main.go remove")
 		fmt.Println("  go run cmd/cli/main.go list")
+		fmt.Println("  go run cmd/cli/main.go telegram")
 		fmt.Println("  go run cmd/cli/main.go list skills")
 		fmt.Println("  go run cmd/cli/main.go run <input...>")
 		fmt.Println("  go run cmd/cli/main.go run-allow <input...>")
+		fmt.Println("  go run cmd/cli/main.go daemon")
+		fmt.Println("  go run cmd/cli/main.go cron list|add|rm|enable|disable")
+		fmt.Println("  go run cmd/cli/main.go task list|add|rm")
+		fmt.Println("  go run cmd/cli/main.go memory show|edit|clear|export|import")
+		fmt.Println("  go run cmd/cli/main.go search <query...>")
+		fmt.Println("  go run cmd/cli/main.go export [<session>] --format md|html|jsonl")
+		fmt.Println("  go run cmd/cli/main.go replay [<session>] --agent <name> [--live]")
 		os.Exit(1)
 	}
 
@@ -46,6 +56,46 @@ func main() {
 		return
 	}
 
+	if os.Args[1] == "telegram" {
+		addAPIKey("Telegram Bot", "TELEGRAM_BOT_TOKEN")
+		return
+	}
+
+	if os.Args[1] == "daemon" {
+		runDaemon()
+		return
+	}
+
+	if os.Args[1] == "cron" {
+		runJobs(scheduler.KindCron, os.Args[2:])
+		return
+	}
+
+	if os.Args[1] == "task" {
+		runJobs(scheduler.KindTask, os.Args[2:])
+		return
+	}
+
+	if os.Args[1] == "memory" {
+		runMemory(os.Args[2:])
+		return
+	}
+
+	if os.Args[1] == "search" {
+		runSearch(os.Args[2:])
+		return
+	}
+
+	if os.Args[1] == "export" {
+		runExport(os.Args[2:])
+		return
+	}
+
+	if os.Args[1] == "replay" {
+		runReplay(os.Args[2:])
+		return
+	}
+
 	if os.Args[1] == "planner" {
 		runPlanner()
 		return
@@ -128,6 +178,7 @@ func main() {
 		skill.SyncSkills(ctx)
 		scanner := skill.NewScanner()
 		defer cancel()
+		defer lsp.Shutdown()
 
 		var selectorBot agentTypes.Agent
 		if cfg, err := keychain.Load(); err == nil && cfg.PlannerModel != "" {
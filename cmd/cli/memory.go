@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/promptui"
+
+	agentExec "github.com/pardnchiu/agenvoy/internal/agents/exec"
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+	"github.com/pardnchiu/agenvoy/internal/filesystem/sessionManager"
+	"github.com/pardnchiu/agenvoy/internal/memory"
+	"github.com/pardnchiu/agenvoy/internal/tools/file"
+)
+
+const (
+	scopeSummary = "summary"
+	scopeErrors  = "errors"
+	scopeFacts   = "facts"
+	scopeAll     = "all"
+)
+
+// * memoryBundle is the export format, sections left out are not touched on import
+type memoryBundle struct {
+	Version    int                           `json:"version"`
+	ExportedAt time.Time                     `json:"exported_at"`
+	SessionID  string                        `json:"session_id,omitempty"`
+	Summary    map[string]any                `json:"summary,omitempty"`
+	Errors     map[string][]file.ErrorMemory `json:"errors,omitempty"`
+	Facts      []*memory.Memory              `json:"facts,omitempty"`
+}
+
+// * runMemory handles `memory`, the summary belongs to the CLI session unless --session is given
+func runMemory(args []string) {
+	usage := func() {
+		fmt.Println("Usage: go run cmd/cli/main.go memory show [summary|errors|facts] [--session <id>]")
+		fmt.Println("       go run cmd/cli/main.go memory edit summary|errors [--session <id>]")
+		fmt.Println("       go run cmd/cli/main.go memory clear summary|errors|facts|all [--session <id>] [--yes]")
+		fmt.Println("       go run cmd/cli/main.go memory export [summary|errors|facts] [--session <id>] [--out <file>]")
+		fmt.Println("       go run cmd/cli/main.go memory import <file> [--session <id>] [--replace]")
+		os.Exit(1)
+	}
+	if len(args) == 0 {
+		usage()
+	}
+
+	positional, flags := parseMemoryFlags(args[1:])
+	scope := scopeAll
+	if len(positional) > 0 {
+		scope = positional[0]
+	}
+	if !slices.Contains([]string{scopeSummary, scopeErrors, scopeFacts, scopeAll}, scope) && args[0] != "import" {
+		usage()
+	}
+
+	switch args[0] {
+	case "show":
+		showMemory(scope, flags["--session"])
+
+	case "edit":
+		switch scope {
+		case scopeSummary:
+			editSummary(memorySession(flags["--session"]))
+		case scopeErrors:
+			editErrors()
+		default:
+			usage()
+		}
+
+	case "clear":
+		if len(positional) == 0 {
+			usage()
+		}
+		clearMemory(scope, flags["--session"], flags["--yes"] != "")
+
+	case "export":
+		exportMemory(scope, flags["--session"], flags["--out"])
+
+	case "import":
+		if len(positional) == 0 {
+			usage()
+		}
+		importMemory(positional[0], flags["--session"], flags["--replace"] != "")
+
+	default:
+		usage()
+	}
+}
+
+// * parseMemoryFlags splits positional args from --session / --out values and --yes / --replace switches
+func parseMemoryFlags(args []string) ([]string, map[string]string) {
+	var positional []string
+	flags := map[string]string{}
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--session", "--out":
+			if i+1 < len(args) {
+				flags[args[i]] = args[i+1]
+				i++
+			}
+		case "--yes", "--replace":
+			flags[args[i]] = "true"
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+	return positional, flags
+}
+
+// * memorySession resolves --session or the session the CLI last used
+func memorySession(sessionID string) string {
+	if sessionID == "" {
+		data, err := os.ReadFile(filesystem.ConfigPath)
+		if err == nil {
+			var index agentExec.IndexData
+			if json.Unmarshal(data, &index) == nil {
+				sessionID = strings.TrimSpace(index.SessionID)
+			}
+		}
+	}
+	if sessionID == "" {
+		exitWith(fmt.Errorf("no CLI session yet, pass --session <id>"))
+	}
+	if _, err := os.Stat(filepath.Join(filesystem.SessionsDir, sessionID)); err != nil {
+		exitWith(fmt.Errorf("session not found: %s", sessionID))
+	}
+	return sessionID
+}
+
+func showMemory(scope, sessionID string) {
+	if scope == scopeSummary || scope == scopeAll {
+		sessionID = memorySession(sessionID)
+		fmt.Printf("# summary (%s)\n", sessionID)
+		if _, summary := sessionManager.GetSummary(sessionID); summary != nil {
+			printJSON(summary)
+		} else {
+			fmt.Println("(empty)")
+		}
+	}
+
+	if scope == scopeErrors || scope == scopeAll {
+		fmt.Println("\n# errors")
+		byTool := file.ListErrorMemory()
+		if len(byTool) == 0 {
+			fmt.Println("(empty)")
+		}
+		tools := make([]string, 0, len(byTool))
+		for tool := range byTool {
+			tools = append(tools, tool)
+		}
+		sort.Strings(tools)
+		for _, tool := range tools {
+			fmt.Printf("%s (%d)\n", tool, len(byTool[tool]))
+			for _, record := range byTool[tool] {
+				fmt.Printf("  [%s] %s → %s\n", shortID(record.ID), record.Symptom, record.Action)
+			}
+		}
+	}
+
+	if scope == scopeFacts || scope == scopeAll {
+		fmt.Println("\n# facts")
+		facts, err := memory.List()
+		if err != nil {
+			exitWith(err)
+		}
+		if len(facts) == 0 {
+			fmt.Println("(empty)")
+		}
+		for _, fact := range facts {
+			fmt.Println(fact.String())
+		}
+	}
+}
+
+func editSummary(sessionID string) {
+	_, summary := sessionManager.GetSummary(sessionID)
+	if summary == nil {
+		summary = map[string]any{}
+	}
+	edited, ok := editJSON(summary, func(data []byte) (any, error) {
+		var result map[string]any
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		return result, sessionManager.ValidateSummary(result)
+	})
+	if !ok {
+		return
+	}
+	sessionManager.SaveSummary(sessionID, edited)
+	fmt.Printf("summary of %s saved\n", sessionID)
+}
+
+func editErrors() {
+	edited, ok := editJSON(file.ListErrorMemory(), func(data []byte) (any, error) {
+		var result map[string][]file.ErrorMemory
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		return result, file.ValidateErrorMemory(result)
+	})
+	if !ok {
+		return
+	}
+	if err := file.ReplaceErrorMemory(edited.(map[string][]file.ErrorMemory)); err != nil {
+		exitWith(err)
+	}
+	fmt.Println("error memory saved")
+}
+
+// * editJSON opens value in $EDITOR until it parses and validates, false when unchanged or discarded
+func editJSON(value any, parse func([]byte) (any, error)) (any, bool) {
+	original, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		exitWith(fmt.Errorf("json.Marshal: %w", err))
+	}
+
+	tmp, err := os.CreateTemp("", "agenvoy-memory-*.json")
+	if err != nil {
+		exitWith(fmt.Errorf("os.CreateTemp: %w", err))
+	}
+	defer os.Remove(tmp.Name())
+	// * exitWith skips deferred calls, the summaries must not stay behind in the temp dir
+	fail := func(err error) {
+		os.Remove(tmp.Name())
+		exitWith(err)
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		fail(fmt.Errorf("tmp.Chmod: %w", err))
+	}
+	_, err = tmp.Write(append(original, '\n'))
+	tmp.Close()
+	if err != nil {
+		fail(fmt.Errorf("tmp.Write: %w", err))
+	}
+
+	for {
+		if err := openEditor(tmp.Name()); err != nil {
+			fail(err)
+		}
+		data, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			fail(fmt.Errorf("os.ReadFile: %w", err))
+		}
+		if bytes.Equal(bytes.TrimSpace(data), bytes.TrimSpace(original)) {
+			fmt.Println("no changes")
+			return nil, false
+		}
+
+		result, err := parse(data)
+		if err == nil {
+			return result, true
+		}
+		fmt.Printf("invalid:\n%s\n", err)
+		retry := promptui.Select{
+			Label:        "Edit again?",
+			Items:        []string{"Edit again", "Discard"},
+			HideSelected: true,
+		}
+		if idx, _, err := retry.Run(); err != nil || idx == 1 {
+			fmt.Println("discarded")
+			return nil, false
+		}
+	}
+}
+
+// * openEditor runs $VISUAL or $EDITOR through the shell so `code --wait` works, vi otherwise
+func openEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", editor, err)
+	}
+	return nil
+}
+
+func clearMemory(scope, sessionID string, yes bool) {
+	if scope == scopeSummary || scope == scopeAll {
+		sessionID = memorySession(sessionID)
+	}
+	if !yes {
+		label := fmt.Sprintf("Clear %s memory?", scope)
+		if sessionID != "" {
+			label = fmt.Sprintf("Clear %s memory (session %s)?", scope, sessionID)
+		}
+		confirm := promptui.Select{
+			Label:        label,
+			Items:        []string{"No", "Yes"},
+			HideSelected: true,
+		}
+		if idx, _, err := confirm.Run(); err != nil || idx == 0 {
+			return
+		}
+	}
+
+	if scope == scopeSummary || scope == scopeAll {
+		if err := sessionManager.ClearSummary(sessionID); err != nil {
+			exitWith(err)
+		}
+	}
+	if scope == scopeErrors || scope == scopeAll {
+		if err := file.ReplaceErrorMemory(nil); err != nil {
+			exitWith(err)
+		}
+	}
+	if scope == scopeFacts || scope == scopeAll {
+		if err := memory.Replace(nil); err != nil {
+			exitWith(err)
+		}
+	}
+	fmt.Printf("%s memory cleared\n", scope)
+}
+
+func exportMemory(scope, sessionID, out string) {
+	bundle := memoryBundle{
+		Version:    1,
+		ExportedAt: time.Now(),
+	}
+	if scope == scopeSummary || scope == scopeAll {
+		bundle.SessionID = memorySession(sessionID)
+		_, bundle.Summary = sessionManager.GetSummary(bundle.SessionID)
+	}
+	if scope == scopeErrors || scope == scopeAll {
+		bundle.Errors = file.ListErrorMemory()
+	}
+	if scope == scopeFacts || scope == scopeAll {
+		facts, err := memory.List()
+		if err != nil {
+			exitWith(err)
+		}
+		bundle.Facts = facts
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		exitWith(fmt.Errorf("json.Marshal: %w", err))
+	}
+	if out == "" {
+		fmt.Println(string(data))
+		return
+	}
+	if err := filesystem.WriteFile(out, string(data), 0600); err != nil {
+		exitWith(fmt.Errorf("filesystem.WriteFile: %w", err))
+	}
+	fmt.Printf("exported to %s\n", out)
+}
+
+// * importMemory merges a bundle by default, --replace overwrites the sections it contains
+func importMemory(path, sessionID string, replace bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		exitWith(fmt.Errorf("os.ReadFile: %w", err))
+	}
+	var bundle memoryBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		exitWith(fmt.Errorf("json.Unmarshal: %w", err))
+	}
+	if bundle.Version != 1 {
+		exitWith(fmt.Errorf("unsupported bundle version %d", bundle.Version))
+	}
+
+	// * validate every section before writing any
+	if bundle.Summary != nil {
+		if err := sessionManager.ValidateSummary(bundle.Summary); err != nil {
+			exitWith(fmt.Errorf("summary:\n%w", err))
+		}
+	}
+	if err := file.ValidateErrorMemory(bundle.Errors); err != nil {
+		exitWith(fmt.Errorf("errors:\n%w", err))
+	}
+	if err := memory.Validate(bundle.Facts); err != nil {
+		exitWith(fmt.Errorf("facts:\n%w", err))
+	}
+
+	if bundle.Summary != nil {
+		sessionID = memorySession(sessionID)
+		summary := bundle.Summary
+		if _, old := sessionManager.GetSummary(sessionID); old != nil && !replace {
+			summary = agentExec.MergeSummary(old, summary)
+		}
+		sessionManager.SaveSummary(sessionID, summary)
+		fmt.Printf("summary imported into %s\n", sessionID)
+	}
+
+	if bundle.Errors != nil {
+		byTool := bundle.Errors
+		if !replace {
+			byTool = file.ListErrorMemory()
+			for tool, records := range bundle.Errors {
+				for _, record := range records {
+					if !slices.ContainsFunc(byTool[tool], func(r file.ErrorMemory) bool {
+						return record.ID != "" && r.ID == record.ID
+					}) {
+						byTool[tool] = append(byTool[tool], record)
+					}
+				}
+			}
+		}
+		if err := file.ReplaceErrorMemory(byTool); err != nil {
+			exitWith(err)
+		}
+		fmt.Println("error memory imported")
+	}
+
+	if bundle.Facts != nil {
+		if replace {
+			if err := memory.Replace(bundle.Facts); err != nil {
+				exitWith(err)
+			}
+			fmt.Printf("%d facts imported\n", len(bundle.Facts))
+		} else {
+			added, err := memory.Merge(bundle.Facts)
+			if err != nil {
+				exitWith(err)
+			}
+			fmt.Printf("%d new facts imported\n", added)
+		}
+	}
+}
+
+func printJSON(value any) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		exitWith(fmt.Errorf("json.Marshal: %w", err))
+	}
+	fmt.Println(string(data))
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"github.com/pardnchiu/agenvoy/internal/replay"
+	"github.com/pardnchiu/agenvoy/internal/skill"
+	"github.com/pardnchiu/agenvoy/internal/tools/lsp"
+)
+
+// * runReplay handles `replay`, the CLI session unless a session ID is given
+func runReplay(args []string) {
+	usage := func() {
+		fmt.Println("Usage: go run cmd/cli/main.go replay [<session>] --agent <name> [--live] [--allow-all] [--format md|json] [--out <file>]")
+		os.Exit(1)
+	}
+
+	var positional []string
+	var agentName, out string
+	format := replay.FormatMarkdown
+	mode := replay.ModeRecorded
+	allowAll := false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--agent", "--format", "--out":
+			if i+1 >= len(args) {
+				usage()
+			}
+			switch args[i] {
+			case "--agent":
+				agentName = args[i+1]
+			case "--format":
+				format = args[i+1]
+			default:
+				out = args[i+1]
+			}
+			i++
+		case "--live":
+			mode = replay.ModeLive
+		case "--allow-all":
+			allowAll = true
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+	if agentName == "" || len(positional) > 1 || !slices.Contains([]string{replay.FormatMarkdown, replay.FormatJSON}, format) {
+		usage()
+	}
+
+	var sessionID string
+	if len(positional) == 1 {
+		sessionID = positional[0]
+	}
+	sessionID = memorySession(sessionID)
+
+	agentRegistry := getAgentRegistry()
+	agent, ok := agentRegistry.Registry[agentName]
+	if !ok {
+		names := make([]string, 0, len(agentRegistry.Registry))
+		for name := range agentRegistry.Registry {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		exitWith(fmt.Errorf("unknown agent: %s, available: %s", agentName, strings.Join(names, ", ")))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	skill.SyncSkills(ctx)
+	defer lsp.Shutdown()
+
+	workDir, _ := os.Getwd()
+	report, err := replay.Run(ctx, sessionID, replay.Options{
+		Agent:    agent,
+		Mode:     mode,
+		WorkDir:  workDir,
+		Scanner:  skill.NewScanner(),
+		AllowAll: allowAll,
+		Confirm: func(name, _ string) bool {
+			prompt := promptui.Select{
+				Label:        fmt.Sprintf("Run %s?", name),
+				Items:        []string{"Yes", "Skip"},
+				Size:         2,
+				HideSelected: true,
+			}
+			idx, _, err := prompt.Run()
+			return err == nil && idx == 0
+		},
+		Progress: func(index, total int, input string) {
+			line, _, _ := strings.Cut(input, "\n")
+			if runes := []rune(line); len(runes) > 60 {
+				line = string(runes[:60]) + "…"
+			}
+			fmt.Fprintf(os.Stderr, "[~] Turn %d/%d: %s\n", index, total, line)
+		},
+	})
+	if err != nil {
+		exitWith(fmt.Errorf("replay.Run: %w", err))
+	}
+
+	if out == "" {
+		if err := replay.Write(os.Stdout, report, format); err != nil {
+			exitWith(fmt.Errorf("replay.Write: %w", err))
+		}
+		return
+	}
+	file, err := os.Create(out)
+	if err != nil {
+		exitWith(fmt.Errorf("os.Create: %w", err))
+	}
+	if err := replay.Write(file, report, format); err != nil {
+		file.Close()
+		exitWith(fmt.Errorf("replay.Write: %w", err))
+	}
+	if err := file.Close(); err != nil {
+		exitWith(fmt.Errorf("file.Close: %w", err))
+	}
+	fmt.Printf("replayed %d turns into session %s, report at %s\n", len(report.Turns), report.ReplaySessionID, out)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pardnchiu/agenvoy/internal/scheduler"
+)
+
+// * runJobs handles `cron` and `task`, both go through the running scheduler
+func runJobs(kind string, args []string) {
+	usage := func() {
+		when := "<at>"
+		if kind == scheduler.KindCron {
+			when = `"<expr>"`
+		}
+		fmt.Printf("Usage: go run cmd/cli/main.go %s list\n", kind)
+		fmt.Printf("       go run cmd/cli/main.go %s add %s <script> [--env NAME=value|keychain:NAME]... [--target <target>] [--timeout <duration>] [--tz <zone>] [--misfire skip|once|all] [--desc <text>]\n", kind, when)
+		fmt.Printf("       go run cmd/cli/main.go %s add %s --prompt <text> [--skill <name>] [--agent <model>] [--target <target>] [--timeout <duration>] [--tz <zone>] [--misfire skip|once|all] [--desc <text>]\n", kind, when)
+		fmt.Printf("       go run cmd/cli/main.go %s rm <id>\n", kind)
+		fmt.Printf("       go run cmd/cli/main.go %s enable|disable <id>\n", kind)
+		os.Exit(1)
+	}
+	if len(args) == 0 {
+		usage()
+	}
+
+	mgr, err := scheduler.Current()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "list":
+		jobs, err := mgr.Jobs(kind)
+		if err != nil {
+			exitWith(err)
+		}
+		if len(jobs) == 0 {
+			fmt.Printf("No %s found\n", kind)
+			return
+		}
+		for _, job := range jobs {
+			fmt.Println(job.String())
+		}
+
+	case "add":
+		positional, job := parseJobFlags(args[1:])
+		// * a prompt takes the place of the script
+		want := 2
+		if job.Prompt != "" {
+			want = 1
+		}
+		if len(positional) < want {
+			usage()
+		}
+		when := strings.Join(positional, " ")
+		if want == 2 {
+			when = strings.Join(positional[:len(positional)-1], " ")
+			job.Script = positional[len(positional)-1]
+		}
+		if job.Target == "" {
+			job.Target = scheduler.TargetStdout
+		}
+
+		var added *scheduler.Job
+		if kind == scheduler.KindCron {
+			added, err = mgr.AddCron(when, job)
+		} else {
+			added, err = mgr.AddTask(when, job)
+		}
+		if err != nil {
+			exitWith(err)
+		}
+		fmt.Println(added.String())
+
+	case "rm", "remove":
+		if len(args) != 2 {
+			usage()
+		}
+		if err := scheduler.RemoveKind(mgr, kind, args[1]); err != nil {
+			exitWith(err)
+		}
+		fmt.Printf("%s %s removed\n", kind, args[1])
+
+	case "enable", "disable":
+		if len(args) != 2 {
+			usage()
+		}
+		if err := mgr.SetEnabled(args[1], args[0] == "enable"); err != nil {
+			exitWith(err)
+		}
+		fmt.Printf("%s %s %sd\n", kind, args[1], args[0])
+
+	default:
+		usage()
+	}
+}
+
+// * parseJobFlags splits flags from the schedule and script, an unquoted schedule may span several args
+func parseJobFlags(args []string) ([]string, scheduler.Job) {
+	var positional []string
+	var job scheduler.Job
+	flags := map[string]*string{
+		"--target":  &job.Target,
+		"--desc":    &job.Description,
+		"--prompt":  &job.Prompt,
+		"--skill":   &job.Skill,
+		"--agent":   &job.Agent,
+		"--timeout": &job.Timeout,
+		"--tz":      &job.Timezone,
+		"--misfire": &job.Misfire,
+	}
+	for i := 0; i < len(args); i++ {
+		// * --env NAME=value, repeatable, value may be keychain:NAME
+		if args[i] == "--env" && i+1 < len(args) {
+			i++
+			name, value, _ := strings.Cut(args[i], "=")
+			if job.Env == nil {
+				job.Env = map[string]string{}
+			}
+			job.Env[name] = value
+			continue
+		}
+		if field, ok := flags[args[i]]; ok {
+			if i+1 < len(args) {
+				i++
+				*field = args[i]
+			}
+			continue
+		}
+		positional = append(positional, args[i])
+	}
+	return positional, job
+}
+
+func exitWith(err error) {
+	fmt.Println(err.Error())
+	os.Exit(1)
+}
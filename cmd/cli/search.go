@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/history"
+)
+
+// * runSearch handles `search`, ranked full-text search over every session's history and tool calls
+func runSearch(args []string) {
+	usage := func() {
+		fmt.Println("Usage: go run cmd/cli/main.go search <query...> [--since <date>] [--until <date>] [--range 1d|7d|1m|1y] [--session <id>] [--limit <n>]")
+		fmt.Println("       go run cmd/cli/main.go search --reindex")
+		os.Exit(1)
+	}
+
+	var terms []string
+	flags := map[string]string{}
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--since", "--until", "--range", "--session", "--limit":
+			if i+1 >= len(args) {
+				usage()
+			}
+			flags[args[i]] = args[i+1]
+			i++
+		case "--reindex":
+			flags[args[i]] = "true"
+		default:
+			terms = append(terms, args[i])
+		}
+	}
+
+	if flags["--reindex"] != "" {
+		count, err := history.Rebuild()
+		if err != nil {
+			exitWith(fmt.Errorf("history.Rebuild: %w", err))
+		}
+		fmt.Printf("indexed %d messages\n", count)
+		if len(terms) == 0 {
+			return
+		}
+	}
+	if len(terms) == 0 {
+		usage()
+	}
+
+	query := history.Query{
+		Text:    strings.Join(terms, " "),
+		Session: flags["--session"],
+		Limit:   20,
+	}
+	if value := flags["--range"]; value != "" {
+		d, ok := history.Ranges[value]
+		if !ok {
+			exitWith(fmt.Errorf("invalid --range: %s", value))
+		}
+		query.Since = time.Now().Add(-d)
+	}
+	if value := flags["--since"]; value != "" {
+		since, err := history.ParseTime(value, false)
+		if err != nil {
+			exitWith(fmt.Errorf("--since: %w", err))
+		}
+		query.Since = since
+	}
+	if value := flags["--until"]; value != "" {
+		until, err := history.ParseTime(value, true)
+		if err != nil {
+			exitWith(fmt.Errorf("--until: %w", err))
+		}
+		query.Until = until
+	}
+	if value := flags["--limit"]; value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			exitWith(fmt.Errorf("invalid --limit: %s", value))
+		}
+		query.Limit = limit
+	}
+
+	hits, err := history.Search(query)
+	if err != nil {
+		exitWith(fmt.Errorf("history.Search: %w", err))
+	}
+	if len(hits) == 0 {
+		fmt.Println("No matches")
+		return
+	}
+	for _, hit := range hits {
+		fmt.Println(hit.String())
+	}
+}
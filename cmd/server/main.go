@@ -20,8 +20,13 @@ import (
 	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
 	"github.com/pardnchiu/agenvoy/internal/discord"
 	"github.com/pardnchiu/agenvoy/internal/filesystem"
+	"github.com/pardnchiu/agenvoy/internal/frontend"
 	"github.com/pardnchiu/agenvoy/internal/keychain"
+	"github.com/pardnchiu/agenvoy/internal/scheduler"
 	"github.com/pardnchiu/agenvoy/internal/skill"
+	"github.com/pardnchiu/agenvoy/internal/slack"
+	"github.com/pardnchiu/agenvoy/internal/telegram"
+	"github.com/pardnchiu/agenvoy/internal/tools/lsp"
 )
 
 func init() {
@@ -55,19 +60,93 @@ func main() {
 	slog.Info("agent registry built",
 		slog.Int("entries", len(registry.Entries)),
 		slog.String("fallback", registry.Fallback.Name()))
-	bot, err := discord.New(selectorBot, registry, scanner)
-	if bot != nil {
-		defer discord.Close(bot)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// * a running daemon owns the jobs, tools reach it through the control socket
+	daemon := scheduler.Running()
+	if daemon {
+		slog.Info("scheduler daemon detected, local scheduler disabled")
+	} else {
+		scheduler.RegisterPromptRunner(func(ctx context.Context, job scheduler.Job) (string, error) {
+			return frontend.RunJob(ctx, frontend.Bot{
+				PlannerAgent:  selectorBot,
+				AgentRegistry: registry,
+				SkillScanner:  scanner,
+			}, job)
+		})
+		startScheduler(ctx)
+		defer scheduler.Stop()
+	}
+	defer lsp.Shutdown()
+
+	dcBot, err := discord.New(selectorBot, registry, scanner)
+	if dcBot != nil {
+		defer discord.Close(dcBot)
 	}
 	if err != nil {
 		slog.Error("failed to start bot", slog.String("error", err.Error()))
 		return
 	}
-	if bot == nil {
-		slog.Warn("DISCORD_TOKEN not set, bot disabled")
+	if dcBot == nil {
+		slog.Warn("DISCORD_TOKEN not set, discord bot disabled")
+	}
+
+	slackBot, err := slack.New(selectorBot, registry, scanner)
+	if slackBot != nil {
+		defer slack.Close(slackBot)
+	}
+	if err != nil {
+		slog.Error("failed to start slack bot", slog.String("error", err.Error()))
+		return
+	}
+	if slackBot == nil {
+		slog.Warn("SLACK_BOT_TOKEN not set, slack bot disabled")
+	}
+
+	tgBot, err := telegram.New(selectorBot, registry, scanner)
+	if tgBot != nil {
+		defer telegram.Close(tgBot)
+	}
+	if err != nil {
+		slog.Error("failed to start telegram bot", slog.String("error", err.Error()))
 		return
 	}
+	if tgBot == nil {
+		slog.Warn("TELEGRAM_BOT_TOKEN not set, telegram bot disabled")
+	}
 
+	if dcBot == nil && slackBot == nil && tgBot == nil {
+		return
+	}
+
+	if daemon {
+		waitSignal()
+		return
+	}
+
+	// * outputs for a disabled bot wait in the dead letter queue until it runs again
+	if dcBot != nil {
+		scheduler.RegisterHandler(scheduler.TargetDiscord, func(ctx context.Context, channelID, output string) error {
+			return discord.Notify(dcBot, channelID, output, !scheduler.IsFinal(ctx))
+		})
+	}
+	if slackBot != nil {
+		scheduler.RegisterHandler(scheduler.TargetSlack, func(ctx context.Context, channelID, output string) error {
+			return slack.Notify(slackBot, channelID, output, !scheduler.IsFinal(ctx))
+		})
+	}
+	if tgBot != nil {
+		scheduler.RegisterHandler(scheduler.TargetTelegram, func(ctx context.Context, chatID, output string) error {
+			return telegram.Notify(tgBot, chatID, output, !scheduler.IsFinal(ctx))
+		})
+	}
+	go scheduler.RetryDeadLetters()
+
+	waitSignal()
+}
+
+func waitSignal() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -75,6 +154,25 @@ func main() {
 	slog.Info("signal received, shutting down")
 }
 
+func startScheduler(ctx context.Context) {
+	if err := scheduler.New(); err != nil {
+		slog.Warn("scheduler.New",
+			slog.String("error", err.Error()))
+		return
+	}
+	if err := scheduler.Get().Load(); err != nil {
+		slog.Warn("scheduler.Get().Load",
+			slog.String("error", err.Error()))
+	}
+	// * lets `agenvoy cron` and `agenvoy task` manage the jobs of this server
+	go func() {
+		if err := scheduler.Serve(ctx); err != nil {
+			slog.Warn("scheduler.Serve",
+				slog.String("error", err.Error()))
+		}
+	}()
+}
+
 func buildAgentRegistry() agentTypes.AgentRegistry {
 	newFn := map[string]func(string) (agentTypes.Agent, error){
 		"copilot": func(m string) (agentTypes.Agent, error) { return copilot.New(m) },
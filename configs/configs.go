@@ -18,12 +18,24 @@ var SkillExecution string
 //go:embed prompts/summary_prompt.md
 var SummaryPrompt string
 
+//go:embed prompts/memory_prompt.md
+var MemoryPrompt string
+
 //go:embed prompts/system_prompt.md
 var SystemPrompt string
 
 //go:embed prompts/discord_system_prompt.md
 var DiscordSystemPrompt string
 
+//go:embed prompts/slack_system_prompt.md
+var SlackSystemPrompt string
+
+//go:embed prompts/telegram_system_prompt.md
+var TelegramSystemPrompt string
+
+//go:embed prompts/scheduled_system_prompt.md
+var ScheduledSystemPrompt string
+
 // * Configs
 
 //go:embed jsons/denied_map.json
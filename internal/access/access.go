@@ -0,0 +1,162 @@
+package access
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+)
+
+var ErrNotAllowed = errors.New("not allowed")
+
+type Tier string
+
+const (
+	TierNone     Tier = "none"      // * no tool calls
+	TierReadOnly Tier = "read_only" // * read-only tools only
+	TierStandard Tier = "standard"  // * read-only tools pass, others need approval
+	TierFull     Tier = "full"      // * every tool passes without approval
+)
+
+var tierRank = map[Tier]int{
+	TierNone:     0,
+	TierReadOnly: 1,
+	TierStandard: 2,
+	TierFull:     3,
+}
+
+// * quota of 0 means unlimited
+type Policy struct {
+	Tier          Tier `json:"tier"`
+	DailyRequests int  `json:"daily_requests,omitempty"`
+	DailyTokens   int  `json:"daily_tokens,omitempty"`
+}
+
+// * one frontend's section of ~/.config/agenvoy/access.json, empty guild or channel list allows all
+type Config struct {
+	Guilds   []string          `json:"guilds,omitempty"` // * discord only
+	Channels []string          `json:"channels,omitempty"`
+	Users    map[string]Policy `json:"users,omitempty"`
+	Roles    map[string]Policy `json:"roles,omitempty"`   // * discord only
+	Default  *Policy           `json:"default,omitempty"` // * members matching no user or role, nil denies them
+}
+
+type Principal struct {
+	GuildID   string
+	ChannelID string
+	ParentID  string // * parent channel when ChannelID is a thread
+	UserID    string
+	Roles     []string
+	Direct    bool // * direct messages have no guild or channel to restrict
+}
+
+// * Load returns the section of frontend, nil when access.json or the section does not exist
+func Load(frontend string) (*Config, error) {
+	data, err := os.ReadFile(filesystem.AccessPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	var sections map[string]*Config
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	return sections[frontend], nil
+}
+
+// * Resolve picks the user policy first, then the most permissive matching role, then default
+func (c *Config) Resolve(p Principal) (Policy, error) {
+	if c == nil {
+		return Policy{Tier: TierStandard}, nil
+	}
+
+	if !p.Direct {
+		if len(c.Guilds) > 0 && !slices.Contains(c.Guilds, p.GuildID) {
+			return Policy{}, ErrNotAllowed
+		}
+		if len(c.Channels) > 0 && !slices.Contains(c.Channels, p.ChannelID) && (p.ParentID == "" || !slices.Contains(c.Channels, p.ParentID)) {
+			return Policy{}, ErrNotAllowed
+		}
+	}
+
+	if policy, ok := c.Users[p.UserID]; ok {
+		return policy.normalize(), nil
+	}
+
+	var matched *Policy
+	for _, roleID := range p.Roles {
+		policy, ok := c.Roles[roleID]
+		if !ok {
+			continue
+		}
+		policy = policy.normalize()
+		if matched == nil {
+			matched = &policy
+			continue
+		}
+		merged := merge(*matched, policy)
+		matched = &merged
+	}
+	if matched != nil {
+		return *matched, nil
+	}
+
+	if c.Default != nil {
+		return c.Default.normalize(), nil
+	}
+	return Policy{}, ErrNotAllowed
+}
+
+func (p Policy) normalize() Policy {
+	if _, ok := tierRank[p.Tier]; !ok {
+		p.Tier = TierStandard
+	}
+	return p
+}
+
+func merge(a, b Policy) Policy {
+	if tierRank[b.Tier] > tierRank[a.Tier] {
+		a.Tier = b.Tier
+	}
+	a.DailyRequests = looser(a.DailyRequests, b.DailyRequests)
+	a.DailyTokens = looser(a.DailyTokens, b.DailyTokens)
+	return a
+}
+
+func looser(a, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return max(a, b)
+}
+
+// * ToolDecision reports whether a tool may run and whether it still needs approval
+func (p Policy) ToolDecision(readOnly bool) (allow, ask bool) {
+	switch p.Tier {
+	case TierFull:
+		return true, false
+	case TierReadOnly:
+		return readOnly, false
+	case TierNone:
+		return false, false
+	default:
+		return true, !readOnly
+	}
+}
+
+// * Exceeded names the daily quota once it is used up, empty otherwise
+func (p Policy) Exceeded(u Usage) string {
+	if p.DailyRequests > 0 && u.Requests >= p.DailyRequests {
+		return fmt.Sprintf("daily request limit (%d)", p.DailyRequests)
+	}
+	if p.DailyTokens > 0 && u.Tokens >= p.DailyTokens {
+		return fmt.Sprintf("daily token limit (%d)", p.DailyTokens)
+	}
+	return ""
+}
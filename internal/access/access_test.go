@@ -0,0 +1,108 @@
+package access
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+)
+
+func TestResolve(t *testing.T) {
+	config := &Config{
+		Guilds:   []string{"g1"},
+		Channels: []string{"c1"},
+		Users: map[string]Policy{
+			"owner": {Tier: TierFull},
+		},
+		Roles: map[string]Policy{
+			"reader": {Tier: TierReadOnly, DailyRequests: 10, DailyTokens: 1000},
+			"member": {Tier: TierStandard, DailyRequests: 50},
+			"guest":  {Tier: "unknown", DailyRequests: 5, DailyTokens: 0},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		p       Principal
+		want    Policy
+		wantErr error
+	}{
+		{"user wins over roles", Principal{GuildID: "g1", ChannelID: "c1", UserID: "owner", Roles: []string{"reader"}}, Policy{Tier: TierFull}, nil},
+		{"single role", Principal{GuildID: "g1", ChannelID: "c1", UserID: "u", Roles: []string{"reader"}}, Policy{Tier: TierReadOnly, DailyRequests: 10, DailyTokens: 1000}, nil},
+		{"roles merge to looser", Principal{GuildID: "g1", ChannelID: "c1", UserID: "u", Roles: []string{"reader", "member"}}, Policy{Tier: TierStandard, DailyRequests: 50, DailyTokens: 0}, nil},
+		{"unknown tier falls back", Principal{GuildID: "g1", ChannelID: "c1", UserID: "u", Roles: []string{"guest"}}, Policy{Tier: TierStandard, DailyRequests: 5}, nil},
+		{"thread of allowed channel", Principal{GuildID: "g1", ChannelID: "t1", ParentID: "c1", UserID: "owner"}, Policy{Tier: TierFull}, nil},
+		{"guild not allowed", Principal{GuildID: "g2", ChannelID: "c1", UserID: "owner"}, Policy{}, ErrNotAllowed},
+		{"channel not allowed", Principal{GuildID: "g1", ChannelID: "c2", UserID: "owner"}, Policy{}, ErrNotAllowed},
+		{"no match without default", Principal{GuildID: "g1", ChannelID: "c1", UserID: "u"}, Policy{}, ErrNotAllowed},
+		{"dm skips guild and channel", Principal{ChannelID: "dm", UserID: "owner", Direct: true}, Policy{Tier: TierFull}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := config.Resolve(tt.p)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	config.Default = &Policy{Tier: TierNone, DailyRequests: 1}
+	got, err := config.Resolve(Principal{GuildID: "g1", ChannelID: "c1", UserID: "u"})
+	if err != nil || got != *config.Default {
+		t.Errorf("Resolve() with default = %+v, %v", got, err)
+	}
+
+	var empty *Config
+	if got, err := empty.Resolve(Principal{UserID: "u"}); err != nil || got.Tier != TierStandard {
+		t.Errorf("nil config Resolve() = %+v, %v", got, err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	saved := filesystem.AccessPath
+	t.Cleanup(func() { filesystem.AccessPath = saved })
+	filesystem.AccessPath = filepath.Join(t.TempDir(), "access.json")
+
+	if config, err := Load("discord"); err != nil || config != nil {
+		t.Fatalf("missing file Load() = %+v, %v", config, err)
+	}
+
+	data := `{"discord": {"users": {"1": {"tier": "full"}}}, "telegram": {"default": {"tier": "read_only"}}}`
+	if err := os.WriteFile(filesystem.AccessPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// * sections keep user IDs of different frontends apart
+	if config, err := Load("telegram"); err != nil || config == nil || config.Users["1"].Tier != "" || config.Default.Tier != TierReadOnly {
+		t.Errorf("telegram Load() = %+v, %v", config, err)
+	}
+	if config, err := Load("slack"); err != nil || config != nil {
+		t.Errorf("missing section Load() = %+v, %v", config, err)
+	}
+}
+
+func TestToolDecision(t *testing.T) {
+	tests := []struct {
+		tier      Tier
+		readOnly  bool
+		wantAllow bool
+		wantAsk   bool
+	}{
+		{TierFull, false, true, false},
+		{TierStandard, true, true, false},
+		{TierStandard, false, true, true},
+		{TierReadOnly, true, true, false},
+		{TierReadOnly, false, false, false},
+		{TierNone, true, false, false},
+	}
+	for _, tt := range tests {
+		allow, ask := Policy{Tier: tt.tier}.ToolDecision(tt.readOnly)
+		if allow != tt.wantAllow || ask != tt.wantAsk {
+			t.Errorf("%s readOnly=%v: got (%v, %v), want (%v, %v)", tt.tier, tt.readOnly, allow, ask, tt.wantAllow, tt.wantAsk)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package access
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+)
+
+type Usage struct {
+	Requests int `json:"requests"`
+	Tokens   int `json:"tokens"`
+}
+
+var usageMu sync.Mutex
+
+// * one file per local day, ~/.config/agenvoy/usage/{date}.json keyed by "frontend:user"
+func usagePath(date string) string {
+	return filepath.Join(filesystem.UsageDir, date+".json")
+}
+
+func today() string {
+	return time.Now().Format("2006-01-02")
+}
+
+func Daily(date string) (map[string]Usage, error) {
+	data, err := os.ReadFile(usagePath(date))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]Usage{}, nil
+		}
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	var usage map[string]Usage
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	if usage == nil {
+		usage = map[string]Usage{}
+	}
+	return usage, nil
+}
+
+func Today(principal string) Usage {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+
+	usage, err := Daily(today())
+	if err != nil {
+		return Usage{}
+	}
+	return usage[principal]
+}
+
+func AddRequest(principal string) error {
+	return add(principal, 1, 0)
+}
+
+func AddTokens(principal string, tokens int) error {
+	if tokens <= 0 {
+		return nil
+	}
+	return add(principal, 0, tokens)
+}
+
+func add(principal string, requests, tokens int) error {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+
+	date := today()
+	usage, err := Daily(date)
+	if err != nil {
+		return err
+	}
+	u := usage[principal]
+	u.Requests += requests
+	u.Tokens += tokens
+	usage[principal] = u
+
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+	if err := filesystem.WriteFile(usagePath(date), string(data), 0644); err != nil {
+		return fmt.Errorf("WriteFile: %w", err)
+	}
+	return nil
+}
@@ -32,6 +32,10 @@ type ExecData struct {
 	Content     string
 	ImageInputs []string
 	FileInputs  []string
+	// * "frontend:user" of a chat request, empty for the CLI and replays
+	Principal string
+	// * serves tool results from a recorded session instead of running the tools
+	Recorded func(name, args string) (string, bool)
 }
 
 func Execute(ctx context.Context, data ExecData, session *agentTypes.AgentSession, events chan<- agentTypes.Event, allowAll bool) error {
@@ -44,12 +48,36 @@ func Execute(ctx context.Context, data ExecData, session *agentTypes.AgentSessio
 	if err != nil {
 		return fmt.Errorf("tools.NewExecutor: %w", err)
 	}
+	exec.Principal = data.Principal
+	exec.Vision = data.Agent.Vision()
+	exec.Recorded = data.Recorded
 
 	limit := MaxToolIterations
 	if data.Skill != nil {
 		limit = MaxSkillIterations
 	}
 
+	turn := sessionManager.Turn{
+		StartedAt: time.Now(),
+		Agent:     data.Agent.Name(),
+	}
+	if data.Skill != nil {
+		turn.Skill = strings.TrimSpace(data.Skill.Name)
+	}
+	// * every exit records the turn, the tool log included, so exports and replays see what ran
+	defer func() {
+		if len(session.Tools) > 0 {
+			if data, err := json.Marshal(session.Tools); err == nil {
+				turn.ToolCalls = sessionManager.SaveToToolCall(session.ID, string(data))
+			}
+		}
+		turn.EndedAt = time.Now()
+		if err := sessionManager.SaveTurn(session.ID, turn); err != nil {
+			slog.Warn("sessionManager.SaveTurn",
+				slog.String("error", err.Error()))
+		}
+	}()
+
 	alreadyCall := make(map[string]string)
 	emptyCount := 0
 	for i := 0; i < limit; i++ {
@@ -62,6 +90,8 @@ func Execute(ctx context.Context, data ExecData, session *agentTypes.AgentSessio
 				slog.String("error", err.Error()))
 			continue
 		}
+		emitUsage(events, resp)
+		addUsage(&turn, resp)
 
 		if len(resp.Choices) == 0 {
 			if actionError(&emptyCount, events) {
@@ -123,12 +153,6 @@ func Execute(ctx context.Context, data ExecData, session *agentTypes.AgentSessio
 		}
 
 		events <- agentTypes.Event{Type: agentTypes.EventDone}
-
-		if len(session.Tools) > 0 {
-			if data, err := json.Marshal(session.Tools); err == nil {
-				sessionManager.SaveToToolCall(session.ID, string(data))
-			}
-		}
 		return nil
 	}
 
@@ -137,6 +161,10 @@ func Execute(ctx context.Context, data ExecData, session *agentTypes.AgentSessio
 		Content: "請根據以上工具查詢結果，整理並總結回答原始問題。",
 	})
 	resp, err := data.Agent.Send(ctx, summaryMessages, nil)
+	if err == nil {
+		emitUsage(events, resp)
+		addUsage(&turn, resp)
+	}
 	if err == nil && len(resp.Choices) > 0 {
 		if text, ok := resp.Choices[0].Message.Content.(string); ok && text != "" {
 			cleaned := extractSummary(session.ID, text)
@@ -151,6 +179,26 @@ func Execute(ctx context.Context, data ExecData, session *agentTypes.AgentSessio
 	return nil
 }
 
+// * frontends count tokens per request, providers without usage report nothing
+func emitUsage(events chan<- agentTypes.Event, resp *agentTypes.Output) {
+	if resp.Usage == nil || resp.Usage.TotalTokens == 0 {
+		return
+	}
+	events <- agentTypes.Event{
+		Type:   agentTypes.EventUsage,
+		Tokens: resp.Usage.TotalTokens,
+	}
+}
+
+func addUsage(turn *sessionManager.Turn, resp *agentTypes.Output) {
+	if resp.Usage == nil {
+		return
+	}
+	turn.PromptTokens += resp.Usage.PromptTokens
+	turn.CompletionTokens += resp.Usage.CompletionTokens
+	turn.TotalTokens += resp.Usage.TotalTokens
+}
+
 func GetSystemPrompt(data ExecData) string {
 	systemOS := runtime.GOOS
 	localtime := time.Now().Format("2006-01-02 15:04:05 MST")
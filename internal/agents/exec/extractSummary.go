@@ -64,7 +64,7 @@ func extractSummary(sessionID, value string) string {
 		if newMap, ok := jsonData.(map[string]any); ok {
 			_, oldMap := sessionManager.GetSummary(sessionID)
 			if oldMap != nil {
-				newMap = mergeSummary(oldMap, newMap)
+				newMap = MergeSummary(oldMap, newMap)
 			}
 			jsonData = newMap
 		}
@@ -73,7 +73,8 @@ func extractSummary(sessionID, value string) string {
 	return cleaned
 }
 
-func mergeSummary(old, new map[string]any) map[string]any {
+// * MergeSummary appends old list entries missing from new and keeps old discussion_log topics
+func MergeSummary(old, new map[string]any) map[string]any {
 	arrayFields := []string{
 		"confirmed_needs", "constraints", "excluded_options", "key_data", "current_conclusion",
 	}
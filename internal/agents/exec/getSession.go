@@ -1,6 +1,7 @@
 package exec
 
 import (
+	"context"
 	_ "embed"
 	"encoding/base64"
 	"encoding/json"
@@ -14,6 +15,7 @@ import (
 	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
 	"github.com/pardnchiu/agenvoy/internal/filesystem"
 	"github.com/pardnchiu/agenvoy/internal/filesystem/sessionManager"
+	"github.com/pardnchiu/agenvoy/internal/memory"
 )
 
 const (
@@ -63,18 +65,7 @@ func buildContent(content string, imageInputs []string, fileInputs []string) any
 }
 
 func GetSession(execData ExecData) (*agentTypes.AgentSession, error) {
-	prompt := GetSystemPrompt(execData)
-	trimInput := strings.TrimSpace(execData.Content)
-	session := agentTypes.AgentSession{
-		Tools: []agentTypes.Message{},
-		Messages: []agentTypes.Message{
-			{
-				Role:    "system",
-				Content: prompt,
-			},
-		},
-		Histories: []agentTypes.Message{},
-	}
+	session := newSession(execData)
 
 	unlock, err := sessionManager.LockConfig()
 	if err != nil {
@@ -115,36 +106,7 @@ func GetSession(execData ExecData) (*agentTypes.AgentSession, error) {
 		}
 		sessionID = strings.TrimSpace(indexData.SessionID)
 
-		oldHistory, maxHistory := sessionManager.GetHistory(sessionID)
-		session.Histories = oldHistory
-		if len(oldHistory) > len(maxHistory) && len(maxHistory) > 0 {
-			copied := make([]agentTypes.Message, len(maxHistory))
-			copy(copied, maxHistory)
-			if text, ok := copied[0].Content.(string); ok {
-				// * for agent to know thie content is cut
-				copied[0].Content = "...\n" + text
-			}
-			maxHistory = copied
-		}
-		session.Messages = append(session.Messages, maxHistory...)
-
-		// * insert summary prompt every time
-		if summary := sessionManager.GetSummaryPrompt(sessionID); summary != "" {
-			session.Messages = append(session.Messages, agentTypes.Message{
-				Role:    "system",
-				Content: summary,
-			})
-		}
-
-		userText := fmt.Sprintf("---\n當前時間: %s\n---\n%s", time.Now().Format("2006-01-02 15:04:05"), trimInput)
-		session.Histories = append(session.Histories, agentTypes.Message{
-			Role:    "user",
-			Content: userText,
-		})
-		session.Messages = append(session.Messages, agentTypes.Message{
-			Role:    "user",
-			Content: buildContent(userText, execData.ImageInputs, execData.FileInputs),
-		})
+		appendTurn(&session, execData, sessionID)
 
 	case os.IsNotExist(configErr):
 		// * config is not exist
@@ -153,15 +115,8 @@ func GetSession(execData ExecData) (*agentTypes.AgentSession, error) {
 			return nil, fmt.Errorf("newSessionID: %w", err)
 		}
 
-		userText := fmt.Sprintf("---\n當前時間: %s\n---\n%s", time.Now().Format("2006-01-02 15:04:05"), trimInput)
-		session.Histories = append(session.Histories, agentTypes.Message{
-			Role:    "user",
-			Content: userText,
-		})
-		session.Messages = append(session.Messages, agentTypes.Message{
-			Role:    "user",
-			Content: buildContent(userText, execData.ImageInputs, execData.FileInputs),
-		})
+		// * a fresh session has no history or summary yet, memories outlive sessions
+		appendTurn(&session, execData, sessionID)
 
 		indexDataBytes, err := json.Marshal(IndexData{SessionID: sessionID})
 		if err != nil {
@@ -191,3 +146,66 @@ func GetSession(execData ExecData) (*agentTypes.AgentSession, error) {
 
 	return &session, nil
 }
+
+// * LoadSession builds a turn on an existing sessionID, config.json is neither read nor written
+func LoadSession(execData ExecData, sessionID string) *agentTypes.AgentSession {
+	session := newSession(execData)
+	appendTurn(&session, execData, sessionID)
+	session.ID = sessionID
+	return &session
+}
+
+func newSession(execData ExecData) agentTypes.AgentSession {
+	return agentTypes.AgentSession{
+		Tools: []agentTypes.Message{},
+		Messages: []agentTypes.Message{
+			{
+				Role:    "system",
+				Content: GetSystemPrompt(execData),
+			},
+		},
+		Histories: []agentTypes.Message{},
+	}
+}
+
+// * appendTurn loads the recent history, summary and memories of sessionID and adds the user input
+func appendTurn(session *agentTypes.AgentSession, execData ExecData, sessionID string) {
+	trimInput := strings.TrimSpace(execData.Content)
+
+	oldHistory, maxHistory := sessionManager.GetHistory(sessionID)
+	session.Histories = oldHistory
+	if len(oldHistory) > len(maxHistory) && len(maxHistory) > 0 {
+		copied := make([]agentTypes.Message, len(maxHistory))
+		copy(copied, maxHistory)
+		if text, ok := copied[0].Content.(string); ok {
+			// * for agent to know thie content is cut
+			copied[0].Content = "...\n" + text
+		}
+		maxHistory = copied
+	}
+	session.Messages = append(session.Messages, maxHistory...)
+
+	// * insert summary prompt every time
+	if summary := sessionManager.GetSummaryPrompt(sessionID); summary != "" {
+		session.Messages = append(session.Messages, agentTypes.Message{
+			Role:    "system",
+			Content: summary,
+		})
+	}
+	if memories := memory.Prompt(context.Background(), trimInput, execData.Principal); memories != "" {
+		session.Messages = append(session.Messages, agentTypes.Message{
+			Role:    "system",
+			Content: memories,
+		})
+	}
+
+	userText := fmt.Sprintf("---\n當前時間: %s\n---\n%s", time.Now().Format("2006-01-02 15:04:05"), trimInput)
+	session.Histories = append(session.Histories, agentTypes.Message{
+		Role:    "user",
+		Content: userText,
+	})
+	session.Messages = append(session.Messages, agentTypes.Message{
+		Role:    "user",
+		Content: buildContent(userText, execData.ImageInputs, execData.FileInputs),
+	})
+}
@@ -12,8 +12,14 @@ import (
 	toolTypes "github.com/pardnchiu/agenvoy/internal/tools/types"
 )
 
+// * marks the user message carrying images read by tools
+const toolImagesText = "以下為工具讀取的圖片內容"
+
 func toolCall(ctx context.Context, exec *toolTypes.Executor, choice agentTypes.OutputChoices, sessionData *agentTypes.AgentSession, events chan<- agentTypes.Event, allowAll bool, alreadyCall map[string]string) (*agentTypes.AgentSession, map[string]string, error) {
+	dropToolImages(sessionData)
 	sessionData.Messages = append(sessionData.Messages, choice.Message)
+	// * the tool log keeps the calls with their arguments next to the results
+	sessionData.Tools = append(sessionData.Tools, choice.Message)
 
 	for _, tool := range choice.Message.ToolCalls {
 		toolID := strings.TrimSpace(tool.ID)
@@ -40,7 +46,7 @@ func toolCall(ctx context.Context, exec *toolTypes.Executor, choice agentTypes.O
 			ToolID:   toolID,
 		}
 
-		if !allowAll {
+		if tools.NeedConfirm(toolName, allowAll) {
 			replyCh := make(chan bool, 1)
 			events <- agentTypes.Event{
 				Type:     agentTypes.EventToolConfirm,
@@ -76,7 +82,13 @@ func toolCall(ctx context.Context, exec *toolTypes.Executor, choice agentTypes.O
 			ToolID:   toolID,
 		}
 
-		result, err := tools.Execute(ctx, exec, toolName, json.RawMessage(tool.Function.Arguments))
+		var result string
+		var err error
+		if recorded, ok := replayed(exec, toolName, toolArg); ok {
+			result = recorded
+		} else {
+			result, err = tools.Execute(ctx, exec, toolName, json.RawMessage(tool.Function.Arguments))
+		}
 		if err != nil {
 			hash := file.SaveToolError(sessionData.ID, toolName, tool.Function.Arguments, err.Error())
 			events <- agentTypes.Event{
@@ -133,5 +145,44 @@ func toolCall(ctx context.Context, exec *toolTypes.Executor, choice agentTypes.O
 			ToolCallID: toolID,
 		})
 	}
+
+	// * tool message only accepts text, images read by tools go as user content
+	if len(exec.Images) > 0 {
+		parts := []agentTypes.ContentPart{
+			{
+				Type: "text",
+				Text: toolImagesText,
+			},
+		}
+		for _, url := range exec.Images {
+			parts = append(parts, agentTypes.ContentPart{
+				Type:     "image_url",
+				ImageURL: &agentTypes.ImageURL{URL: url},
+			})
+		}
+		sessionData.Messages = append(sessionData.Messages, agentTypes.Message{
+			Role:    "user",
+			Content: parts,
+		})
+		exec.Images = nil
+	}
 	return sessionData, alreadyCall, nil
 }
+
+// * dropToolImages keeps images read by tools for one round only, the agent has answered to them since
+func dropToolImages(sessionData *agentTypes.AgentSession) {
+	for i, message := range sessionData.Messages {
+		parts, ok := message.Content.([]agentTypes.ContentPart)
+		if !ok || len(parts) == 0 || parts[0].Text != toolImagesText {
+			continue
+		}
+		sessionData.Messages[i].Content = fmt.Sprintf("（已移除先前工具讀取的 %d 張圖片）", len(parts)-1)
+	}
+}
+
+func replayed(exec *toolTypes.Executor, name, args string) (string, bool) {
+	if exec.Recorded == nil {
+		return "", false
+	}
+	return exec.Recorded(name, args)
+}
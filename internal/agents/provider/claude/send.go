@@ -179,6 +179,11 @@ func (a *Agent) convertToTools(tools []toolTypes.Tool) []map[string]any {
 func (a *Agent) convertToOutput(resp *Output) *agentTypes.Output {
 	output := &agentTypes.Output{
 		Choices: make([]agentTypes.OutputChoices, 1),
+		Usage: &agentTypes.Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
 	}
 
 	var toolCalls []agentTypes.ToolCall
@@ -16,6 +16,8 @@ type Agent struct {
 	baseURL    string
 	apiKey     string
 	workDir    string
+	// * models behind compat are unknown, image input is opt-in
+	vision bool
 }
 
 const (
@@ -53,6 +55,7 @@ func New(model ...string) (*Agent, error) {
 	baseURL = strings.TrimRight(baseURL, "/")
 
 	apiKey := keychain.Get(apiKeyEnvKey)
+	vision := keychain.Get(strings.TrimSuffix(urlEnvKey, "_URL")+"_VISION") == "true"
 
 	workDir, err := os.Getwd()
 	if err != nil {
@@ -65,9 +68,14 @@ func New(model ...string) (*Agent, error) {
 		baseURL:    baseURL,
 		apiKey:     apiKey,
 		workDir:    workDir,
+		vision:     vision,
 	}, nil
 }
 
 func (a *Agent) Name() string {
 	return a.model
 }
+
+func (a *Agent) Vision() bool {
+	return a.vision
+}
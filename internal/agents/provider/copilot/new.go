@@ -88,3 +88,7 @@ func New(model ...string) (*Agent, error) {
 func (a *Agent) Name() string {
 	return a.model
 }
+
+func (a *Agent) Vision() bool {
+	return provider.SupportVision("copilot", a.model)
+}
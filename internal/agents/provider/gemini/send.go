@@ -189,6 +189,13 @@ func (a *Agent) convertToOutput(resp *Output) *agentTypes.Output {
 	output := &agentTypes.Output{
 		Choices: make([]agentTypes.OutputChoices, 1),
 	}
+	if resp.UsageMetadata != nil {
+		output.Usage = &agentTypes.Usage{
+			PromptTokens:     resp.UsageMetadata.PromptTokenCount,
+			CompletionTokens: resp.UsageMetadata.CandidatesTokenCount,
+			TotalTokens:      resp.UsageMetadata.TotalTokenCount,
+		}
+	}
 
 	if len(resp.Candidates) == 0 {
 		return output
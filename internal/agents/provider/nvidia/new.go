@@ -45,3 +45,7 @@ func New(model ...string) (*Agent, error) {
 func (a *Agent) Name() string {
 	return a.model
 }
+
+func (a *Agent) Vision() bool {
+	return provider.SupportVision("nvidia", a.model)
+}
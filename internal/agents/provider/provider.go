@@ -17,6 +17,7 @@ type ModelItem struct {
 	Output        int    `json:"output"`
 	Description   string `json:"description"`
 	NoTemperature bool   `json:"no_temperature,omitempty"`
+	NoVision      bool   `json:"no_vision,omitempty"`
 }
 
 func parse(data []byte) ProviderItem {
@@ -67,6 +68,10 @@ func SupportTemperature(providerName, model string) bool {
 	return !Get(providerName, model).NoTemperature
 }
 
+func SupportVision(providerName, model string) bool {
+	return !Get(providerName, model).NoVision
+}
+
 func InputBytes(provider, model string) int {
 	return Get(provider, model).Input * 4
 }
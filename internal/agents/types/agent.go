@@ -9,6 +9,8 @@ import (
 
 type Agent interface {
 	Name() string
+	// * Vision reports whether the model takes image input
+	Vision() bool
 	Send(ctx context.Context, messages []Message, toolDefs []toolTypes.Tool) (*Output, error)
 	Execute(ctx context.Context, skill *skill.Skill, userInput string, events chan<- Event, allowAll bool) error
 }
@@ -18,6 +18,7 @@ const (
 	EventExecError
 	EventError
 	EventDone
+	EventUsage
 )
 
 type Event struct {
@@ -27,6 +28,7 @@ type Event struct {
 	ToolArgs string    `json:"tool_args,omitempty"`
 	ToolID   string    `json:"tool_id,omitempty"`
 	Result   string    `json:"result,omitempty"`
+	Tokens   int       `json:"tokens,omitempty"`
 	Err      error     `json:"-"`
 	ReplyCh  chan bool `json:"-"`
 }
@@ -31,6 +31,7 @@ type ToolCall struct {
 
 type Output struct {
 	Choices []OutputChoices `json:"choices"`
+	Usage   *Usage          `json:"usage,omitempty"`
 	Error   *struct {
 		Message string      `json:"message"`
 		Type    string      `json:"type"`
@@ -38,6 +39,13 @@ type Output struct {
 	} `json:"error,omitempty"`
 }
 
+// * openai compatible shape, other providers convert into it
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
 type OutputChoices struct {
 	Message      Message `json:"message"`
 	Delta        Message `json:"delta"`
@@ -0,0 +1,48 @@
+package discord
+
+import (
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pardnchiu/agenvoy/internal/access"
+	discordTypes "github.com/pardnchiu/agenvoy/internal/discord/types"
+	"github.com/pardnchiu/agenvoy/internal/frontend"
+)
+
+// * checkAccess resolves the policy into message, replies politely and returns false when refused
+func checkAccess(dcSession *discordgo.Session, dcMessageCreate *discordgo.MessageCreate, message *discordTypes.ReceiveMessage) bool {
+	policy, notice, ok := frontend.CheckAccess("discord", principal(message))
+	if !ok {
+		if notice != "" {
+			replyNotice(dcSession, dcMessageCreate, notice)
+		}
+		return false
+	}
+	message.Policy = policy
+	return true
+}
+
+func principal(message *discordTypes.ReceiveMessage) access.Principal {
+	return access.Principal{
+		GuildID:   message.GuildID,
+		ChannelID: message.ChannelID,
+		ParentID:  message.ThreadParentID,
+		UserID:    message.AuthorID,
+		Roles:     message.AuthorRoles,
+		Direct:    message.GuildID == "",
+	}
+}
+
+func replyNotice(dcSession *discordgo.Session, dcMessageCreate *discordgo.MessageCreate, content string) {
+	if _, err := dcSession.ChannelMessageSendReply(dcMessageCreate.ChannelID, content, dcMessageCreate.Reference()); err != nil {
+		slog.Warn("ChannelMessageSendReply",
+			slog.String("error", err.Error()))
+	}
+}
+
+func memberRoles(member *discordgo.Member) []string {
+	if member == nil {
+		return nil
+	}
+	return member.Roles
+}
@@ -0,0 +1,138 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	discordCommand "github.com/pardnchiu/agenvoy/internal/discord/command"
+	"github.com/pardnchiu/agenvoy/internal/frontend"
+	"github.com/pardnchiu/agenvoy/internal/utils"
+)
+
+const (
+	approvePrefix = "tool_approve"
+	denyPrefix    = "tool_deny"
+	// * keep arguments preview inside a single message
+	maxArgsPreview = 1500
+)
+
+// * requestApproval posts Approve/Deny buttons and blocks until decided, timed out or cancelled
+func requestApproval(ctx context.Context, dcSession *discordgo.Session, channelID string, reference *discordgo.MessageReference, requesterID, toolName, toolArgs string) bool {
+	a, err := frontend.NewApproval(requesterID)
+	if err != nil {
+		return false
+	}
+	defer a.Close()
+
+	base := fmt.Sprintf("🔐 <@%s> `%s` needs approval\n```json\n%s\n```",
+		requesterID, toolName, formatArgs(toolArgs))
+	content := fmt.Sprintf("%s\n-# denied automatically in %s", base, frontend.ApproveTimeout)
+	msg, err := dcSession.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:   content,
+		Reference: reference,
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Approve",
+						Style:    discordgo.SuccessButton,
+						CustomID: approvePrefix + ":" + a.Token,
+					},
+					discordgo.Button{
+						Label:    "Deny",
+						Style:    discordgo.DangerButton,
+						CustomID: denyPrefix + ":" + a.Token,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		slog.Warn("failed to send approval",
+			slog.String("error", err.Error()))
+		return false
+	}
+
+	approved, status := a.Wait(ctx)
+	if status != "" {
+		closeApproval(dcSession, channelID, msg.ID, base+"\n"+status)
+	}
+	return approved
+}
+
+// * handleApproval answers Approve/Deny clicks, returns false for other components
+func handleApproval(dcSession *discordgo.Session, dcInteractionCreate *discordgo.InteractionCreate) bool {
+	prefix, token, ok := strings.Cut(dcInteractionCreate.MessageComponentData().CustomID, ":")
+	if !ok || (prefix != approvePrefix && prefix != denyPrefix) {
+		return false
+	}
+
+	var userID string
+	var roles []string
+	if dcInteractionCreate.Member != nil {
+		userID = dcInteractionCreate.Member.User.ID
+		roles = dcInteractionCreate.Member.Roles
+	} else if dcInteractionCreate.User != nil {
+		userID = dcInteractionCreate.User.ID
+	}
+
+	approved := prefix == approvePrefix
+	if notice := frontend.Decide(token, userID, discordCommand.IsAdmin(roles), approved); notice != "" {
+		respondEphemeral(dcSession, dcInteractionCreate, notice)
+		return true
+	}
+
+	status := fmt.Sprintf("❌ Denied by <@%s>", userID)
+	if approved {
+		status = fmt.Sprintf("✅ Approved by <@%s>", userID)
+	}
+	content := dcInteractionCreate.Message.Content
+	if idx := strings.LastIndex(content, "\n-# "); idx != -1 {
+		content = content[:idx]
+	}
+	if err := dcSession.InteractionRespond(dcInteractionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    fmt.Sprintf("%s\n%s", content, status),
+			Components: []discordgo.MessageComponent{},
+		},
+	}); err != nil {
+		slog.Warn("InteractionRespond",
+			slog.String("error", err.Error()))
+	}
+	return true
+}
+
+func closeApproval(dcSession *discordgo.Session, channelID, messageID, content string) {
+	empty := []discordgo.MessageComponent{}
+	if _, err := dcSession.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    channelID,
+		ID:         messageID,
+		Content:    &content,
+		Components: &empty,
+	}); err != nil {
+		slog.Warn("ChannelMessageEditComplex",
+			slog.String("error", err.Error()))
+	}
+}
+
+func respondEphemeral(dcSession *discordgo.Session, dcInteractionCreate *discordgo.InteractionCreate, content string) {
+	if err := dcSession.InteractionRespond(dcInteractionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	}); err != nil {
+		slog.Warn("InteractionRespond",
+			slog.String("error", err.Error()))
+	}
+}
+
+func formatArgs(args string) string {
+	args = strings.ReplaceAll(frontend.IndentArgs(args), "```", "`\u200b``")
+	return utils.TruncateUTF8(args, maxArgsPreview)
+}
@@ -6,11 +6,25 @@ type CommandType int
 const (
 	CmdHelp CommandType = iota
 	CmdRole
+	CmdReset
+	CmdSummary
+	CmdTasks
+	CmdCrons
+	CmdModel
+	CmdSkills
+	CmdUsage
 )
 
 var commands = []CommandType{
 	CmdHelp,
 	CmdRole,
+	CmdReset,
+	CmdSummary,
+	CmdTasks,
+	CmdCrons,
+	CmdModel,
+	CmdSkills,
+	CmdUsage,
 }
 
 func (c CommandType) Text() string {
@@ -19,18 +33,55 @@ func (c CommandType) Text() string {
 		return "help"
 	case CmdRole:
 		return "role"
+	case CmdReset:
+		return "reset"
+	case CmdSummary:
+		return "summary"
+	case CmdTasks:
+		return "tasks"
+	case CmdCrons:
+		return "crons"
+	case CmdModel:
+		return "model"
+	case CmdSkills:
+		return "skills"
+	case CmdUsage:
+		return "usage"
 	default:
 		return ""
 	}
 }
 
-func getCmd(cmd string) CommandType {
-	switch cmd {
-	case "help", "/help":
-		return CmdHelp
-	case "role", "/role":
-		return CmdRole
+func (c CommandType) Description() string {
+	switch c {
+	case CmdHelp:
+		return "Show how to use"
+	case CmdRole:
+		return "Manage persona of this channel or DM"
+	case CmdReset:
+		return "Clear summary and history of this channel or DM"
+	case CmdSummary:
+		return "Show the stored summary of this channel or DM"
+	case CmdTasks:
+		return "List one-time tasks"
+	case CmdCrons:
+		return "List cron jobs"
+	case CmdModel:
+		return "Pin a model for this channel or DM"
+	case CmdSkills:
+		return "List available skills"
+	case CmdUsage:
+		return "Show daily requests and tokens per user (admins only)"
 	default:
-		return -1
+		return ""
+	}
+}
+
+func getCmd(cmd string) CommandType {
+	for _, c := range commands {
+		if cmd == c.Text() || cmd == "/"+c.Text() {
+			return c
+		}
 	}
+	return -1
 }
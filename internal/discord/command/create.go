@@ -8,34 +8,87 @@ import (
 	discordTypes "github.com/pardnchiu/agenvoy/internal/discord/types"
 )
 
+// * discord limits string choices to 25
+const maxChoices = 25
+
 func Create(dcBot *discordTypes.DiscordBot, dcSession *discordgo.Session) {
 	var command []*discordgo.ApplicationCommand
 	for _, cmd := range commands {
 		switch cmd {
-		case CmdHelp:
+		case CmdRole:
 			command = append(command, &discordgo.ApplicationCommand{
 				Name:        cmd.Text(),
-				Description: "Show how to use",
+				Description: cmd.Description(),
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "assign",
+						Description: "Assign a persona to this channel or DM",
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "name",
+								Description: "Persona name",
+								Required:    true,
+							},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "list",
+						Description: "List available personas",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "clear",
+						Description: "Remove persona from this channel or DM",
+					},
+				},
 			})
-		case CmdRole:
+		case CmdModel:
+			choices := []*discordgo.ApplicationCommandOptionChoice{
+				{Name: modelAuto, Value: modelAuto},
+			}
+			for _, entry := range dcBot.AgentRegistry.Entries {
+				if len(choices) >= maxChoices {
+					break
+				}
+				choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+					Name:  entry.Name,
+					Value: entry.Name,
+				})
+			}
 			command = append(command, &discordgo.ApplicationCommand{
 				Name:        cmd.Text(),
-				Description: "Assign role session to handle",
+				Description: cmd.Description(),
 				Options: []*discordgo.ApplicationCommandOption{
 					{
 						Type:        discordgo.ApplicationCommandOptionString,
 						Name:        "name",
-						Description: "Role name",
-						Required:    true,
+						Description: "Model to pin, auto to unpin, empty to show current",
+						Required:    false,
+						Choices:     choices,
 					},
+				},
+			})
+		case CmdUsage:
+			command = append(command, &discordgo.ApplicationCommand{
+				Name:        cmd.Text(),
+				Description: cmd.Description(),
+				Options: []*discordgo.ApplicationCommandOption{
 					{
 						Type:        discordgo.ApplicationCommandOptionString,
-						Name:        "message",
-						Description: "Message",
-						Required:    true,
+						Name:        "date",
+						Description: "Day in YYYY-MM-DD, today when empty",
+						Required:    false,
 					},
 				},
 			})
+		default:
+			command = append(command, &discordgo.ApplicationCommand{
+				Name:        cmd.Text(),
+				Description: cmd.Description(),
+			})
 		}
 	}
 
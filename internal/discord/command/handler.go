@@ -3,11 +3,13 @@ package discordCommand
 import (
 	"fmt"
 	"log/slog"
+	"strings"
 
 	discordTypes "github.com/pardnchiu/agenvoy/internal/discord/types"
+	"github.com/pardnchiu/agenvoy/internal/filesystem/sessionManager"
 )
 
-func Handler(receiveMessage *discordTypes.ReceiveMessage) []discordTypes.ReplyMessage {
+func Handler(dcBot *discordTypes.DiscordBot, receiveMessage *discordTypes.ReceiveMessage) []discordTypes.ReplyMessage {
 	var replies []discordTypes.ReplyMessage
 
 	slog.Info("handler",
@@ -19,22 +21,44 @@ func Handler(receiveMessage *discordTypes.ReceiveMessage) []discordTypes.ReplyMe
 	if receiveMessage.Cmd != "" {
 		switch getCmd(receiveMessage.Cmd) {
 		case CmdHelp:
-			return []discordTypes.ReplyMessage{
-				{Content: "is in building"},
-			}
+			return []discordTypes.ReplyMessage{{Content: help()}}
 		case CmdRole:
-			if len(receiveMessage.Params) < 2 {
-				return []discordTypes.ReplyMessage{
-					{Content: "Usage: `/role {name} {message}`"},
-				}
-			}
-			role := receiveMessage.Params[0]
-			message := receiveMessage.Params[1]
-			return []discordTypes.ReplyMessage{
-				{Content: fmt.Sprintf("Assign role: %s, message: %s (is in building)", role, message)},
-			}
+			return handleRole(receiveMessage)
+		case CmdReset:
+			return handleReset(receiveMessage)
+		case CmdSummary:
+			return handleSummary(receiveMessage)
+		case CmdTasks:
+			return []discordTypes.ReplyMessage{listTasks(receiveMessage.ChannelID, receiveMessage.AuthorID)}
+		case CmdCrons:
+			return []discordTypes.ReplyMessage{listCrons(receiveMessage.ChannelID, receiveMessage.AuthorID)}
+		case CmdModel:
+			return handleModel(dcBot, receiveMessage)
+		case CmdSkills:
+			return handleSkills(dcBot, receiveMessage)
+		case CmdUsage:
+			return handleUsage(receiveMessage)
 		}
 	}
 
 	return replies
 }
+
+func help() string {
+	var sb strings.Builder
+	sb.WriteString("Mention the bot in a channel, or send a DM, to start a conversation.\n")
+	sb.WriteString("Attach images or files to include them as input.\n\n")
+	for _, cmd := range commands {
+		sb.WriteString(fmt.Sprintf("`/%s` — %s\n", cmd.Text(), cmd.Description()))
+	}
+	sb.WriteString("\n`/role assign {name}` · `/role list` · `/role clear`")
+	return sb.String()
+}
+
+func getSessionID(receiveMessage *discordTypes.ReceiveMessage) (string, []discordTypes.ReplyMessage) {
+	sessionID, err := sessionManager.GetDiscordSession(receiveMessage.GuildID, receiveMessage.ChannelID, receiveMessage.AuthorID)
+	if err != nil {
+		return "", []discordTypes.ReplyMessage{{Content: fmt.Sprintf("failed to get session: %s", err.Error())}}
+	}
+	return sessionID, nil
+}
@@ -0,0 +1,82 @@
+package discordCommand
+
+import (
+	"fmt"
+	"strings"
+
+	discordTypes "github.com/pardnchiu/agenvoy/internal/discord/types"
+	"github.com/pardnchiu/agenvoy/internal/persona"
+)
+
+const roleUsage = "Usage: `/role assign {name}`, `/role list`, `/role clear`"
+
+func handleRole(receiveMessage *discordTypes.ReceiveMessage) []discordTypes.ReplyMessage {
+	if len(receiveMessage.Params) == 0 {
+		return []discordTypes.ReplyMessage{{Content: roleUsage}}
+	}
+
+	sessionID, errReply := getSessionID(receiveMessage)
+	if errReply != nil {
+		return errReply
+	}
+
+	switch receiveMessage.Params[0] {
+	case "assign":
+		if len(receiveMessage.Params) < 2 {
+			return []discordTypes.ReplyMessage{{Content: roleUsage}}
+		}
+		p, err := persona.Assign(sessionID, receiveMessage.Params[1])
+		if err != nil {
+			return []discordTypes.ReplyMessage{{Content: err.Error()}}
+		}
+		return []discordTypes.ReplyMessage{{Content: fmt.Sprintf("Persona assigned: **%s**\n%s", p.Name, describe(p))}}
+
+	case "list":
+		list := persona.List()
+		if len(list) == 0 {
+			return []discordTypes.ReplyMessage{{Content: "No personas found, add JSON files to `~/.config/agenvoy/personas/`"}}
+		}
+
+		current := ""
+		if p := persona.ForSession(sessionID); p != nil {
+			current = p.Name
+		}
+
+		var sb strings.Builder
+		for _, p := range list {
+			mark := "•"
+			if p.Name == current {
+				mark = "★"
+			}
+			sb.WriteString(fmt.Sprintf("%s **%s**", mark, p.Name))
+			if p.Description != "" {
+				sb.WriteString(" — " + p.Description)
+			}
+			sb.WriteString("\n")
+		}
+		return []discordTypes.ReplyMessage{{Content: sb.String()}}
+
+	case "clear":
+		if err := persona.Clear(sessionID); err != nil {
+			return []discordTypes.ReplyMessage{{Content: err.Error()}}
+		}
+		return []discordTypes.ReplyMessage{{Content: "Persona cleared"}}
+
+	default:
+		return []discordTypes.ReplyMessage{{Content: roleUsage}}
+	}
+}
+
+func describe(p *persona.Persona) string {
+	var lines []string
+	if p.Description != "" {
+		lines = append(lines, p.Description)
+	}
+	if p.Agent != "" {
+		lines = append(lines, fmt.Sprintf("-# agent: %s", p.Agent))
+	}
+	if len(p.Skills) > 0 {
+		lines = append(lines, fmt.Sprintf("-# skills: %s", strings.Join(p.Skills, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
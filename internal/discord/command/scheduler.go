@@ -0,0 +1,143 @@
+package discordCommand
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	discordTypes "github.com/pardnchiu/agenvoy/internal/discord/types"
+	"github.com/pardnchiu/agenvoy/internal/scheduler"
+)
+
+const (
+	taskRemovePrefix = "task_rm"
+	cronRemovePrefix = "cron_rm"
+	// * discord allows 5 buttons per row and 5 rows per message
+	buttonsPerRow = 5
+	maxButtons    = 25
+)
+
+func listTasks(channelID, requesterID string) discordTypes.ReplyMessage {
+	return listJobs(scheduler.KindTask, taskRemovePrefix, channelID, requesterID)
+}
+
+func listCrons(channelID, requesterID string) discordTypes.ReplyMessage {
+	return listJobs(scheduler.KindCron, cronRemovePrefix, channelID, requesterID)
+}
+
+func listJobs(kind, prefix, channelID, requesterID string) discordTypes.ReplyMessage {
+	jobs, err := channelJobs(kind, channelID)
+	if err != nil {
+		return discordTypes.ReplyMessage{Content: err.Error()}
+	}
+	return buildList(kind, prefix, requesterID, jobs)
+}
+
+// * channelJobs keeps the jobs delivering to channelID, others may hold webhooks, emails and prompts of other users
+func channelJobs(kind, channelID string) ([]scheduler.Job, error) {
+	mgr, err := scheduler.Current()
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := mgr.Jobs(kind)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(jobs, func(job scheduler.Job) bool {
+		targetKind, address, err := scheduler.ParseTarget(job.Target)
+		return err != nil || targetKind != scheduler.TargetDiscord || address != channelID
+	}), nil
+}
+
+func buildList(kind, prefix, requesterID string, jobs []scheduler.Job) discordTypes.ReplyMessage {
+	if len(jobs) == 0 {
+		return discordTypes.ReplyMessage{Content: fmt.Sprintf("no %s in this channel", kind)}
+	}
+
+	var sb strings.Builder
+	var buttons []discordgo.MessageComponent
+	for i, job := range jobs {
+		sb.WriteString(fmt.Sprintf("`%s`\n", job.String()))
+		if i >= maxButtons {
+			continue
+		}
+		// * the requester rides along, only they or an admin may press it
+		buttons = append(buttons, discordgo.Button{
+			Label:    fmt.Sprintf("Remove %s", job.ID),
+			Style:    discordgo.DangerButton,
+			CustomID: fmt.Sprintf("%s:%s:%s", prefix, job.ID, requesterID),
+		})
+	}
+	if len(jobs) > maxButtons {
+		sb.WriteString(fmt.Sprintf("-# only first %d can be removed here\n", maxButtons))
+	}
+
+	var rows []discordgo.MessageComponent
+	for start := 0; start < len(buttons); start += buttonsPerRow {
+		end := min(start+buttonsPerRow, len(buttons))
+		rows = append(rows, discordgo.ActionsRow{Components: buttons[start:end]})
+	}
+
+	return discordTypes.ReplyMessage{
+		Content:    sb.String(),
+		Components: rows,
+	}
+}
+
+// * ComponentHandler handles remove buttons, returns the refreshed list to replace the message,
+// * or a notice for the clicker alone when they may not remove
+func ComponentHandler(customID, channelID, userID string, roles []string) (reply discordTypes.ReplyMessage, notice string, ok bool) {
+	prefix, rest, ok := strings.Cut(customID, ":")
+	if !ok {
+		return discordTypes.ReplyMessage{}, "", false
+	}
+
+	var kind string
+	switch prefix {
+	case taskRemovePrefix:
+		kind = scheduler.KindTask
+	case cronRemovePrefix:
+		kind = scheduler.KindCron
+	default:
+		return discordTypes.ReplyMessage{}, "", false
+	}
+
+	id, requesterID, _ := strings.Cut(rest, ":")
+	// * lists posted before buttons carried a requester can only be used by admins
+	if userID != requesterID && !IsAdmin(roles) {
+		return discordTypes.ReplyMessage{}, "Only the requester or an admin can remove", true
+	}
+	refresh := func() discordTypes.ReplyMessage {
+		return listJobs(kind, prefix, channelID, requesterID)
+	}
+
+	mgr, err := scheduler.Current()
+	if err != nil {
+		return discordTypes.ReplyMessage{Content: err.Error()}, "", true
+	}
+	jobs, err := channelJobs(kind, channelID)
+	if err != nil {
+		return discordTypes.ReplyMessage{Content: err.Error()}, "", true
+	}
+
+	// * job IDs are stable, a stale list can only point to a removed job
+	idx := slices.IndexFunc(jobs, func(j scheduler.Job) bool {
+		return j.ID == id
+	})
+	if idx < 0 {
+		reply := refresh()
+		reply.Content = "-# already removed\n" + reply.Content
+		return reply, "", true
+	}
+
+	if err := mgr.Remove(id); err != nil {
+		reply := refresh()
+		reply.Content = fmt.Sprintf("-# failed to remove %s: %s\n%s", id, err.Error(), reply.Content)
+		return reply, "", true
+	}
+
+	reply = refresh()
+	reply.Content = fmt.Sprintf("-# removed: %s\n%s", jobs[idx].String(), reply.Content)
+	return reply, "", true
+}
@@ -0,0 +1,121 @@
+package discordCommand
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	discordTypes "github.com/pardnchiu/agenvoy/internal/discord/types"
+	"github.com/pardnchiu/agenvoy/internal/filesystem/sessionManager"
+	"github.com/pardnchiu/agenvoy/internal/persona"
+)
+
+const (
+	// * /model choice to remove the pinned model
+	modelAuto = "auto"
+	// * skill description is cut to keep /skills in a few messages
+	maxSkillDesc = 120
+)
+
+func handleReset(receiveMessage *discordTypes.ReceiveMessage) []discordTypes.ReplyMessage {
+	sessionID, errReply := getSessionID(receiveMessage)
+	if errReply != nil {
+		return errReply
+	}
+
+	if err := sessionManager.ResetSession(sessionID); err != nil {
+		return []discordTypes.ReplyMessage{{Content: fmt.Sprintf("failed to reset: %s", err.Error())}}
+	}
+	// * channel messages are history in discord, skip everything before this command
+	if err := sessionManager.SetConfig(sessionID, sessionManager.ConfigKeyResetAfter, receiveMessage.MessageID); err != nil {
+		return []discordTypes.ReplyMessage{{Content: fmt.Sprintf("failed to reset: %s", err.Error())}}
+	}
+	return []discordTypes.ReplyMessage{{Content: "Session reset, summary and history cleared"}}
+}
+
+func handleSummary(receiveMessage *discordTypes.ReceiveMessage) []discordTypes.ReplyMessage {
+	sessionID, errReply := getSessionID(receiveMessage)
+	if errReply != nil {
+		return errReply
+	}
+
+	if bytes, _ := sessionManager.GetSummary(sessionID); len(bytes) == 0 {
+		return []discordTypes.ReplyMessage{{Content: "No summary yet"}}
+	}
+	return []discordTypes.ReplyMessage{{Content: sessionManager.GetSummaryPrompt(sessionID)}}
+}
+
+func handleModel(dcBot *discordTypes.DiscordBot, receiveMessage *discordTypes.ReceiveMessage) []discordTypes.ReplyMessage {
+	sessionID, errReply := getSessionID(receiveMessage)
+	if errReply != nil {
+		return errReply
+	}
+
+	if len(receiveMessage.Params) == 0 || receiveMessage.Params[0] == "" {
+		config, err := sessionManager.GetConfig(sessionID)
+		if err != nil {
+			return []discordTypes.ReplyMessage{{Content: err.Error()}}
+		}
+		current := config[sessionManager.ConfigKeyModel]
+		if current == "" {
+			current = modelAuto
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("Current model: **%s**\n\n", current))
+		for _, entry := range dcBot.AgentRegistry.Entries {
+			sb.WriteString(fmt.Sprintf("• `%s`", entry.Name))
+			if entry.Description != "" {
+				sb.WriteString(" — " + entry.Description)
+			}
+			sb.WriteString("\n")
+		}
+		return []discordTypes.ReplyMessage{{Content: sb.String()}}
+	}
+
+	name := strings.TrimSpace(receiveMessage.Params[0])
+	if name == modelAuto {
+		if err := sessionManager.SetConfig(sessionID, sessionManager.ConfigKeyModel, ""); err != nil {
+			return []discordTypes.ReplyMessage{{Content: err.Error()}}
+		}
+		return []discordTypes.ReplyMessage{{Content: "Model unpinned, agent is selected per message"}}
+	}
+
+	if _, ok := dcBot.AgentRegistry.Registry[name]; !ok {
+		return []discordTypes.ReplyMessage{{Content: fmt.Sprintf("model not found: %s", name)}}
+	}
+	if err := sessionManager.SetConfig(sessionID, sessionManager.ConfigKeyModel, name); err != nil {
+		return []discordTypes.ReplyMessage{{Content: err.Error()}}
+	}
+	return []discordTypes.ReplyMessage{{Content: fmt.Sprintf("Model pinned: **%s**", name)}}
+}
+
+func handleSkills(dcBot *discordTypes.DiscordBot, receiveMessage *discordTypes.ReceiveMessage) []discordTypes.ReplyMessage {
+	dcBot.SkillScanner.Scan()
+	names := dcBot.SkillScanner.List()
+	if len(names) == 0 {
+		return []discordTypes.ReplyMessage{{Content: "No skills found"}}
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Found %d skill(s):\n\n", len(names)))
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf("• **%s**", name))
+		if s := dcBot.SkillScanner.Skills.ByName[name]; s != nil && s.Description != "" {
+			desc := strings.Join(strings.Fields(s.Description), " ")
+			if runes := []rune(desc); len(runes) > maxSkillDesc {
+				desc = string(runes[:maxSkillDesc]) + "…"
+			}
+			sb.WriteString(" — " + desc)
+		}
+		sb.WriteString("\n")
+	}
+
+	if sessionID, errReply := getSessionID(receiveMessage); errReply == nil {
+		if p := persona.ForSession(sessionID); p != nil && len(p.Skills) > 0 {
+			sb.WriteString(fmt.Sprintf("\n-# persona %s limits skills to: %s", p.Name, strings.Join(p.Skills, ", ")))
+		}
+	}
+	return []discordTypes.ReplyMessage{{Content: sb.String()}}
+}
@@ -0,0 +1,70 @@
+package discordCommand
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/access"
+	discordTypes "github.com/pardnchiu/agenvoy/internal/discord/types"
+)
+
+// * DISCORD_ADMIN_ROLES is a comma separated list of role IDs with admin rights
+func IsAdmin(roles []string) bool {
+	for _, id := range strings.Split(os.Getenv("DISCORD_ADMIN_ROLES"), ",") {
+		if id = strings.TrimSpace(id); id != "" && slices.Contains(roles, id) {
+			return true
+		}
+	}
+	return false
+}
+
+func handleUsage(receiveMessage *discordTypes.ReceiveMessage) []discordTypes.ReplyMessage {
+	if !IsAdmin(receiveMessage.AuthorRoles) {
+		return []discordTypes.ReplyMessage{{Content: "Only admins can view usage"}}
+	}
+
+	date := time.Now().Format("2006-01-02")
+	if len(receiveMessage.Params) > 0 && receiveMessage.Params[0] != "" {
+		date = strings.TrimSpace(receiveMessage.Params[0])
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return []discordTypes.ReplyMessage{{Content: "Date must be YYYY-MM-DD"}}
+		}
+	}
+
+	usage, err := access.Daily(date)
+	if err != nil {
+		return []discordTypes.ReplyMessage{{Content: fmt.Sprintf("failed to read usage: %s", err.Error())}}
+	}
+	if len(usage) == 0 {
+		return []discordTypes.ReplyMessage{{Content: fmt.Sprintf("No usage on %s", date)}}
+	}
+
+	principals := make([]string, 0, len(usage))
+	for principal := range usage {
+		principals = append(principals, principal)
+	}
+	sort.Slice(principals, func(i, j int) bool {
+		return usage[principals[i]].Tokens > usage[principals[j]].Tokens
+	})
+
+	var total access.Usage
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("**Usage on %s**\n", date))
+	for _, principal := range principals {
+		u := usage[principal]
+		total.Requests += u.Requests
+		total.Tokens += u.Tokens
+		// * discord users are mentioned, other frontends show the principal as is
+		name := fmt.Sprintf("`%s`", principal)
+		if userID, ok := strings.CutPrefix(principal, "discord:"); ok {
+			name = fmt.Sprintf("<@%s>", userID)
+		}
+		sb.WriteString(fmt.Sprintf("• %s — %d requests · %d tokens\n", name, u.Requests, u.Tokens))
+	}
+	sb.WriteString(fmt.Sprintf("\nTotal: %d requests · %d tokens", total.Requests, total.Tokens))
+	return []discordTypes.ReplyMessage{{Content: sb.String()}}
+}
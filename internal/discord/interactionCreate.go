@@ -10,9 +10,17 @@ import (
 	"github.com/bwmarrin/discordgo"
 	discordCommand "github.com/pardnchiu/agenvoy/internal/discord/command"
 	discordTypes "github.com/pardnchiu/agenvoy/internal/discord/types"
+	"github.com/pardnchiu/agenvoy/internal/frontend"
 )
 
-func interactionCreate(dcSession *discordgo.Session, dcInderactionCreate *discordgo.InteractionCreate) {
+func interactionCreate(bot *discordTypes.DiscordBot, dcSession *discordgo.Session, dcInderactionCreate *discordgo.InteractionCreate) {
+	if dcInderactionCreate.Type == discordgo.InteractionMessageComponent {
+		if handleApproval(dcSession, dcInderactionCreate) {
+			return
+		}
+		componentCreate(dcSession, dcInderactionCreate)
+		return
+	}
 	if dcInderactionCreate.Type != discordgo.InteractionApplicationCommand {
 		return
 	}
@@ -20,31 +28,49 @@ func interactionCreate(dcSession *discordgo.Session, dcInderactionCreate *discor
 	data := dcInderactionCreate.ApplicationCommandData()
 
 	var userID, username string
+	var roles []string
 	if dcInderactionCreate.Member != nil {
 		userID = dcInderactionCreate.Member.User.ID
 		username = dcInderactionCreate.Member.User.Username
+		roles = dcInderactionCreate.Member.Roles
 	} else if dcInderactionCreate.User != nil {
 		userID = dcInderactionCreate.User.ID
 		username = dcInderactionCreate.User.Username
 	}
 
-	var params []string
-	for _, opt := range data.Options {
-		params = append(params, opt.StringValue())
-	}
+	params := flattenOptions(data.Options)
 
 	message := &discordTypes.ReceiveMessage{
-		GuildID:    dcInderactionCreate.GuildID,
-		ChannelID:  dcInderactionCreate.ChannelID,
-		AuthorID:   userID,
-		AuthorName: username,
-		Content:    fmt.Sprintf("/%s %s", data.Name, strings.Join(params, " ")),
-		Cmd:        fmt.Sprintf("/%s", data.Name),
-		Params:     params,
-		IsChannel:  dcInderactionCreate.GuildID != "",
-		IsMention:  false,
-		RecievedAt: time.Now().Unix(),
+		MessageID:   dcInderactionCreate.ID,
+		GuildID:     dcInderactionCreate.GuildID,
+		ChannelID:   dcInderactionCreate.ChannelID,
+		AuthorID:    userID,
+		AuthorName:  username,
+		AuthorRoles: roles,
+		Content:     fmt.Sprintf("/%s %s", data.Name, strings.Join(params, " ")),
+		Cmd:         fmt.Sprintf("/%s", data.Name),
+		Params:      params,
+		IsChannel:   dcInderactionCreate.GuildID != "",
+		IsMention:   false,
+		RecievedAt:  time.Now().Unix(),
+	}
+	if message.IsChannel {
+		if parentID, ok := botThread(dcSession, message.ChannelID); ok {
+			message.ThreadParentID = parentID
+		}
+	}
+
+	// * commands follow access.json like mentions, quotas only limit conversations
+	policy, notice, ok := frontend.ResolvePolicy("discord", principal(message))
+	if !ok {
+		if notice == "" {
+			notice = frontend.DeniedNotice
+		}
+		respondEphemeral(dcSession, dcInderactionCreate, notice)
+		return
 	}
+	message.Policy = policy
+
 	ctx := context.Background()
 	dcSession.InteractionRespond(dcInderactionCreate.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
@@ -55,7 +81,7 @@ func interactionCreate(dcSession *discordgo.Session, dcInderactionCreate *discor
 		slog.String("content", message.Content),
 		slog.Bool("is_channel", message.IsChannel))
 
-	replies := discordCommand.Handler(message)
+	replies := discordCommand.Handler(bot, message)
 	for _, reply := range replies {
 		dcReply := &discordTypes.DiscordReply{
 			Session:     dcSession,
@@ -64,3 +90,58 @@ func interactionCreate(dcSession *discordgo.Session, dcInderactionCreate *discor
 		Reply(ctx, dcReply, reply)
 	}
 }
+
+// * buttons rewrite the message they belong to
+func componentCreate(dcSession *discordgo.Session, dcInderactionCreate *discordgo.InteractionCreate) {
+	customID := dcInderactionCreate.MessageComponentData().CustomID
+	var userID string
+	var roles []string
+	if dcInderactionCreate.Member != nil {
+		userID = dcInderactionCreate.Member.User.ID
+		roles = dcInderactionCreate.Member.Roles
+	} else if dcInderactionCreate.User != nil {
+		userID = dcInderactionCreate.User.ID
+	}
+	reply, notice, ok := discordCommand.ComponentHandler(customID, dcInderactionCreate.ChannelID, userID, roles)
+	if !ok {
+		return
+	}
+	if notice != "" {
+		respondEphemeral(dcSession, dcInderactionCreate, notice)
+		return
+	}
+
+	slog.Info("component received",
+		slog.String("custom_id", customID))
+
+	components := reply.Components
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+	if err := dcSession.InteractionRespond(dcInderactionCreate.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    reply.Content,
+			Components: components,
+		},
+	}); err != nil {
+		slog.Warn("InteractionRespond",
+			slog.String("error", err.Error()))
+	}
+}
+
+// * subcommand name comes first, followed by its option values
+func flattenOptions(options []*discordgo.ApplicationCommandInteractionDataOption) []string {
+	var params []string
+	for _, opt := range options {
+		switch opt.Type {
+		case discordgo.ApplicationCommandOptionSubCommand,
+			discordgo.ApplicationCommandOptionSubCommandGroup:
+			params = append(params, opt.Name)
+			params = append(params, flattenOptions(opt.Options)...)
+		default:
+			params = append(params, fmt.Sprint(opt.Value))
+		}
+	}
+	return params
+}
@@ -29,10 +29,12 @@ func messageCreate(bot *discordTypes.DiscordBot, dcSession *discordgo.Session, d
 	}
 
 	message := &discordTypes.ReceiveMessage{
+		MessageID:   dcMessageCreate.ID,
 		GuildID:     dcMessageCreate.GuildID,
 		ChannelID:   dcMessageCreate.ChannelID,
 		AuthorID:    dcMessageCreate.Author.ID,
 		AuthorName:  dcMessageCreate.Author.Username,
+		AuthorRoles: memberRoles(dcMessageCreate.Member),
 		Content:     dcMessageCreate.Content,
 		ImageInputs: imageInputs,
 		FileInputs:  fileInputs,
@@ -73,11 +75,35 @@ func messageCreate(bot *discordTypes.DiscordBot, dcSession *discordgo.Session, d
 		}
 	}
 
-	// * if in channel, must be used mention to trigger
+	// * messages inside a bot thread continue the conversation without mention
 	if message.IsChannel && !message.IsMention {
+		parentID, ok := botThread(dcSession, dcMessageCreate.ChannelID)
+		if !ok {
+			return
+		}
+		message.IsMention = true
+		message.ThreadParentID = parentID
+	} else if message.IsChannel {
+		if parentID, ok := botThread(dcSession, dcMessageCreate.ChannelID); ok {
+			message.ThreadParentID = parentID
+		}
+	}
+
+	if !checkAccess(dcSession, dcMessageCreate, message) {
 		return
 	}
 
+	if message.IsChannel && message.ThreadParentID == "" && threadsEnabled() {
+		thread, err := startThread(dcSession, dcMessageCreate)
+		if err != nil {
+			slog.Warn("startThread",
+				slog.String("error", err.Error()))
+		} else {
+			message.ChannelID = thread.ID
+			message.ThreadParentID = dcMessageCreate.ChannelID
+		}
+	}
+
 	slog.Info("message received",
 		slog.String("user", message.AuthorName),
 		slog.String("content", message.Content),
@@ -1,7 +1,6 @@
 package discord
 
 import (
-	"context"
 	"fmt"
 	"log/slog"
 	"os"
@@ -11,7 +10,7 @@ import (
 	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
 	discordCommand "github.com/pardnchiu/agenvoy/internal/discord/command"
 	discordTypes "github.com/pardnchiu/agenvoy/internal/discord/types"
-	"github.com/pardnchiu/agenvoy/internal/scheduler"
+	"github.com/pardnchiu/agenvoy/internal/frontend"
 	"github.com/pardnchiu/agenvoy/internal/skill"
 )
 
@@ -26,20 +25,6 @@ func New(plannerAgent agentTypes.Agent, agentRegistry agentTypes.AgentRegistry,
 		return nil, fmt.Errorf("create discord session: %w", err)
 	}
 
-	if err := scheduler.New(); err != nil {
-		slog.Warn("scheduler.New",
-			slog.String("error", err.Error()))
-	} else {
-		if err := scheduler.Get().LoadTasks(); err != nil {
-			slog.Warn("scheduler.Get().LoadTasks",
-				slog.String("error", err.Error()))
-		}
-		if err := scheduler.Get().LoadCrons(); err != nil {
-			slog.Warn("scheduler.Get().LoadCrons",
-				slog.String("error", err.Error()))
-		}
-	}
-
 	bot := &discordTypes.DiscordBot{
 		Session:       session,
 		PlannerAgent:  plannerAgent,
@@ -47,25 +32,9 @@ func New(plannerAgent agentTypes.Agent, agentRegistry agentTypes.AgentRegistry,
 		SkillScanner:  skillScanner,
 	}
 
-	if cronMgr := scheduler.Get(); cronMgr != nil {
-		cronMgr.OnCompleted = func(channelID, output string) {
-			if output == "" {
-				output = "任務完成"
-			}
-			var content string
-			if strings.HasPrefix(output, "error:") {
-				content = output
-			} else {
-				content = wrapScriptOutput(plannerAgent, output)
-			}
-			if err := Send(bot, channelID, discordTypes.ReplyMessage{Content: content}); err != nil {
-				slog.Warn("Send",
-					slog.String("error", err.Error()))
-			}
-		}
-	}
-
-	session.AddHandler(interactionCreate)
+	session.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		interactionCreate(bot, s, i)
+	})
 	session.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
 		messageCreate(bot, s, m)
 	})
@@ -76,10 +45,11 @@ func New(plannerAgent agentTypes.Agent, agentRegistry agentTypes.AgentRegistry,
 	}
 
 	discordCommand.Create(bot, session)
+	startThreadReaper(session)
 
 	clientID := session.State.User.ID
 	oauthURL := fmt.Sprintf(
-		"https://discord.com/oauth2/authorize?client_id=%s&scope=identify+email+bot+applications.commands&permissions=83968",
+		"https://discord.com/oauth2/authorize?client_id=%s&scope=identify+email+bot+applications.commands&permissions=309237729280",
 		clientID,
 	)
 	slog.Info("bot is running",
@@ -89,40 +59,41 @@ func New(plannerAgent agentTypes.Agent, agentRegistry agentTypes.AgentRegistry,
 	return bot, nil
 }
 
-func wrapScriptOutput(agent agentTypes.Agent, output string) string {
-	if agent == nil {
-		return output
+// * NewNotifier only sends over REST, for processes that deliver without handling chat
+func NewNotifier(plannerAgent agentTypes.Agent) (*discordTypes.DiscordBot, error) {
+	token := os.Getenv("DISCORD_TOKEN")
+	if token == "" {
+		return nil, nil
 	}
-	messages := []agentTypes.Message{
-		{
-			Role:    "system",
-			Content: "你是一個訊息整理助理。收到腳本執行結果後，將其轉化為自然、簡潔、適合在 Discord 頻道傳送的訊息。若結果為空或無意義，回覆「任務已完成」。直接輸出訊息內容，不要加任何前綴或解釋。",
-		},
-		{
-			Role:    "user",
-			Content: output,
-		},
+
+	session, err := discordgo.New("Bot " + token)
+	if err != nil {
+		return nil, fmt.Errorf("create discord session: %w", err)
+	}
+	return &discordTypes.DiscordBot{
+		Session:      session,
+		PlannerAgent: plannerAgent,
+	}, nil
+}
+
+// * Notify posts scheduler output to a Discord channel, wrap rewrites raw script output with the planner
+func Notify(bot *discordTypes.DiscordBot, channelID, output string, wrap bool) error {
+	if output == "" {
+		output = "任務完成"
 	}
-	resp, err := agent.Send(context.Background(), messages, nil)
-	if err != nil || len(resp.Choices) == 0 {
-		slog.Warn("wrapScriptOutput: agent.Send failed, using raw output",
-			slog.String("error", func() string {
-				if err != nil {
-					return err.Error()
-				}
-				return "empty choices"
-			}()))
-		return output
+	content := output
+	if wrap && !strings.HasPrefix(output, "error:") {
+		content = frontend.WrapScriptOutput(bot.PlannerAgent, output)
 	}
-	if text, ok := resp.Choices[0].Message.Content.(string); ok && text != "" {
-		return text
+	if err := Send(bot, channelID, discordTypes.ReplyMessage{Content: content}); err != nil {
+		return fmt.Errorf("Send: %w", err)
 	}
-	return output
+	return nil
 }
 
 func Close(b *discordTypes.DiscordBot) error {
 	slog.Info("shutting down")
-	scheduler.Stop()
+	stopThreadReaper()
 	if b.Session == nil {
 		return nil
 	}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
 	discordTypes "github.com/pardnchiu/agenvoy/internal/discord/types"
+	"github.com/pardnchiu/agenvoy/internal/frontend"
 )
 
 const (
@@ -37,7 +39,7 @@ func Send(bot *discordTypes.DiscordBot, channelID string, reply discordTypes.Rep
 		})
 	}
 
-	chunks := split(reply.Content)
+	chunks := frontend.Split(reply.Content, replayMax)
 	replyFiles := chunkFiles(files, attachMax)
 
 	for i, chunk := range chunks {
@@ -99,19 +101,25 @@ func Reply(ctx context.Context, dcReply *discordTypes.DiscordReply, reply discor
 	}
 
 	if dcReply.Interaction != nil {
+		chunks := frontend.Split(reply.Content, replayMax)
 		replyFiles := chunkFiles(files, attachMax)
-		files := []*discordgo.File(nil)
-		if len(replyFiles) > 0 {
-			files = replyFiles[0]
-			replyFiles = replyFiles[1:]
-		}
-		_, err := dcReply.Session.FollowupMessageCreate(dcReply.Interaction.Interaction, true, &discordgo.WebhookParams{
-			Content: reply.Content,
-			Embeds:  embeds,
-			Files:   files,
-		})
-		if err != nil {
-			return err
+		for i, chunk := range chunks {
+			params := &discordgo.WebhookParams{
+				Content: chunk,
+			}
+			// * embeds, buttons and first files go with the last chunk
+			if i == len(chunks)-1 {
+				params.Embeds = embeds
+				params.Components = reply.Components
+				if len(replyFiles) > 0 {
+					params.Files = replyFiles[0]
+					replyFiles = replyFiles[1:]
+				}
+			}
+			_, err := dcReply.Session.FollowupMessageCreate(dcReply.Interaction.Interaction, true, params)
+			if err != nil {
+				return err
+			}
 		}
 
 		for _, replyFile := range replyFiles {
@@ -125,7 +133,7 @@ func Reply(ctx context.Context, dcReply *discordTypes.DiscordReply, reply discor
 		return nil
 	}
 
-	chunks := split(reply.Content)
+	chunks := frontend.Split(reply.Content, replayMax)
 	replyFiles := chunkFiles(files, attachMax)
 
 	for i, chunk := range chunks {
@@ -166,23 +174,30 @@ func Reply(ctx context.Context, dcReply *discordTypes.DiscordReply, reply discor
 	return nil
 }
 
-func split(s string) []string {
-	if len(s) <= replayMax {
-		return []string{s}
-	}
-	var chunks []string
-	for len(s) > replayMax {
-		cut := replayMax
-		if idx := isLast(s[:cut]); idx > 0 {
-			cut = idx + 1
+// * ReplyEdit replaces the placeholder with the first chunk, the rest follow as new messages
+func ReplyEdit(ctx context.Context, dcReply *discordTypes.DiscordReply, messageID string, reply discordTypes.ReplyMessage) error {
+	chunks := frontend.Split(reply.Content, replayMax)
+	// * single chunk with attachments is sent fresh, edits cannot carry embeds and files reliably
+	if messageID == "" || (len(chunks) == 1 && (reply.ImageURL != "" || len(reply.FilePaths) > 0)) {
+		if messageID != "" {
+			dcReply.Session.ChannelMessageDelete(dcReply.ChannelID, messageID)
 		}
-		chunks = append(chunks, s[:cut])
-		s = s[cut:]
+		return Reply(ctx, dcReply, reply)
 	}
-	if s != "" {
-		chunks = append(chunks, s)
+
+	if _, err := dcReply.Session.ChannelMessageEdit(dcReply.ChannelID, messageID, chunks[0]); err != nil {
+		return err
 	}
-	return chunks
+	if len(chunks) == 1 {
+		return nil
+	}
+
+	rest := reply
+	rest.Content = strings.Join(chunks[1:], "")
+	// * first chunk already replies to the message
+	restReply := *dcReply
+	restReply.Reference = nil
+	return Reply(ctx, &restReply, rest)
 }
 
 func chunkFiles(files []*discordgo.File, size int) [][]*discordgo.File {
@@ -195,12 +210,3 @@ func chunkFiles(files []*discordgo.File, size int) [][]*discordgo.File {
 	}
 	return append(chunkFiles, files)
 }
-
-func isLast(s string) int {
-	for i := len(s) - 1; i >= 0; i-- {
-		if s[i] == '\n' {
-			return i
-		}
-	}
-	return -1
-}
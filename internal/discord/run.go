@@ -4,127 +4,135 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"os"
-	"regexp"
 	"strings"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/pardnchiu/agenvoy/internal/agents/exec"
+	"github.com/pardnchiu/agenvoy/configs"
 	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
 	discordTypes "github.com/pardnchiu/agenvoy/internal/discord/types"
+	"github.com/pardnchiu/agenvoy/internal/filesystem/sessionManager"
+	"github.com/pardnchiu/agenvoy/internal/frontend"
 )
 
+// * conversation adapts one Discord message to frontend.Run
+type conversation struct {
+	dcSession      *discordgo.Session
+	dcMessage      *discordgo.MessageCreate
+	receiveMessage *discordTypes.ReceiveMessage
+	sessionID      string
+	channelID      string
+	reference      *discordgo.MessageReference
+	prog           *frontend.Progress
+	replied        bool
+}
+
 func run(ctx context.Context, dcBot *discordTypes.DiscordBot, dcSession *discordgo.Session, dcMessageCreate *discordgo.MessageCreate, receiveMessage *discordTypes.ReceiveMessage) error {
-	workDir, err := os.UserHomeDir()
+	sessionID, err := sessionManager.GetDiscordSession(receiveMessage.GuildID, receiveMessage.ChannelID, receiveMessage.AuthorID)
 	if err != nil {
-		return fmt.Errorf("os.UserHomeDir: %w", err)
-	}
-
-	dcBot.SkillScanner.Scan()
-
-	fileNames := make([]string, len(receiveMessage.FileInputs))
-	for i, f := range receiveMessage.FileInputs {
-		fileNames[i] = f.Name
-	}
-	skill := exec.SelectSkill(ctx, dcBot.PlannerAgent, dcBot.SkillScanner, receiveMessage.Content, fileNames)
-	if skill != nil {
-		slog.Info("skill", slog.String("skill", skill.Name))
-	}
-	agent := exec.SelectAgent(ctx, dcBot.PlannerAgent, dcBot.AgentRegistry, receiveMessage.Content, skill != nil)
-
-	execData := exec.ExecData{
-		Agent:   agent,
-		WorkDir: workDir,
-		Skill:   skill,
-		Content: receiveMessage.Content,
+		return fmt.Errorf("sessionManager.GetDiscordSession: %w", err)
 	}
 
-	session, err := getSession(ctx, dcSession, receiveMessage.GuildID, receiveMessage.ChannelID, receiveMessage.AuthorID, dcMessageCreate.ID, receiveMessage.Content, receiveMessage.ImageInputs, receiveMessage.FileInputs, execData)
-	if err != nil {
-		return fmt.Errorf("loadDiscordSession: %w", err)
+	conv := &conversation{
+		dcSession:      dcSession,
+		dcMessage:      dcMessageCreate,
+		receiveMessage: receiveMessage,
+		sessionID:      sessionID,
+		channelID:      receiveMessage.ChannelID,
+		reference:      dcMessageCreate.Reference(),
 	}
-
-	interactionMax := 128
-	if skill == nil {
-		interactionMax = 16
+	if receiveMessage.ThreadParentID != "" {
+		touchThread(sessionID, receiveMessage.ThreadParentID)
+		// * a new thread cannot reply across to the starter message
+		if conv.channelID != dcMessageCreate.ChannelID {
+			conv.reference = nil
+		}
 	}
-	events := make(chan agentTypes.Event, interactionMax)
 
-	go func() {
-		err := exec.Execute(ctx, execData, session, events, true)
+	conv.prog = frontend.NewProgress(func(content string) (string, error) {
+		msg, err := dcSession.ChannelMessageSendComplex(conv.channelID, &discordgo.MessageSend{
+			Content:   content,
+			Reference: conv.reference,
+		})
 		if err != nil {
-			slog.Warn("exec.Execute",
-				slog.String("error", err.Error()))
+			return "", err
+		}
+		return msg.ID, nil
+	}, func(messageID, content string) error {
+		_, err := dcSession.ChannelMessageEdit(conv.channelID, messageID, content)
+		return err
+	})
+	defer func() {
+		// * placeholder must not stay as "thinking" when no answer is delivered
+		if messageID := conv.prog.Finish(); !conv.replied && messageID != "" {
+			dcSession.ChannelMessageEdit(conv.channelID, messageID, "⚠️ No reply")
 		}
-		close(events)
 	}()
 
-	var replyText string
-	var execErrors []string
-	for e := range events {
-		switch e.Type {
-		case agentTypes.EventSkillResult:
-			slog.Info("EventSkillSelect",
-				slog.Any("skill", e))
-		case agentTypes.EventAgentResult:
-			slog.Info("EventAgentResult",
-				slog.Any("agent", e))
-		case agentTypes.EventText:
-			slog.Info("EventText",
-				slog.Any("text", e.Text))
-			replyText = e.Text
-		case agentTypes.EventExecError:
-			slog.Warn("EventExecError",
-				slog.String("tool", e.ToolName),
-				slog.String("hash", e.Text))
-			execErrors = append(execErrors, fmt.Sprintf("`%s` → `%s`", e.ToolName, e.Text))
-
-		case agentTypes.EventToolCall:
-			slog.Info("EventToolCall",
-				slog.Any("tool", e.ToolName))
-		// * use full name for remindering
-		case agentTypes.EventSkillSelect,
-			agentTypes.EventAgentSelect,
-			agentTypes.EventToolCallStart,
-			agentTypes.EventToolCallEnd,
-			agentTypes.EventToolConfirm,
-			agentTypes.EventToolCallText,
-			agentTypes.EventToolResult,
-			agentTypes.EventToolSkipped,
-			agentTypes.EventDone:
-			break
+	var imageInputs []string
+	for _, imageInput := range receiveMessage.ImageInputs {
+		dataURL, err := frontend.FetchImageDataURL(ctx, imageInput, "")
+		if err != nil {
+			slog.Warn("frontend.FetchImageDataURL",
+				slog.String("error", err.Error()))
+			dataURL = imageInput
 		}
+		imageInputs = append(imageInputs, dataURL)
 	}
-
-	replyText = strings.TrimSpace(regexp.MustCompile(`^ts:\d+\n`).ReplaceAllString(replyText, ""))
-	if replyText == "" {
-		return fmt.Errorf("no reply")
+	var fileInputs []frontend.FileInput
+	for _, fileInput := range receiveMessage.FileInputs {
+		text, err := frontend.FetchText(ctx, fileInput.URL, "")
+		if err != nil {
+			slog.Warn("frontend.FetchText",
+				slog.String("error", err.Error()))
+			continue
+		}
+		fileInputs = append(fileInputs, frontend.FileInput{Name: fileInput.Name, Text: text})
 	}
 
-	fileMarker := regexp.MustCompile(`\[SEND_FILE:([^\]]+)\]`)
-	var filePaths []string
-	for _, match := range fileMarker.FindAllStringSubmatch(replyText, -1) {
-		filePaths = append(filePaths, strings.TrimSpace(match[1]))
-	}
-	replyText = strings.TrimSpace(fileMarker.ReplaceAllString(replyText, ""))
+	return frontend.Run(ctx, frontend.Bot{
+		PlannerAgent:  dcBot.PlannerAgent,
+		AgentRegistry: dcBot.AgentRegistry,
+		SkillScanner:  dcBot.SkillScanner,
+	}, frontend.Request{
+		SessionID:    sessionID,
+		Frontend:     "discord",
+		ChannelID:    receiveMessage.ChannelID,
+		UserID:       receiveMessage.AuthorID,
+		Content:      receiveMessage.Content,
+		ImageInputs:  imageInputs,
+		FileInputs:   fileInputs,
+		SystemPrompt: configs.DiscordSystemPrompt,
+		Policy:       receiveMessage.Policy,
+	}, conv.prog, conv)
+}
+
+func (c *conversation) History(ctx context.Context) []agentTypes.Message {
+	return getHistory(c.dcSession, c.sessionID, c.channelID, c.dcMessage.ID)
+}
 
-	if len(execErrors) > 0 {
-		replyText = fmt.Sprintf("%s\n-# errors: %s", replyText, strings.Join(execErrors, ", "))
+func (c *conversation) Approve(ctx context.Context, toolName, toolArgs string) bool {
+	return requestApproval(ctx, c.dcSession, c.channelID, c.reference, c.receiveMessage.AuthorID, toolName, toolArgs)
+}
+
+func (c *conversation) Reply(ctx context.Context, answer frontend.Answer) error {
+	replyText := answer.Text
+	if len(answer.Errors) > 0 {
+		replyText = fmt.Sprintf("%s\n-# errors: %s", replyText, strings.Join(answer.Errors, ", "))
 	}
-	replyText = fmt.Sprintf("%s\n-# %s", replyText, agent.Name())
+	replyText = fmt.Sprintf("%s\n-# %s", replyText, answer.Footer)
 
 	dr := &discordTypes.DiscordReply{
-		Session:   dcSession,
-		ChannelID: dcMessageCreate.ChannelID,
-		Reference: dcMessageCreate.Reference(),
+		Session:   c.dcSession,
+		ChannelID: c.channelID,
+		Reference: c.reference,
 	}
-	if err := Reply(ctx, dr, discordTypes.ReplyMessage{
+	if err := ReplyEdit(ctx, dr, c.prog.Finish(), discordTypes.ReplyMessage{
 		Content:   replyText,
-		FilePaths: filePaths,
+		FilePaths: answer.FilePaths,
 	}); err != nil {
 		slog.Warn("ReplyDiscord",
 			slog.String("error", err.Error()))
 	}
-
+	c.replied = true
 	return nil
 }
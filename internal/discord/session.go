@@ -1,39 +1,18 @@
 package discord
 
 import (
-	"context"
-	_ "embed"
-	"encoding/base64"
-	"fmt"
-	"io"
-	"log/slog"
-	"net/http"
-	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/pardnchiu/agenvoy/configs"
-	"github.com/pardnchiu/agenvoy/internal/agents/exec"
 	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
-	discordTypes "github.com/pardnchiu/agenvoy/internal/discord/types"
 	"github.com/pardnchiu/agenvoy/internal/filesystem/sessionManager"
+	"github.com/pardnchiu/agenvoy/internal/frontend"
 )
 
-func getSession(ctx context.Context, dcSession *discordgo.Session, guildID, channelID, userID, currentMessageID, input string, imageInputs []string, fileInputs []discordTypes.FileInput, data exec.ExecData) (*agentTypes.AgentSession, error) {
-	sessionID, err := sessionManager.GetDiscordSession(guildID, channelID, userID)
-	if err != nil {
-		return nil, fmt.Errorf("sessionManager.GetDiscordSessionID: %w", err)
-	}
-
-	session := &agentTypes.AgentSession{
-		ID:    sessionID,
-		Tools: []agentTypes.Message{},
-		Messages: []agentTypes.Message{
-			{Role: "system", Content: exec.GetSystemPrompt(data)},
-			{Role: "system", Content: configs.DiscordSystemPrompt},
-		},
-		Histories: []agentTypes.Message{},
+func getHistory(dcSession *discordgo.Session, sessionID, channelID, currentMessageID string) []agentTypes.Message {
+	resetAfter := ""
+	if config, err := sessionManager.GetConfig(sessionID); err == nil {
+		resetAfter = config[sessionManager.ConfigKeyResetAfter]
 	}
 
 	var oldHistory []agentTypes.Message
@@ -44,9 +23,16 @@ func getSession(ctx context.Context, dcSession *discordgo.Session, guildID, chan
 			if msg.Author == nil || msg.Content == "" {
 				continue
 			}
+			if resetAfter != "" && !isNewerID(msg.ID, resetAfter) {
+				continue
+			}
 			role := "user"
 			content := msg.Content
 			if msg.Author.ID == botID {
+				// * progress placeholders and approval prompts are not answers
+				if frontend.IsStatusMessage(content) {
+					continue
+				}
 				role = "assistant"
 				if idx := strings.LastIndex(content, "\n-# "); idx != -1 {
 					content = content[:idx]
@@ -58,119 +44,13 @@ func getSession(ctx context.Context, dcSession *discordgo.Session, guildID, chan
 			})
 		}
 	}
-	session.Messages = append(session.Messages, oldHistory...)
-
-	if summary := sessionManager.GetSummaryPrompt(sessionID); summary != "" {
-		session.Messages = append(session.Messages, agentTypes.Message{
-			Role:    "system",
-			Content: summary,
-		})
-	}
-
-	userText := fmt.Sprintf("當前時間: %s\n當前頻道 ID: %s\n---\n%s", time.Now().Format("2006-01-02 15:04:05"), channelID, strings.TrimSpace(input))
-
-	var userContent any
-	if len(imageInputs) > 0 || len(fileInputs) > 0 {
-		parts := []agentTypes.ContentPart{
-			{Type: "text", Text: userText},
-		}
-
-		for _, imageInput := range imageInputs {
-			dataURL, err := fetchImageDataURL(ctx, imageInput)
-			if err != nil {
-				slog.Warn("fetchImageDataURL",
-					slog.String("error", err.Error()))
-				dataURL = imageInput
-			}
-			parts = append(parts, agentTypes.ContentPart{
-				Type:     "image_url",
-				ImageURL: &agentTypes.ImageURL{URL: dataURL},
-			})
-		}
-
-		for _, fileInput := range fileInputs {
-			text, err := fetchFileText(ctx, fileInput.URL)
-			if err != nil {
-				slog.Warn("fetchFileText",
-					slog.String("error", err.Error()))
-				continue
-			}
-			parts = append(parts, agentTypes.ContentPart{
-				Type: "text",
-				Text: fmt.Sprintf("----------\n%s\n----------\n%s", fileInput.Name, text),
-			})
-		}
-		userContent = parts
-	} else {
-		userContent = userText
-	}
-
-	session.Messages = append(session.Messages, agentTypes.Message{
-		Role:    "user",
-		Content: userContent,
-	})
-
-	return session, nil
+	return oldHistory
 }
 
-func fetchImageDataURL(ctx context.Context, rawURL string) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("http.NewRequestWithContext: %w", err)
-	}
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("http.DefaultClient.Do: %w", err)
-	}
-	defer resp.Body.Close()
-
-	contentType := resp.Header.Get("Content-Type")
-	if idx := strings.Index(contentType, ";"); idx != -1 {
-		contentType = contentType[:idx]
-	}
-	contentType = strings.TrimSpace(contentType)
-	if contentType == "" {
-		base := strings.SplitN(rawURL, "?", 2)[0]
-		ext := strings.ToLower(filepath.Ext(base))
-		switch ext {
-		case ".jpg", ".jpeg":
-			contentType = "image/jpeg"
-		case ".png":
-			contentType = "image/png"
-		case ".gif":
-			contentType = "image/gif"
-		case ".webp":
-			contentType = "image/webp"
-		default:
-			contentType = "image/jpeg"
-		}
-	}
-
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("io.ReadAll: %w", err)
+// * snowflake IDs grow with time, compare by length then lexically
+func isNewerID(id, than string) bool {
+	if len(id) != len(than) {
+		return len(id) > len(than)
 	}
-
-	return fmt.Sprintf("data:%s;base64,%s", contentType, base64.StdEncoding.EncodeToString(data)), nil
-}
-
-func fetchFileText(ctx context.Context, rawURL string) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("http.NewRequestWithContext: %w", err)
-	}
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("http.DefaultClient.Do: %w", err)
-	}
-	defer resp.Body.Close()
-
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("io.ReadAll: %w", err)
-	}
-
-	return string(data), nil
+	return id > than
 }
@@ -0,0 +1,148 @@
+package discord
+
+import (
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pardnchiu/agenvoy/internal/filesystem/sessionManager"
+	"github.com/pardnchiu/agenvoy/internal/utils"
+)
+
+const (
+	threadIdleDefault = 24 * time.Hour
+	threadReapEvery   = 10 * time.Minute
+	// * discord limits thread names to 100 characters
+	threadNameMax = 80
+	// * minutes, longest duration available without boosts
+	threadAutoArchive = 1440
+)
+
+var (
+	threadReaperStop chan struct{}
+	threadReaperOnce sync.Once
+	mentionRegex     = regexp.MustCompile(`<@[!&]?\d+>`)
+)
+
+// * DISCORD_THREADS=true moves every channel mention into its own thread
+func threadsEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("DISCORD_THREADS"))
+	return enabled
+}
+
+// * DISCORD_THREAD_IDLE accepts go duration format, e.g. 6h or 90m
+func threadIdle() time.Duration {
+	if value := os.Getenv("DISCORD_THREAD_IDLE"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+		slog.Warn("invalid DISCORD_THREAD_IDLE, using default",
+			slog.String("value", value))
+	}
+	return threadIdleDefault
+}
+
+// * botThread reports the parent channel when channelID is a thread started by the bot
+func botThread(dcSession *discordgo.Session, channelID string) (string, bool) {
+	channel, err := dcSession.State.Channel(channelID)
+	if err != nil {
+		if channel, err = dcSession.Channel(channelID); err != nil {
+			return "", false
+		}
+	}
+	if !channel.IsThread() || channel.OwnerID != dcSession.State.User.ID {
+		return "", false
+	}
+	return channel.ParentID, true
+}
+
+func startThread(dcSession *discordgo.Session, dcMessageCreate *discordgo.MessageCreate) (*discordgo.Channel, error) {
+	name := strings.Join(strings.Fields(mentionRegex.ReplaceAllString(dcMessageCreate.Content, "")), " ")
+	if name == "" {
+		name = "Chat with " + dcMessageCreate.Author.Username
+	}
+	name = strings.TrimSuffix(utils.TruncateUTF8(name, threadNameMax), "\n...(truncated)")
+
+	return dcSession.MessageThreadStartComplex(dcMessageCreate.ChannelID, dcMessageCreate.ID, &discordgo.ThreadStart{
+		Name:                name,
+		AutoArchiveDuration: threadAutoArchive,
+	})
+}
+
+// * touchThread marks the session as a thread session and refreshes its activity
+func touchThread(sessionID, parentID string) {
+	if err := sessionManager.SetConfig(sessionID, sessionManager.ConfigKeyThreadParent, parentID); err != nil {
+		slog.Warn("sessionManager.SetConfig",
+			slog.String("error", err.Error()))
+		return
+	}
+	if err := sessionManager.SetConfig(sessionID, sessionManager.ConfigKeyLastActive, strconv.FormatInt(time.Now().Unix(), 10)); err != nil {
+		slog.Warn("sessionManager.SetConfig",
+			slog.String("error", err.Error()))
+	}
+}
+
+func startThreadReaper(dcSession *discordgo.Session) {
+	threadReaperStop = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(threadReapEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				reapThreads(dcSession, threadIdle())
+			case <-threadReaperStop:
+				return
+			}
+		}
+	}()
+}
+
+func stopThreadReaper() {
+	if threadReaperStop == nil {
+		return
+	}
+	threadReaperOnce.Do(func() {
+		close(threadReaperStop)
+	})
+}
+
+// * reapThreads archives idle threads, their sessions keep history, summary and tool logs
+func reapThreads(dcSession *discordgo.Session, idle time.Duration) {
+	sessions, err := sessionManager.ListThreadSessions()
+	if err != nil {
+		slog.Warn("sessionManager.ListThreadSessions",
+			slog.String("error", err.Error()))
+		return
+	}
+
+	archived := true
+	for sessionID, config := range sessions {
+		lastActive, err := strconv.ParseInt(config[sessionManager.ConfigKeyLastActive], 10, 64)
+		if err == nil && time.Since(time.Unix(lastActive, 0)) < idle {
+			continue
+		}
+
+		if threadID := config["channel_id"]; threadID != "" {
+			if _, err := dcSession.ChannelEditComplex(threadID, &discordgo.ChannelEdit{
+				Archived: &archived,
+			}); err != nil {
+				slog.Warn("failed to archive thread",
+					slog.String("thread", threadID),
+					slog.String("error", err.Error()))
+			}
+		}
+		if err := sessionManager.ArchiveThreadSession(sessionID); err != nil {
+			slog.Warn("sessionManager.ArchiveThreadSession",
+				slog.String("error", err.Error()))
+			continue
+		}
+		slog.Info("thread session archived",
+			slog.String("session", sessionID))
+	}
+}
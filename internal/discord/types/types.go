@@ -2,6 +2,7 @@ package discordTypes
 
 import (
 	"github.com/bwmarrin/discordgo"
+	"github.com/pardnchiu/agenvoy/internal/access"
 	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
 	"github.com/pardnchiu/agenvoy/internal/skill"
 )
@@ -20,10 +21,12 @@ type FileInput struct {
 }
 
 type ReceiveMessage struct {
+	MessageID   string
 	GuildID     string
 	ChannelID   string
 	AuthorID    string
 	AuthorName  string
+	AuthorRoles []string
 	Content     string
 	ImageInputs []string
 	FileInputs  []FileInput
@@ -32,6 +35,10 @@ type ReceiveMessage struct {
 	IsChannel   bool
 	IsMention   bool
 	RecievedAt  int64
+	// * parent channel when the conversation runs in a bot thread
+	ThreadParentID string
+	// * resolved from access.json before run
+	Policy access.Policy
 }
 
 type DiscordReply struct {
@@ -42,7 +49,8 @@ type DiscordReply struct {
 }
 
 type ReplyMessage struct {
-	Content   string
-	ImageURL  string
-	FilePaths []string
+	Content    string
+	ImageURL   string
+	FilePaths  []string
+	Components []discordgo.MessageComponent
 }
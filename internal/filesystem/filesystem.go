@@ -11,18 +11,29 @@ import (
 )
 
 var (
-	once         sync.Once
-	AgenvoyDir   string
-	ConfigPath   string
-	SessionsDir  string
-	APIsDir      string
-	ErrorsDir    string
-	SchedulerDir string
-	TasksPath    string
-	CronsPath    string
-	ScriptsDir   string
-	SkillsDir    string
-	ToolsDir     string
+	once            sync.Once
+	AgenvoyDir      string
+	ConfigPath      string
+	SessionsDir     string
+	APIsDir         string
+	ErrorsDir       string
+	SchedulerDir    string
+	TasksPath       string
+	CronsPath       string
+	JobsPath        string
+	ScriptsDir      string
+	OutputsDir      string
+	DeadLetterPath  string
+	ControlPath     string
+	DaemonPIDPath   string
+	SkillsDir       string
+	ToolsDir        string
+	LSPPath         string
+	PersonasDir     string
+	AccessPath      string
+	UsageDir        string
+	MemoryPath      string
+	HistoryIndexDir string
 
 	WorkAgenvoyDir string
 	WorkAPIsDir    string
@@ -54,10 +65,21 @@ func Init() error {
 		SchedulerDir = filepath.Join(AgenvoyDir, "scheduler")
 		TasksPath = filepath.Join(SchedulerDir, "tasks")
 		CronsPath = filepath.Join(SchedulerDir, "crons")
+		JobsPath = filepath.Join(SchedulerDir, "jobs.json")
 		ScriptsDir = filepath.Join(SchedulerDir, "scripts")
+		OutputsDir = filepath.Join(SchedulerDir, "outputs")
+		DeadLetterPath = filepath.Join(SchedulerDir, "dead_letter.jsonl")
+		ControlPath = filepath.Join(SchedulerDir, "control.sock")
+		DaemonPIDPath = filepath.Join(AgenvoyDir, "daemon.pid")
 
 		SkillsDir = filepath.Join(AgenvoyDir, "skills")
 		ToolsDir = filepath.Join(AgenvoyDir, "tools")
+		LSPPath = filepath.Join(AgenvoyDir, "lsp.json")
+		PersonasDir = filepath.Join(AgenvoyDir, "personas")
+		AccessPath = filepath.Join(AgenvoyDir, "access.json")
+		UsageDir = filepath.Join(AgenvoyDir, "usage")
+		MemoryPath = filepath.Join(AgenvoyDir, "memory.json")
+		HistoryIndexDir = filepath.Join(AgenvoyDir, "history_index")
 
 		WorkAgenvoyDir = filepath.Join(workDir, ".config", projectName)
 		WorkAPIsDir = filepath.Join(WorkAgenvoyDir, "apis")
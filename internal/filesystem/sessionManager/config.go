@@ -0,0 +1,112 @@
+package sessionManager
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+)
+
+const (
+	// * AgentRegistry entry pinned by /model
+	ConfigKeyModel = "model"
+	// * messages at or before this ID are dropped from history after /reset
+	ConfigKeyResetAfter = "reset_after"
+	// * parent channel of a bot created thread, marks the session as a thread session
+	ConfigKeyThreadParent = "thread_parent"
+	// * unix seconds of the latest message handled in a thread session
+	ConfigKeyLastActive = "last_active"
+)
+
+func GetConfig(sessionID string) (map[string]string, error) {
+	if sessionID == "" {
+		return nil, fmt.Errorf("sessionID is required")
+	}
+
+	configPath := filepath.Join(filesystem.SessionsDir, sessionID, "config.json")
+	bytes, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	var config map[string]string
+	if err := json.Unmarshal(bytes, &config); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	if config == nil {
+		config = map[string]string{}
+	}
+	return config, nil
+}
+
+// * SetConfig updates a single key in session config.json, empty value removes the key
+func SetConfig(sessionID, key, value string) error {
+	config, err := GetConfig(sessionID)
+	if err != nil {
+		return err
+	}
+
+	if value == "" {
+		delete(config, key)
+	} else {
+		config[key] = value
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+	configPath := filepath.Join(filesystem.SessionsDir, sessionID, "config.json")
+	if err := filesystem.WriteFile(configPath, string(data), 0644); err != nil {
+		return fmt.Errorf("WriteFile: %w", err)
+	}
+	return nil
+}
+
+// * ResetSession drops stored history and summary, config is kept
+func ResetSession(sessionID string) error {
+	sessionDir := filepath.Join(filesystem.SessionsDir, sessionID)
+	for _, name := range []string{"history.json", "summary.json"} {
+		if err := os.Remove(filepath.Join(sessionDir, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("os.Remove: %w", err)
+		}
+	}
+	return nil
+}
+
+// * ListThreadSessions returns config of every session created for a Discord thread
+func ListThreadSessions() (map[string]map[string]string, error) {
+	entries, err := os.ReadDir(filesystem.SessionsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("os.ReadDir: %w", err)
+	}
+
+	sessions := map[string]map[string]string{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		config, err := GetConfig(entry.Name())
+		if err != nil || config[ConfigKeyThreadParent] == "" {
+			continue
+		}
+		sessions[entry.Name()] = config
+	}
+	return sessions, nil
+}
+
+// * ArchiveThreadSession drops the thread markers so the session is no longer reaped, its recorded files stay
+func ArchiveThreadSession(sessionID string) error {
+	if err := SetConfig(sessionID, ConfigKeyThreadParent, ""); err != nil {
+		return err
+	}
+	return SetConfig(sessionID, ConfigKeyLastActive, "")
+}
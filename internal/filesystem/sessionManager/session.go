@@ -16,18 +16,22 @@ import (
 	"github.com/pardnchiu/agenvoy/internal/filesystem"
 )
 
-func SaveToToolCall(sessionID, content string) {
+// * SaveToToolCall writes the tool log of a turn and returns its path relative to the session, empty on failure
+func SaveToToolCall(sessionID, content string) string {
 	now := time.Now()
 	date := now.Format("2006-01-02")
 	toolCallsDir := filepath.Join(filesystem.SessionsDir, sessionID, "tool_calls", date)
-	if err := os.MkdirAll(toolCallsDir, 0755); err == nil {
-		filename := fmt.Sprintf("%s.json", now.Format("2006-01-02-15-04-05"))
-		toolActionsPath := filepath.Join(toolCallsDir, filename)
-		if err := filesystem.WriteFile(toolActionsPath, content, 0644); err != nil {
-			slog.Warn("WriteFile",
-				slog.String("error", err.Error()))
-		}
+	if err := os.MkdirAll(toolCallsDir, 0755); err != nil {
+		return ""
+	}
+	filename := fmt.Sprintf("%s.json", now.Format("2006-01-02-15-04-05"))
+	toolActionsPath := filepath.Join(toolCallsDir, filename)
+	if err := filesystem.WriteFile(toolActionsPath, content, 0644); err != nil {
+		slog.Warn("WriteFile",
+			slog.String("error", err.Error()))
+		return ""
 	}
+	return filepath.Join("tool_calls", date, filename)
 }
 
 func CreateSession() (string, error) {
@@ -87,6 +91,46 @@ func GetDiscordSession(guildID, channelID, userID string) (string, error) {
 			"channel_id": channelID,
 		}
 	}
+	return keyedSession(key, config)
+}
+
+// * GetSlackSession keys channel conversations by thread, DMs by channel and user
+func GetSlackSession(teamID, channelID, threadTS, userID string) (string, error) {
+	var key string
+	config := map[string]string{
+		"team_id": teamID,
+		// * prefixed so scheduler callbacks are routed back to slack
+		"channel_id": "slack:" + channelID,
+	}
+	if threadTS != "" {
+		key = fmt.Sprintf("slack_%s_%s_%s", teamID, channelID, threadTS)
+		config["thread_ts"] = threadTS
+	} else {
+		key = fmt.Sprintf("slack_%s_%s_%s", teamID, channelID, userID)
+		config["user_id"] = userID
+	}
+	return keyedSession(key, config)
+}
+
+// * GetTelegramSession keys sessions by chat, groups share one session like discord channels
+func GetTelegramSession(chatID, userID string) (string, error) {
+	return keyedSession("telegram_"+chatID, map[string]string{
+		// * prefixed so scheduler callbacks are routed back to telegram
+		"channel_id": "telegram:" + chatID,
+		"user_id":    userID,
+	})
+}
+
+// * GetSchedulerSession gives each scheduled prompt its own session, runs see earlier answers
+func GetSchedulerSession(jobID, target string) (string, error) {
+	return keyedSession("scheduler_"+jobID, map[string]string{
+		// * jobs added by the agent during a run default to the same target
+		"channel_id": target,
+	})
+}
+
+// * keyedSession derives a stable session ID from key, config is written on first use
+func keyedSession(key string, config map[string]string) (string, error) {
 	sum := sha256.Sum256([]byte(key))
 
 	sessionID := hex.EncodeToString(sum[:])
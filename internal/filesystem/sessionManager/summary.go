@@ -2,9 +2,12 @@ package sessionManager
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/pardnchiu/agenvoy/configs"
@@ -49,3 +52,72 @@ func SaveSummary(sessionID string, data any) {
 			slog.String("error", err.Error()))
 	}
 }
+
+// * summary fields written by the agent, see the summary block of the system prompt
+var (
+	summaryTextFields = []string{"core_discussion"}
+	summaryListFields = []string{"confirmed_needs", "constraints", "excluded_options", "key_data", "current_conclusion", "pending_questions"}
+	summaryLogFields  = []string{"topic", "time", "conclusion"}
+)
+
+// * ValidateSummary checks a summary against the fields the agent writes, every problem is reported
+func ValidateSummary(summary map[string]any) error {
+	var errs []error
+	for key, value := range summary {
+		switch {
+		case slices.Contains(summaryTextFields, key):
+			if _, ok := value.(string); !ok {
+				errs = append(errs, fmt.Errorf("%s: must be a string", key))
+			}
+		case slices.Contains(summaryListFields, key):
+			list, ok := value.([]any)
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: must be an array of strings", key))
+				continue
+			}
+			for i, item := range list {
+				if _, ok := item.(string); !ok {
+					errs = append(errs, fmt.Errorf("%s[%d]: must be a string", key, i))
+				}
+			}
+		case key == "discussion_log":
+			list, ok := value.([]any)
+			if !ok {
+				errs = append(errs, fmt.Errorf("discussion_log: must be an array of objects"))
+				continue
+			}
+			for i, item := range list {
+				entry, ok := item.(map[string]any)
+				if !ok {
+					errs = append(errs, fmt.Errorf("discussion_log[%d]: must be an object", i))
+					continue
+				}
+				if topic, _ := entry["topic"].(string); strings.TrimSpace(topic) == "" {
+					errs = append(errs, fmt.Errorf("discussion_log[%d].topic: required", i))
+				}
+				for field, v := range entry {
+					if !slices.Contains(summaryLogFields, field) {
+						errs = append(errs, fmt.Errorf("discussion_log[%d]: unknown field %q", i, field))
+					} else if _, ok := v.(string); !ok {
+						errs = append(errs, fmt.Errorf("discussion_log[%d].%s: must be a string", i, field))
+					}
+				}
+			}
+		default:
+			errs = append(errs, fmt.Errorf("unknown field %q", key))
+		}
+	}
+	// * map order is random, keep the report stable
+	slices.SortFunc(errs, func(a, b error) int {
+		return strings.Compare(a.Error(), b.Error())
+	})
+	return errors.Join(errs...)
+}
+
+// * ClearSummary removes the summary of a session
+func ClearSummary(sessionID string) error {
+	if err := os.Remove(SummaryPath(sessionID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("os.Remove: %w", err)
+	}
+	return nil
+}
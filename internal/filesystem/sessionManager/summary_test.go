@@ -0,0 +1,44 @@
+package sessionManager
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateSummary(t *testing.T) {
+	valid := `{
+		"core_discussion": "deploy",
+		"confirmed_needs": ["a"],
+		"pending_questions": [],
+		"discussion_log": [{"topic": "t", "time": "2026-10-19 09:00", "conclusion": "resolved"}]
+	}`
+	var summary map[string]any
+	json.Unmarshal([]byte(valid), &summary)
+	if err := ValidateSummary(summary); err != nil {
+		t.Errorf("valid summary: %v", err)
+	}
+
+	invalid := `{
+		"core_discussion": ["not a string"],
+		"constraints": ["ok", 1],
+		"discussion_log": [{"time": "x"}, {"topic": "t", "extra": "y"}],
+		"bogus": true
+	}`
+	json.Unmarshal([]byte(invalid), &summary)
+	err := ValidateSummary(summary)
+	if err == nil {
+		t.Fatalf("invalid summary accepted")
+	}
+	for _, want := range []string{
+		"core_discussion: must be a string",
+		"constraints[1]: must be a string",
+		"discussion_log[0].topic: required",
+		`discussion_log[1]: unknown field "extra"`,
+		`unknown field "bogus"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in:\n%v", want, err)
+		}
+	}
+}
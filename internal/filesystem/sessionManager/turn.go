@@ -0,0 +1,69 @@
+package sessionManager
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+)
+
+// * Turn records how one user input was answered, history.json keeps only the text
+type Turn struct {
+	StartedAt        time.Time `json:"started_at"`
+	EndedAt          time.Time `json:"ended_at"`
+	Agent            string    `json:"agent"`
+	Skill            string    `json:"skill,omitempty"`
+	ToolCalls        string    `json:"tool_calls,omitempty"` // * relative to the session directory
+	PromptTokens     int       `json:"prompt_tokens,omitempty"`
+	CompletionTokens int       `json:"completion_tokens,omitempty"`
+	TotalTokens      int       `json:"total_tokens,omitempty"`
+}
+
+// * SaveTurn appends turn to turns.jsonl, one line per write so concurrent turns do not interleave
+func SaveTurn(sessionID string, turn Turn) error {
+	sessionDir := filepath.Join(filesystem.SessionsDir, sessionID)
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		return fmt.Errorf("os.MkdirAll: %w", err)
+	}
+	data, err := json.Marshal(turn)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(sessionDir, "turns.jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("os.OpenFile: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("file.Write: %w", err)
+	}
+	return nil
+}
+
+// * GetTurns reads turns.jsonl oldest first, sessions before it existed have none
+func GetTurns(sessionID string) ([]Turn, error) {
+	file, err := os.Open(filepath.Join(filesystem.SessionsDir, sessionID, "turns.jsonl"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("os.Open: %w", err)
+	}
+	defer file.Close()
+
+	var turns []Turn
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var turn Turn
+		if json.Unmarshal(scanner.Bytes(), &turn) == nil {
+			turns = append(turns, turn)
+		}
+	}
+	return turns, scanner.Err()
+}
@@ -0,0 +1,57 @@
+package frontend
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/pardnchiu/agenvoy/internal/access"
+)
+
+const DeniedNotice = "🚫 Sorry, I'm not available to you here. Please ask an admin if you need access."
+
+// * ResolvePolicy checks the frontend's section of access.json, the notice explains a refusal and is empty when the refusal stays silent
+func ResolvePolicy(frontend string, p access.Principal) (access.Policy, string, bool) {
+	config, err := access.Load(frontend)
+	if err != nil {
+		// * broken access.json must not open the bot to everyone
+		slog.Warn("access.Load",
+			slog.String("error", err.Error()))
+		return access.Policy{}, "", false
+	}
+
+	// * discord stays open without its section, the newer frontends stay closed until it lists someone
+	if config == nil && frontend != "discord" {
+		slog.Info("access denied, no section in access.json",
+			slog.String("frontend", frontend),
+			slog.String("user", p.UserID))
+		return access.Policy{}, DeniedNotice, false
+	}
+
+	policy, err := config.Resolve(p)
+	if errors.Is(err, access.ErrNotAllowed) {
+		slog.Info("access denied",
+			slog.String("frontend", frontend),
+			slog.String("user", p.UserID),
+			slog.String("channel", p.ChannelID))
+		return access.Policy{}, DeniedNotice, false
+	}
+	return policy, "", true
+}
+
+// * CheckAccess adds today's quota to ResolvePolicy, used before a conversation turn
+func CheckAccess(frontend string, p access.Principal) (access.Policy, string, bool) {
+	policy, notice, ok := ResolvePolicy(frontend, p)
+	if !ok {
+		return policy, notice, false
+	}
+
+	if reason := policy.Exceeded(access.Today(Principal(frontend, p.UserID))); reason != "" {
+		slog.Info("quota exceeded",
+			slog.String("frontend", frontend),
+			slog.String("user", p.UserID),
+			slog.String("reason", reason))
+		return access.Policy{}, fmt.Sprintf("🚫 Sorry, you've hit your %s for today. Please try again tomorrow.", reason), false
+	}
+	return policy, "", true
+}
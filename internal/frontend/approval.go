@@ -0,0 +1,108 @@
+package frontend
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ApproveTimeout = 2 * time.Minute
+
+// * Approval is one pending tool approval, frontends render its buttons around Token
+type Approval struct {
+	Token       string
+	requesterID string
+	decision    chan bool
+}
+
+var (
+	approvalMu sync.Mutex
+	approvals  = map[string]*Approval{}
+)
+
+// * NewApproval registers an approval only requesterID or an admin may decide, Close drops it
+func NewApproval(requesterID string) (*Approval, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	a := &Approval{
+		Token:       hex.EncodeToString(b),
+		requesterID: requesterID,
+		decision:    make(chan bool, 1),
+	}
+	approvalMu.Lock()
+	approvals[a.Token] = a
+	approvalMu.Unlock()
+	return a, nil
+}
+
+func (a *Approval) Close() {
+	approvalMu.Lock()
+	delete(approvals, a.Token)
+	approvalMu.Unlock()
+}
+
+// * Wait blocks until decided, timed out or cancelled, status is empty only when someone decided
+func (a *Approval) Wait(ctx context.Context) (bool, string) {
+	timer := time.NewTimer(ApproveTimeout)
+	defer timer.Stop()
+
+	select {
+	case ok := <-a.decision:
+		return ok, ""
+	case <-timer.C:
+		return false, "⌛ Timed out, denied"
+	case <-ctx.Done():
+		return false, "Cancelled"
+	}
+}
+
+// * Decide records a click on the approval named by token, the notice explains a refused click and is empty once accepted
+func Decide(token, userID string, admin, approved bool) string {
+	approvalMu.Lock()
+	a, exist := approvals[token]
+	approvalMu.Unlock()
+	if !exist {
+		return "This approval has expired"
+	}
+	if userID != a.requesterID && !admin {
+		return "Only the requester or an admin can decide"
+	}
+
+	// * first click wins
+	select {
+	case a.decision <- approved:
+		return ""
+	default:
+		return "Already decided"
+	}
+}
+
+// * IsAdminUser reports whether userID is listed in the comma separated env
+func IsAdminUser(env, userID string) bool {
+	var admins []string
+	for _, id := range strings.Split(os.Getenv(env), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			admins = append(admins, id)
+		}
+	}
+	return slices.Contains(admins, userID)
+}
+
+// * IndentArgs pretty prints JSON tool arguments for approval prompts, other text is kept
+func IndentArgs(args string) string {
+	var v any
+	if err := json.Unmarshal([]byte(args), &v); err == nil {
+		if data, err := json.MarshalIndent(v, "", "  "); err == nil {
+			return string(data)
+		}
+	}
+	return args
+}
@@ -0,0 +1,43 @@
+package frontend
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDecide(t *testing.T) {
+	if notice := Decide("missing", "u1", true, true); notice != "This approval has expired" {
+		t.Errorf("unknown token: %q", notice)
+	}
+
+	a, err := NewApproval("u1")
+	if err != nil {
+		t.Fatalf("NewApproval: %v", err)
+	}
+	defer a.Close()
+
+	if notice := Decide(a.Token, "u2", false, true); notice != "Only the requester or an admin can decide" {
+		t.Errorf("other user: %q", notice)
+	}
+	if notice := Decide(a.Token, "u2", true, false); notice != "" {
+		t.Errorf("admin: %q", notice)
+	}
+	// * first click wins
+	if notice := Decide(a.Token, "u1", false, true); notice != "Already decided" {
+		t.Errorf("second click: %q", notice)
+	}
+	if approved, status := a.Wait(context.Background()); approved || status != "" {
+		t.Errorf("Wait() = %v, %q", approved, status)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if approved, status := a.Wait(ctx); approved || status != "Cancelled" {
+		t.Errorf("cancelled Wait() = %v, %q", approved, status)
+	}
+
+	a.Close()
+	if notice := Decide(a.Token, "u1", false, true); notice != "This approval has expired" {
+		t.Errorf("closed: %q", notice)
+	}
+}
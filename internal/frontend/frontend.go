@@ -0,0 +1,350 @@
+package frontend
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/access"
+	"github.com/pardnchiu/agenvoy/internal/agents/exec"
+	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
+	"github.com/pardnchiu/agenvoy/internal/filesystem/sessionManager"
+	"github.com/pardnchiu/agenvoy/internal/memory"
+	"github.com/pardnchiu/agenvoy/internal/persona"
+	"github.com/pardnchiu/agenvoy/internal/skill"
+	"github.com/pardnchiu/agenvoy/internal/tools"
+)
+
+var (
+	tsRegex       = regexp.MustCompile(`^ts:\d+\n`)
+	sendFileRegex = regexp.MustCompile(`\[SEND_FILE:([^\]]+)\]`)
+)
+
+type Bot struct {
+	PlannerAgent  agentTypes.Agent
+	AgentRegistry agentTypes.AgentRegistry
+	SkillScanner  *skill.SkillScanner
+}
+
+type FileInput struct {
+	Name string
+	Text string
+}
+
+// * Request is one user turn, inputs already downloaded by the frontend
+type Request struct {
+	SessionID    string
+	Frontend     string // * discord, slack, telegram or scheduler
+	ChannelID    string // * target for scheduler callbacks, shown to the agent
+	UserID       string
+	Content      string
+	ImageInputs  []string // * data URLs or public URLs
+	FileInputs   []FileInput
+	SystemPrompt string // * frontend output rules
+	Policy       access.Policy
+	// * platforms without readable chat history keep turns in history.json
+	StoreHistory bool
+	// * pinned by scheduled prompts, selected by the planner when empty
+	Skill string
+	Agent string
+}
+
+type Answer struct {
+	Text      string
+	FilePaths []string // * from [SEND_FILE:] markers
+	Errors    []string // * tool errors as "tool → hash"
+	Footer    string   // * agent and persona names
+}
+
+// * Conversation is what a chat frontend provides to Run
+type Conversation interface {
+	// * History returns earlier turns of this conversation, oldest first
+	History(ctx context.Context) []agentTypes.Message
+	// * Approve blocks until the requester or an admin decides on the tool call
+	Approve(ctx context.Context, toolName, toolArgs string) bool
+	// * Reply delivers the final answer in place of the progress message
+	Reply(ctx context.Context, answer Answer) error
+}
+
+// * Run selects skill and agent, executes the turn and hands the answer to conv
+func Run(ctx context.Context, bot Bot, req Request, prog *Progress, conv Conversation) error {
+	workDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("os.UserHomeDir: %w", err)
+	}
+
+	role := persona.ForSession(req.SessionID)
+
+	if err := access.AddRequest(req.Principal()); err != nil {
+		slog.Warn("access.AddRequest",
+			slog.String("error", err.Error()))
+	}
+
+	bot.SkillScanner.Scan()
+	scanner := bot.SkillScanner
+	if role != nil && len(role.Skills) > 0 {
+		scanner = scanner.Filter(role.Skills)
+	}
+
+	// * selection runs with the turn, the progress log follows the events it reports
+	events := make(chan agentTypes.Event, 128)
+	var agent agentTypes.Agent
+	go func() {
+		defer close(events)
+
+		events <- agentTypes.Event{Type: agentTypes.EventSkillSelect}
+		skill := pinnedSkill(scanner, req.Skill)
+		if skill == nil {
+			fileNames := make([]string, len(req.FileInputs))
+			for i, f := range req.FileInputs {
+				fileNames[i] = f.Name
+			}
+			skill = exec.SelectSkill(ctx, bot.PlannerAgent, scanner, req.Content, fileNames)
+		}
+		skillName := "none"
+		if skill != nil {
+			skillName = skill.Name
+		}
+		events <- agentTypes.Event{Type: agentTypes.EventSkillResult, Text: skillName}
+
+		events <- agentTypes.Event{Type: agentTypes.EventAgentSelect}
+		agent = selectAgent(ctx, bot, req, role, skill != nil)
+		events <- agentTypes.Event{Type: agentTypes.EventAgentResult, Text: agent.Name()}
+
+		execData := exec.ExecData{
+			Agent:     agent,
+			WorkDir:   workDir,
+			Skill:     skill,
+			Content:   req.Content,
+			Principal: req.Principal(),
+		}
+		session := buildSession(ctx, req, conv, execData, role)
+		if err := exec.Execute(ctx, execData, session, events, false); err != nil {
+			slog.Warn("exec.Execute",
+				slog.String("error", err.Error()))
+		}
+	}()
+
+	var replyText string
+	var execErrors []string
+	for e := range events {
+		switch e.Type {
+		case agentTypes.EventText:
+			slog.Info("EventText",
+				slog.Any("text", e.Text))
+			replyText = e.Text
+		case agentTypes.EventExecError:
+			slog.Warn("EventExecError",
+				slog.String("tool", e.ToolName),
+				slog.String("hash", e.Text))
+			execErrors = append(execErrors, fmt.Sprintf("`%s` → `%s`", e.ToolName, e.Text))
+			prog.Add(fmt.Sprintf("❌ `%s` failed", e.ToolName))
+		case agentTypes.EventToolCall:
+			slog.Info("EventToolCall",
+				slog.Any("tool", e.ToolName))
+			prog.ToolCall(e.ToolID, e.ToolName, e.ToolArgs)
+		case agentTypes.EventToolResult:
+			prog.ToolDone(e.ToolID, "✅")
+		case agentTypes.EventToolSkipped:
+			prog.ToolDone(e.ToolID, "⛔")
+		case agentTypes.EventUsage:
+			if err := access.AddTokens(req.Principal(), e.Tokens); err != nil {
+				slog.Warn("access.AddTokens",
+					slog.String("error", err.Error()))
+			}
+		// * tier decides, approval waits for the requester or an admin
+		case agentTypes.EventToolConfirm:
+			allow, ask := req.Policy.ToolDecision(tools.IsReadOnly(e.ToolName))
+			if !allow || !ask {
+				e.ReplyCh <- allow
+				continue
+			}
+			prog.SetHeader("🔐 Waiting for approval…")
+			e.ReplyCh <- conv.Approve(ctx, e.ToolName, e.ToolArgs)
+			prog.SetHeader("⏳ Working…")
+		case agentTypes.EventSkillSelect:
+			prog.SetHeader("🔎 Selecting skill…")
+		case agentTypes.EventSkillResult:
+			if e.Text != "none" {
+				slog.Info("skill", slog.String("skill", e.Text))
+				prog.Add(fmt.Sprintf("📚 skill `%s`", e.Text))
+			}
+		case agentTypes.EventAgentSelect:
+			prog.SetHeader("🔎 Selecting agent…")
+		case agentTypes.EventAgentResult:
+			prog.Add(fmt.Sprintf("🧠 agent `%s`", e.Text))
+			prog.SetHeader("⏳ Working…")
+		// * use full name for remindering
+		case agentTypes.EventToolCallStart,
+			agentTypes.EventToolCallEnd,
+			agentTypes.EventToolCallText,
+			agentTypes.EventDone:
+			break
+		}
+	}
+
+	replyText = strings.TrimSpace(tsRegex.ReplaceAllString(replyText, ""))
+	if replyText == "" {
+		return fmt.Errorf("no reply")
+	}
+
+	var filePaths []string
+	for _, match := range sendFileRegex.FindAllStringSubmatch(replyText, -1) {
+		filePaths = append(filePaths, strings.TrimSpace(match[1]))
+	}
+	replyText = strings.TrimSpace(sendFileRegex.ReplaceAllString(replyText, ""))
+
+	footer := agent.Name()
+	if role != nil {
+		footer = fmt.Sprintf("%s · %s", footer, role.Name)
+	}
+
+	return conv.Reply(ctx, Answer{
+		Text:      replyText,
+		FilePaths: filePaths,
+		Errors:    execErrors,
+		Footer:    footer,
+	})
+}
+
+// * Principal names the requester across frontends, user IDs alone may collide
+func (req Request) Principal() string {
+	return Principal(req.Frontend, req.UserID)
+}
+
+func Principal(frontend, userID string) string {
+	return frontend + ":" + userID
+}
+
+// * pinnedSkill returns the skill pinned by a scheduled prompt, nil falls back to selection
+func pinnedSkill(scanner *skill.SkillScanner, name string) *skill.Skill {
+	if name == "" {
+		return nil
+	}
+	if pinned, ok := scanner.Skills.ByName[name]; ok {
+		return pinned
+	}
+	slog.Warn("pinned skill not found, fallback to selection",
+		slog.String("skill", name))
+	return nil
+}
+
+// * pinned model by /model wins over persona preference
+func selectAgent(ctx context.Context, bot Bot, req Request, role *persona.Persona, hasSkill bool) agentTypes.Agent {
+	if req.Agent != "" {
+		if a, ok := bot.AgentRegistry.Registry[req.Agent]; ok {
+			return a
+		}
+		slog.Warn("pinned agent not in registry, fallback to selection",
+			slog.String("agent", req.Agent))
+	}
+	if config, err := sessionManager.GetConfig(req.SessionID); err == nil && config[sessionManager.ConfigKeyModel] != "" {
+		if a, ok := bot.AgentRegistry.Registry[config[sessionManager.ConfigKeyModel]]; ok {
+			return a
+		}
+		slog.Warn("pinned model not in registry, fallback to selection",
+			slog.String("model", config[sessionManager.ConfigKeyModel]))
+	}
+	if role != nil && role.Agent != "" {
+		if a, ok := bot.AgentRegistry.Registry[role.Agent]; ok {
+			return a
+		}
+		slog.Warn("persona agent not in registry, fallback to selection",
+			slog.String("persona", role.Name),
+			slog.String("agent", role.Agent))
+	}
+	return exec.SelectAgent(ctx, bot.PlannerAgent, bot.AgentRegistry, req.Content, hasSkill)
+}
+
+func buildSession(ctx context.Context, req Request, conv Conversation, data exec.ExecData, role *persona.Persona) *agentTypes.AgentSession {
+	session := &agentTypes.AgentSession{
+		ID:    req.SessionID,
+		Tools: []agentTypes.Message{},
+		Messages: []agentTypes.Message{
+			{Role: "system", Content: exec.GetSystemPrompt(data)},
+		},
+		Histories: []agentTypes.Message{},
+	}
+	if req.SystemPrompt != "" {
+		session.Messages = append(session.Messages, agentTypes.Message{
+			Role:    "system",
+			Content: req.SystemPrompt,
+		})
+	}
+	if role != nil && strings.TrimSpace(role.SystemPrompt) != "" {
+		session.Messages = append(session.Messages, agentTypes.Message{
+			Role:    "system",
+			Content: role.Prompt(),
+		})
+	}
+	session.Messages = append(session.Messages, conv.History(ctx)...)
+
+	if summary := sessionManager.GetSummaryPrompt(req.SessionID); summary != "" {
+		session.Messages = append(session.Messages, agentTypes.Message{
+			Role:    "system",
+			Content: summary,
+		})
+	}
+	if memories := memory.Prompt(ctx, req.Content, req.Principal()); memories != "" {
+		session.Messages = append(session.Messages, agentTypes.Message{
+			Role:    "system",
+			Content: memories,
+		})
+	}
+
+	userText := fmt.Sprintf("當前時間: %s\n當前頻道 ID: %s\n---\n%s", time.Now().Format("2006-01-02 15:04:05"), req.ChannelID, strings.TrimSpace(req.Content))
+
+	var userContent any
+	if len(req.ImageInputs) > 0 || len(req.FileInputs) > 0 {
+		parts := []agentTypes.ContentPart{
+			{Type: "text", Text: userText},
+		}
+		for _, imageInput := range req.ImageInputs {
+			parts = append(parts, agentTypes.ContentPart{
+				Type:     "image_url",
+				ImageURL: &agentTypes.ImageURL{URL: imageInput},
+			})
+		}
+		for _, fileInput := range req.FileInputs {
+			parts = append(parts, agentTypes.ContentPart{
+				Type: "text",
+				Text: fmt.Sprintf("----------\n%s\n----------\n%s", fileInput.Name, fileInput.Text),
+			})
+		}
+		userContent = parts
+	} else {
+		userContent = userText
+	}
+
+	session.Messages = append(session.Messages, agentTypes.Message{
+		Role:    "user",
+		Content: userContent,
+	})
+	if req.StoreHistory {
+		// * exec saves Histories plus the answer back to history.json
+		oldHistory, _ := sessionManager.GetHistory(req.SessionID)
+		session.Histories = append(oldHistory, agentTypes.Message{
+			Role:    "user",
+			Content: userText,
+		})
+	}
+	return session
+}
+
+// * StoredHistory returns the recent part of history.json, marking the cut for the agent
+func StoredHistory(sessionID string) []agentTypes.Message {
+	oldHistory, maxHistory := sessionManager.GetHistory(sessionID)
+	if len(oldHistory) > len(maxHistory) && len(maxHistory) > 0 {
+		copied := make([]agentTypes.Message, len(maxHistory))
+		copy(copied, maxHistory)
+		if text, ok := copied[0].Content.(string); ok {
+			copied[0].Content = "...\n" + text
+		}
+		maxHistory = copied
+	}
+	return maxHistory
+}
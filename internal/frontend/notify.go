@@ -0,0 +1,40 @@
+package frontend
+
+import (
+	"context"
+	"log/slog"
+
+	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
+)
+
+// * WrapScriptOutput rewrites raw script stdout into a chat message, raw output on failure
+func WrapScriptOutput(agent agentTypes.Agent, output string) string {
+	if agent == nil {
+		return output
+	}
+	messages := []agentTypes.Message{
+		{
+			Role:    "system",
+			Content: "你是一個訊息整理助理。收到腳本執行結果後，將其轉化為自然、簡潔、適合在聊天頻道傳送的訊息。若結果為空或無意義，回覆「任務已完成」。直接輸出訊息內容，不要加任何前綴或解釋。",
+		},
+		{
+			Role:    "user",
+			Content: output,
+		},
+	}
+	resp, err := agent.Send(context.Background(), messages, nil)
+	if err != nil || len(resp.Choices) == 0 {
+		slog.Warn("WrapScriptOutput: agent.Send failed, using raw output",
+			slog.String("error", func() string {
+				if err != nil {
+					return err.Error()
+				}
+				return "empty choices"
+			}()))
+		return output
+	}
+	if text, ok := resp.Choices[0].Message.Content.(string); ok && text != "" {
+		return text
+	}
+	return output
+}
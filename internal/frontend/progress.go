@@ -0,0 +1,173 @@
+package frontend
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/utils"
+)
+
+const (
+	// * chat platforms rate limit message edits, one edit per interval at most
+	progressInterval = 1500 * time.Millisecond
+	progressMaxLines = 15
+	progressLineMax  = 160
+)
+
+// * Progress keeps one status message updated while the agent works
+type Progress struct {
+	edit      func(messageID, content string) error
+	messageID string
+
+	mu     sync.Mutex
+	header string
+	lines  []string
+	tools  map[string]int // * tool ID to line index, for marking results
+	dirty  bool
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// * NewProgress posts the placeholder via post, returns a no-op progress when it cannot be sent
+func NewProgress(post func(content string) (string, error), edit func(messageID, content string) error) *Progress {
+	p := &Progress{
+		edit:   edit,
+		header: "⏳ Thinking…",
+		tools:  make(map[string]int),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	messageID, err := post(p.header)
+	if err != nil {
+		slog.Warn("failed to send placeholder",
+			slog.String("error", err.Error()))
+		close(p.done)
+		return p
+	}
+	p.messageID = messageID
+
+	go p.loop()
+	return p
+}
+
+func (p *Progress) loop() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.flush()
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+func (p *Progress) flush() {
+	p.mu.Lock()
+	if !p.dirty {
+		p.mu.Unlock()
+		return
+	}
+	p.dirty = false
+	content := p.render()
+	p.mu.Unlock()
+
+	if err := p.edit(p.messageID, content); err != nil {
+		slog.Warn("failed to update progress",
+			slog.String("error", err.Error()))
+	}
+}
+
+// * render must be called with mu held
+func (p *Progress) render() string {
+	lines := p.lines
+	var sb strings.Builder
+	sb.WriteString(p.header)
+	if skipped := len(lines) - progressMaxLines; skipped > 0 {
+		sb.WriteString(fmt.Sprintf("\n_… %d earlier steps_", skipped))
+		lines = lines[skipped:]
+	}
+	for _, line := range lines {
+		sb.WriteString("\n" + line)
+	}
+	return sb.String()
+}
+
+func (p *Progress) SetHeader(header string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.header = header
+	p.dirty = true
+}
+
+func (p *Progress) Add(line string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lines = append(p.lines, truncateLine(line))
+	p.dirty = true
+	return len(p.lines) - 1
+}
+
+func (p *Progress) ToolCall(toolID, toolName, toolArgs string) {
+	line := fmt.Sprintf("🔧 `%s` %s", toolName, compactArgs(toolArgs))
+	index := p.Add(line)
+	p.mu.Lock()
+	p.tools[toolID] = index
+	p.mu.Unlock()
+}
+
+// * ToolDone swaps the tool line icon once the result or skip arrives
+func (p *Progress) ToolDone(toolID, icon string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	index, ok := p.tools[toolID]
+	if !ok {
+		return
+	}
+	p.lines[index] = icon + strings.TrimPrefix(p.lines[index], "🔧")
+	p.dirty = true
+}
+
+// * Finish stops edits, returns the placeholder ID to be replaced by the answer
+func (p *Progress) Finish() string {
+	p.once.Do(func() {
+		if p.messageID != "" {
+			close(p.stop)
+		}
+		<-p.done
+	})
+	return p.messageID
+}
+
+func compactArgs(args string) string {
+	args = strings.Join(strings.Fields(args), " ")
+	if args == "" || args == "{}" {
+		return ""
+	}
+	return strings.ReplaceAll(args, "`", "'")
+}
+
+func truncateLine(line string) string {
+	if len(line) <= progressLineMax {
+		return line
+	}
+	return strings.TrimSuffix(utils.TruncateUTF8(line, progressLineMax), "\n...(truncated)") + "…"
+}
+
+// * IsStatusMessage reports bot messages that are progress, approval or refusal notices, not answers
+func IsStatusMessage(content string) bool {
+	for _, prefix := range []string{"⏳", "🔐", "⚠️", "🚫"} {
+		if strings.HasPrefix(content, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package frontend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pardnchiu/agenvoy/configs"
+	"github.com/pardnchiu/agenvoy/internal/access"
+	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
+	"github.com/pardnchiu/agenvoy/internal/filesystem/sessionManager"
+	"github.com/pardnchiu/agenvoy/internal/scheduler"
+)
+
+// * scheduledUserID counts usage of scheduled prompts apart from chat users
+const scheduledUserID = "scheduler"
+
+// * jobConversation has no one to ask, history lives in history.json of the job session
+type jobConversation struct {
+	sessionID string
+	answer    Answer
+}
+
+func (c *jobConversation) History(_ context.Context) []agentTypes.Message {
+	return StoredHistory(c.sessionID)
+}
+
+func (c *jobConversation) Approve(_ context.Context, _, _ string) bool {
+	return false
+}
+
+func (c *jobConversation) Reply(_ context.Context, answer Answer) error {
+	c.answer = answer
+	return nil
+}
+
+// * RunJob runs a scheduled prompt in its own session, tools are limited to read-only
+func RunJob(ctx context.Context, bot Bot, job scheduler.Job) (string, error) {
+	sessionID, err := sessionManager.GetSchedulerSession(job.ID, job.Target)
+	if err != nil {
+		return "", fmt.Errorf("sessionManager.GetSchedulerSession: %w", err)
+	}
+
+	conv := &jobConversation{sessionID: sessionID}
+	prog := NewProgress(func(string) (string, error) { return "", nil }, func(string, string) error { return nil })
+	defer prog.Finish()
+
+	if err := Run(ctx, bot, Request{
+		SessionID:    sessionID,
+		Frontend:     "scheduler",
+		ChannelID:    job.Target,
+		UserID:       scheduledUserID,
+		Content:      job.Prompt,
+		SystemPrompt: configs.ScheduledSystemPrompt,
+		Policy:       access.Policy{Tier: access.TierReadOnly},
+		StoreHistory: true,
+		Skill:        job.Skill,
+		Agent:        job.Agent,
+	}, prog, conv); err != nil {
+		return "", err
+	}
+
+	text := conv.answer.Text
+	if len(conv.answer.FilePaths) > 0 {
+		text += "\n\n" + strings.Join(conv.answer.FilePaths, "\n")
+	}
+	return text, nil
+}
@@ -0,0 +1,30 @@
+package frontend
+
+// * Split cuts s into chunks of at most size bytes, preferring line breaks
+func Split(s string, size int) []string {
+	if len(s) <= size {
+		return []string{s}
+	}
+	var chunks []string
+	for len(s) > size {
+		cut := size
+		if idx := lastNewline(s[:cut]); idx > 0 {
+			cut = idx + 1
+		}
+		chunks = append(chunks, s[:cut])
+		s = s[cut:]
+	}
+	if s != "" {
+		chunks = append(chunks, s)
+	}
+	return chunks
+}
+
+func lastNewline(s string) int {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == '\n' {
+			return i
+		}
+	}
+	return -1
+}
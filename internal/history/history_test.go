@@ -0,0 +1,126 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+)
+
+func TestSplitHeader(t *testing.T) {
+	want := time.Date(2026, 3, 1, 9, 30, 0, 0, time.Local)
+	cases := []string{
+		"---\n當前時間: 2026-03-01 09:30:00\n---\nhello",
+		"當前時間: 2026-03-01 09:30:00\n當前頻道 ID: C1\n---\nhello",
+	}
+	for _, content := range cases {
+		at, body := SplitHeader(content)
+		if !at.Equal(want) || body != "hello" {
+			t.Errorf("SplitHeader(%q) = %v, %q", content, at, body)
+		}
+	}
+	if at, body := SplitHeader("no header"); !at.IsZero() || body != "no header" {
+		t.Errorf("plain content changed: %v %q", at, body)
+	}
+}
+
+func writeJSON(t *testing.T, path, data string) {
+	t.Helper()
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	dir := t.TempDir()
+	filesystem.AgenvoyDir = dir
+	filesystem.SessionsDir = filepath.Join(dir, "sessions")
+	filesystem.HistoryIndexDir = filepath.Join(dir, "history_index")
+
+	writeJSON(t, filepath.Join(filesystem.SessionsDir, "a", "history.json"), `[
+		{"role":"user","content":"---\n當前時間: 2026-01-05 10:00:00\n---\n邱敬幃是誰"},
+		{"role":"assistant","content":"---\n當前時間: 2026-01-05 10:00:05\n---\n邱敬幃是一位工程師，維護 agenvoy。"}
+	]`)
+	writeJSON(t, filepath.Join(filesystem.SessionsDir, "b", "history.json"), `[
+		{"role":"user","content":"當前時間: 2026-02-10 08:00:00\n當前頻道 ID: C1\n---\nDeploy the staging server"}
+	]`)
+	writeJSON(t, filepath.Join(filesystem.SessionsDir, "b", "tool_calls", "2026-02-10", "2026-02-10-08-00-03.json"), `[
+		{"role":"assistant","tool_calls":[{"id":"c1","type":"function","function":{"name":"run_command","arguments":"{\"command\":\"make deploy STAGE=staging\"}"}}]},
+		{"role":"tool","tool_call_id":"c1","content":"[run_command] deploy finished on staging"}
+	]`)
+
+	hits, err := Search(Query{Text: "邱敬幃"})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 2 || hits[0].Doc.Session != "a" {
+		t.Fatalf("got %d hits", len(hits))
+	}
+	if !strings.Contains(hits[0].Snippet, "邱敬幃") {
+		t.Errorf("snippet not read back: %q", hits[0].Snippet)
+	}
+
+	// * the newest messages of the current session are left out, other sessions keep theirs
+	hits, _ = Search(Query{Text: "邱敬幃", Current: "a", SkipRecent: 1})
+	if len(hits) != 1 || hits[0].Doc.Role != "user" {
+		t.Errorf("skip recent: got %d hits", len(hits))
+	}
+	if hits, _ := Search(Query{Text: "邱敬幃", Current: "b", SkipRecent: 1}); len(hits) != 2 {
+		t.Errorf("skip recent of another session: got %d hits", len(hits))
+	}
+
+	// * each session has its own index, holding no message text
+	data, err := os.ReadFile(filepath.Join(filesystem.HistoryIndexDir, "a.json"))
+	if err != nil {
+		t.Fatalf("session index: %v", err)
+	}
+	if strings.Contains(string(data), "工程師") {
+		t.Errorf("index stores doc text")
+	}
+
+	hits, _ = Search(Query{Text: "staging", Session: "b"})
+	if len(hits) != 3 {
+		t.Fatalf("staging: got %d hits, want 3", len(hits))
+	}
+	for _, hit := range hits {
+		if hit.Doc.Source == SourceToolCall && hit.Doc.Tool != "run_command" {
+			t.Errorf("tool result not named: %+v", hit.Doc)
+		}
+	}
+
+	since, _ := ParseTime("2026-02-01", false)
+	if hits, _ := Search(Query{Text: "邱敬幃 staging", Since: since}); len(hits) != 3 {
+		t.Errorf("since filter: got %d hits, want 3", len(hits))
+	}
+	until, _ := ParseTime("2026-01-05", true)
+	if hits, _ := Search(Query{Text: "邱敬幃 staging", Until: until}); len(hits) != 2 {
+		t.Errorf("until filter: got %d hits, want 2", len(hits))
+	}
+
+	// * a rewritten file replaces its docs, a removed one drops them
+	writeJSON(t, filepath.Join(filesystem.SessionsDir, "a", "history.json"), `[
+		{"role":"user","content":"---\n當前時間: 2026-01-06 10:00:00\n---\nsomething else entirely"}
+	]`)
+	os.RemoveAll(filepath.Join(filesystem.SessionsDir, "b", "tool_calls"))
+	if hits, _ := Search(Query{Text: "邱敬幃"}); len(hits) != 0 {
+		t.Errorf("stale docs after rewrite: %d", len(hits))
+	}
+	if hits, _ := Search(Query{Text: "staging"}); len(hits) != 1 {
+		t.Errorf("stale docs after removal: %d", len(hits))
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	text := strings.Repeat("filler ", 30) + "the Needle\nis here " + strings.Repeat("tail ", 40)
+	got := snippet(text, []string{"needle"})
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "the Needle is here") {
+		t.Errorf("snippet = %q", got)
+	}
+	if got := snippet("short text", []string{"text"}); got != "short text" {
+		t.Errorf("short snippet = %q", got)
+	}
+}
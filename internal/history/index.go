@@ -0,0 +1,326 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+	"github.com/pardnchiu/agenvoy/internal/memory"
+)
+
+// * bump when Doc or the tokenizer changes, an older index is rebuilt from scratch
+const indexVersion = 2
+
+const (
+	SourceHistory  = "history"
+	SourceToolCall = "tool_calls"
+)
+
+// * Doc is one indexed message of a session history or tool call log, its text is read back from File when shown
+type Doc struct {
+	ID      int       `json:"id"`
+	Session string    `json:"session"`
+	Source  string    `json:"source"`
+	Role    string    `json:"role"`
+	Tool    string    `json:"tool,omitempty"`
+	Time    time.Time `json:"time"`
+	File    string    `json:"file"`
+	Pos     int       `json:"pos"`
+	Length  int       `json:"length"`
+	Text    string    `json:"-"`
+}
+
+type posting struct {
+	Doc int `json:"d"`
+	TF  int `json:"f"`
+}
+
+// * fileState remembers what a source file looked like when its docs were indexed
+type fileState struct {
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+	Docs    []int     `json:"docs"`
+}
+
+// * index covers one session, a search only reads and rewrites the sessions it touches
+type index struct {
+	Version     int                   `json:"version"`
+	Session     string                `json:"session"`
+	NextID      int                   `json:"next_id"`
+	TotalLength int                   `json:"total_length"`
+	Files       map[string]*fileState `json:"files"`
+	Docs        map[int]*Doc          `json:"docs"`
+	Postings    map[string][]posting  `json:"postings"`
+}
+
+func newIndex(session string) *index {
+	return &index{
+		Version:  indexVersion,
+		Session:  session,
+		Files:    map[string]*fileState{},
+		Docs:     map[int]*Doc{},
+		Postings: map[string][]posting{},
+	}
+}
+
+// * Rebuild drops the index and indexes every session again
+func Rebuild() (int, error) {
+	unlock, err := lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if err := os.RemoveAll(filesystem.HistoryIndexDir); err != nil {
+		return 0, fmt.Errorf("os.RemoveAll: %w", err)
+	}
+	count := 0
+	for _, session := range sessions() {
+		idx := newIndex(session)
+		idx.sync()
+		if err := idx.write(); err != nil {
+			return 0, err
+		}
+		count += len(idx.Docs)
+	}
+	return count, nil
+}
+
+// * open loads the index of session, or of every session when empty, up to date with the session files, caller holds the lock
+func open(session string) ([]*index, error) {
+	if session != "" {
+		if !isSession(session) {
+			return nil, nil
+		}
+		idx, err := openSession(session)
+		if err != nil {
+			return nil, err
+		}
+		return []*index{idx}, nil
+	}
+
+	list := sessions()
+	indexes := make([]*index, 0, len(list))
+	seen := map[string]bool{}
+	for _, name := range list {
+		idx, err := openSession(name)
+		if err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, idx)
+		seen[name] = true
+	}
+
+	// * indexes of removed sessions
+	paths, _ := filepath.Glob(filepath.Join(filesystem.HistoryIndexDir, "*.json"))
+	for _, path := range paths {
+		if !seen[strings.TrimSuffix(filepath.Base(path), ".json")] {
+			os.Remove(path)
+		}
+	}
+	return indexes, nil
+}
+
+func openSession(session string) (*index, error) {
+	idx, err := read(session)
+	if err != nil {
+		return nil, err
+	}
+	if idx.sync() {
+		if err := idx.write(); err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+func sessions() []string {
+	entries, err := os.ReadDir(filesystem.SessionsDir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+func isSession(session string) bool {
+	if !filepath.IsLocal(session) || strings.ContainsRune(session, filepath.Separator) {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(filesystem.SessionsDir, session))
+	return err == nil && info.IsDir()
+}
+
+// * sync reindexes source files whose size or mtime changed and drops removed ones
+func (idx *index) sync() bool {
+	changed := false
+	seen := map[string]bool{}
+	for _, path := range sourceFiles(idx.Session) {
+		rel, err := filepath.Rel(filesystem.SessionsDir, path)
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		seen[rel] = true
+		if state, ok := idx.Files[rel]; ok && state.Size == info.Size() && state.ModTime.Equal(info.ModTime()) {
+			continue
+		}
+
+		docs, err := parseFile(rel, path, info.ModTime())
+		if err != nil {
+			slog.Warn("parseFile",
+				slog.String("path", path),
+				slog.String("error", err.Error()))
+		}
+		idx.removeFile(rel)
+		state := &fileState{
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+		}
+		for _, doc := range docs {
+			state.Docs = append(state.Docs, idx.add(doc))
+		}
+		idx.Files[rel] = state
+		changed = true
+	}
+
+	for rel := range idx.Files {
+		if !seen[rel] {
+			idx.removeFile(rel)
+			changed = true
+		}
+	}
+	return changed
+}
+
+func sourceFiles(session string) []string {
+	dir := filepath.Join(filesystem.SessionsDir, session)
+	histories, _ := filepath.Glob(filepath.Join(dir, "history.json"))
+	toolCalls, _ := filepath.Glob(filepath.Join(dir, "tool_calls", "*", "*.json"))
+	return append(histories, toolCalls...)
+}
+
+func (idx *index) add(doc *Doc) int {
+	doc.ID = idx.NextID
+	idx.NextID++
+
+	tf := map[string]int{}
+	for _, token := range memory.Tokenize(doc.Text + " " + doc.Tool) {
+		tf[token]++
+		doc.Length++
+	}
+	for term, freq := range tf {
+		idx.Postings[term] = append(idx.Postings[term], posting{Doc: doc.ID, TF: freq})
+	}
+	idx.Docs[doc.ID] = doc
+	idx.TotalLength += doc.Length
+	return doc.ID
+}
+
+// * text is not stored, so postings are swept for the removed docs, bounded by the one session
+func (idx *index) removeFile(rel string) {
+	state, ok := idx.Files[rel]
+	if !ok {
+		return
+	}
+	removed := map[int]bool{}
+	for _, id := range state.Docs {
+		doc, ok := idx.Docs[id]
+		if !ok {
+			continue
+		}
+		removed[id] = true
+		idx.TotalLength -= doc.Length
+		delete(idx.Docs, id)
+	}
+	delete(idx.Files, rel)
+	if len(removed) == 0 {
+		return
+	}
+
+	for term, list := range idx.Postings {
+		kept := list[:0]
+		for _, p := range list {
+			if !removed[p.Doc] {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) == 0 {
+			delete(idx.Postings, term)
+		} else {
+			idx.Postings[term] = kept
+		}
+	}
+}
+
+func lock() (func(), error) {
+	if err := os.MkdirAll(filesystem.HistoryIndexDir, 0755); err != nil {
+		return nil, fmt.Errorf("os.MkdirAll: %w", err)
+	}
+	file, err := os.OpenFile(filesystem.HistoryIndexDir+".lock", os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("os.OpenFile: %w", err)
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("syscall.Flock: %w", err)
+	}
+	return func() {
+		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
+
+func indexPath(session string) string {
+	return filepath.Join(filesystem.HistoryIndexDir, session+".json")
+}
+
+// * read returns an empty index when none exists or it was written by another version
+func read(session string) (*index, error) {
+	data, err := os.ReadFile(indexPath(session))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return newIndex(session), nil
+		}
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+	var idx index
+	if err := json.Unmarshal(data, &idx); err != nil || idx.Version != indexVersion || idx.Session != session {
+		return newIndex(session), nil
+	}
+	if idx.Files == nil {
+		idx.Files = map[string]*fileState{}
+	}
+	if idx.Docs == nil {
+		idx.Docs = map[int]*Doc{}
+	}
+	if idx.Postings == nil {
+		idx.Postings = map[string][]posting{}
+	}
+	return &idx, nil
+}
+
+func (idx *index) write() error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+	if err := filesystem.WriteFile(indexPath(idx.Session), string(data), 0600); err != nil {
+		return fmt.Errorf("filesystem.WriteFile: %w", err)
+	}
+	return nil
+}
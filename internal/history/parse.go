@@ -0,0 +1,183 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
+)
+
+const (
+	headerTimeLayout   = "2006-01-02 15:04:05"
+	toolCallFileLayout = "2006-01-02-15-04-05"
+	// * tool results can be whole web pages, the head is enough to find them again
+	maxDocText = 16 * 1024
+)
+
+// * tool results are logged as "[tool] result"
+var toolResultRegex = regexp.MustCompile(`^\[([\w.:-]+)\] `)
+
+// * CLI writes "---\n當前時間: ...\n---\n", frontends "當前時間: ...\n當前頻道 ID: ...\n---\n"
+var headerRegex = regexp.MustCompile(`\A(?:-{3,}\n)?當前時間: (\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})\n(?:[^\n]*\n)*?-{3,}\n`)
+
+// * SplitHeader returns the time of the message header and the content without it
+func SplitHeader(content string) (time.Time, string) {
+	match := headerRegex.FindStringSubmatch(content)
+	if match == nil {
+		return time.Time{}, content
+	}
+	t, err := time.ParseInLocation(headerTimeLayout, match[1], time.Local)
+	if err != nil {
+		return time.Time{}, content
+	}
+	return t, content[len(match[0]):]
+}
+
+// * parseFile turns one history.json or tool_calls/<date>/<time>.json into docs, Pos is the order within the file
+func parseFile(rel, path string, modTime time.Time) ([]*Doc, error) {
+	docs, err := parseDocs(rel, path, modTime)
+	for i, doc := range docs {
+		doc.File = rel
+		doc.Pos = i
+	}
+	return docs, err
+}
+
+func parseDocs(rel, path string, modTime time.Time) ([]*Doc, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+	var messages []agentTypes.Message
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	session := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+	if filepath.Base(path) == "history.json" {
+		return historyDocs(session, messages, modTime), nil
+	}
+
+	at := modTime
+	name := strings.TrimSuffix(filepath.Base(path), ".json")
+	if t, err := time.ParseInLocation(toolCallFileLayout, name, time.Local); err == nil {
+		at = t
+	}
+	return toolCallDocs(session, messages, at), nil
+}
+
+// * historyDocs dates each message by its header, a message without one takes the time of the one before
+func historyDocs(session string, messages []agentTypes.Message, modTime time.Time) []*Doc {
+	var docs []*Doc
+	var last time.Time
+	for _, message := range messages {
+		if message.Role == "system" {
+			continue
+		}
+		at, text := SplitHeader(MessageText(message.Content))
+		if at.IsZero() {
+			at = last
+		} else {
+			last = at
+		}
+		if at.IsZero() {
+			at = modTime
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		docs = append(docs, &Doc{
+			Session: session,
+			Source:  SourceHistory,
+			Role:    message.Role,
+			Time:    at,
+			Text:    clip(text),
+		})
+	}
+	return docs
+}
+
+// * toolCallDocs indexes every call with its arguments and every result under the tool name
+func toolCallDocs(session string, messages []agentTypes.Message, at time.Time) []*Doc {
+	names := map[string]string{}
+	var docs []*Doc
+	for _, message := range messages {
+		for _, call := range message.ToolCalls {
+			names[call.ID] = call.Function.Name
+			docs = append(docs, &Doc{
+				Session: session,
+				Source:  SourceToolCall,
+				Role:    "tool_call",
+				Tool:    call.Function.Name,
+				Time:    at,
+				Text:    clip(call.Function.Arguments),
+			})
+		}
+		if message.Role != "tool" {
+			continue
+		}
+		name, text := SplitToolResult(MessageText(message.Content))
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if known, ok := names[message.ToolCallID]; ok {
+			name = known
+		}
+		docs = append(docs, &Doc{
+			Session: session,
+			Source:  SourceToolCall,
+			Role:    "tool",
+			Tool:    name,
+			Time:    at,
+			Text:    clip(text),
+		})
+	}
+	return docs
+}
+
+// * SplitToolResult returns the tool name of a logged "[tool] result" and the result without it
+func SplitToolResult(content string) (string, string) {
+	match := toolResultRegex.FindStringSubmatch(content)
+	if match == nil {
+		return "", content
+	}
+	return match[1], content[len(match[0]):]
+}
+
+// * MessageText flattens string content or the text parts of multimodal content
+func MessageText(content any) string {
+	switch value := content.(type) {
+	case string:
+		return value
+	case []any:
+		var parts []string
+		for _, item := range value {
+			part, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			if text, ok := part["text"].(string); ok {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+func clip(text string) string {
+	if len(text) <= maxDocText {
+		return text
+	}
+	cut := maxDocText
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut]
+}
@@ -0,0 +1,256 @@
+package history
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+	"github.com/pardnchiu/agenvoy/internal/memory"
+)
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// * runes of context kept before the first match
+	snippetLead  = 40
+	snippetWidth = 160
+)
+
+// * Ranges are the time_range shorthands, each counted back from now
+var Ranges = map[string]time.Duration{
+	"1d": 24 * time.Hour,
+	"7d": 7 * 24 * time.Hour,
+	"1m": 30 * 24 * time.Hour,
+	"1y": 365 * 24 * time.Hour,
+}
+
+// * Query selects docs by terms, an empty Session searches every session and zero times leave that side open
+type Query struct {
+	Text    string
+	Session string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+	// * the newest SkipRecent messages of the Current session are already in the conversation
+	Current    string
+	SkipRecent int
+}
+
+type Hit struct {
+	Doc     *Doc
+	Score   float64
+	Snippet string
+}
+
+// * Search brings the index up to date and ranks matching docs by BM25, the newest first on ties
+func Search(q Query) ([]Hit, error) {
+	terms := slices.Compact(slices.Sorted(slices.Values(memory.Tokenize(q.Text))))
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("query has no searchable terms")
+	}
+
+	unlock, err := lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	indexes, err := open(q.Session)
+	if err != nil {
+		return nil, err
+	}
+
+	// * BM25 statistics span every loaded session so scores compare across them
+	var n, totalLength float64
+	df := map[string]float64{}
+	for _, idx := range indexes {
+		n += float64(len(idx.Docs))
+		totalLength += float64(idx.TotalLength)
+		for _, term := range terms {
+			df[term] += float64(len(idx.Postings[term]))
+		}
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	avgLen := totalLength / n
+
+	var hits []Hit
+	for _, idx := range indexes {
+		recent := idx.recentFrom(q)
+		scores := map[int]float64{}
+		for _, term := range terms {
+			if df[term] == 0 {
+				continue
+			}
+			idf := math.Log(1 + (n-df[term]+0.5)/(df[term]+0.5))
+			for _, p := range idx.Postings[term] {
+				doc := idx.Docs[p.Doc]
+				if doc == nil || !q.match(doc) || (doc.File == recent.file && doc.Pos >= recent.pos) {
+					continue
+				}
+				freq := float64(p.TF)
+				scores[p.Doc] += idf * freq * (bm25K1 + 1) / (freq + bm25K1*(1-bm25B+bm25B*float64(doc.Length)/avgLen))
+			}
+		}
+		for id, score := range scores {
+			hits = append(hits, Hit{Doc: idx.Docs[id], Score: score})
+		}
+	}
+	slices.SortFunc(hits, func(a, b Hit) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return b.Doc.Time.Compare(a.Doc.Time)
+	})
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	loadText(hits)
+	for i := range hits {
+		hits[i].Snippet = snippet(hits[i].Doc.Text, terms)
+	}
+	return hits, nil
+}
+
+// * loadText reads the text of the returned docs back from their source files, each file parsed once
+func loadText(hits []Hit) {
+	parsed := map[string][]*Doc{}
+	for _, hit := range hits {
+		docs, ok := parsed[hit.Doc.File]
+		if !ok {
+			path := filepath.Join(filesystem.SessionsDir, hit.Doc.File)
+			var modTime time.Time
+			if info, err := os.Stat(path); err == nil {
+				modTime = info.ModTime()
+			}
+			var err error
+			docs, err = parseFile(hit.Doc.File, path, modTime)
+			if err != nil {
+				slog.Warn("parseFile",
+					slog.String("path", path),
+					slog.String("error", err.Error()))
+			}
+			parsed[hit.Doc.File] = docs
+		}
+		if hit.Doc.Pos < len(docs) {
+			hit.Doc.Text = docs[hit.Doc.Pos].Text
+		}
+	}
+}
+
+type recentCut struct {
+	file string
+	pos  int
+}
+
+// * recentFrom marks where the skipped tail of the current session's history starts, an empty file skips nothing
+func (idx *index) recentFrom(q Query) recentCut {
+	if q.SkipRecent <= 0 || q.Current == "" || idx.Session != q.Current {
+		return recentCut{}
+	}
+	rel := filepath.Join(idx.Session, "history.json")
+	state, ok := idx.Files[rel]
+	if !ok {
+		return recentCut{}
+	}
+	return recentCut{file: rel, pos: len(state.Docs) - q.SkipRecent}
+}
+
+func (q Query) match(doc *Doc) bool {
+	if q.Session != "" && doc.Session != q.Session {
+		return false
+	}
+	if !q.Since.IsZero() && doc.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && doc.Time.After(q.Until) {
+		return false
+	}
+	return true
+}
+
+// * snippet cuts a single-line window of text around the earliest matching term
+func snippet(text string, terms []string) string {
+	runes := []rune(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return ' '
+		}
+		return r
+	}, text))
+	// * ToLower may change byte lengths, match rune by rune instead
+	lower := []rune(strings.ToLower(string(runes)))
+	if len(lower) != len(runes) {
+		lower = runes
+	}
+
+	first := -1
+	for _, term := range terms {
+		if pos := runeIndex(lower, []rune(term)); pos >= 0 && (first < 0 || pos < first) {
+			first = pos
+		}
+	}
+	start := 0
+	if first > snippetLead {
+		start = first - snippetLead
+	}
+	end := min(start+snippetWidth, len(runes))
+
+	result := strings.Join(strings.Fields(string(runes[start:end])), " ")
+	if start > 0 {
+		result = "…" + result
+	}
+	if end < len(runes) {
+		result += "…"
+	}
+	return result
+}
+
+func runeIndex(text, sub []rune) int {
+	for i := 0; i+len(sub) <= len(text); i++ {
+		if slices.Equal(text[i:i+len(sub)], sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// * String renders a hit as one line: time, session, role or tool, snippet
+func (h Hit) String() string {
+	who := h.Doc.Role
+	if h.Doc.Tool != "" {
+		who += ":" + h.Doc.Tool
+	}
+	return fmt.Sprintf("[%s] %s %s: %s", h.Doc.Time.Format("2006-01-02 15:04"), h.Doc.Session, who, h.Snippet)
+}
+
+// * ParseTime reads a date or datetime in local time, the end of the day when endOfDay is set for a bare date
+func ParseTime(value string, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{headerTimeLayout, "2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, want YYYY-MM-DD[ HH:MM[:SS]] or RFC3339", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
@@ -18,6 +18,11 @@ func Get(key string) string {
 	return os.Getenv(key)
 }
 
+// * Stored reads the keychain or its fallback file only, never the process environment
+func Stored(key string) string {
+	return readKeychain(key)
+}
+
 func Set(key, value string) error {
 	if value == "" {
 		return nil
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/keychain"
+	"github.com/pardnchiu/agenvoy/internal/utils"
+)
+
+const (
+	defaultEmbeddingURL = "https://api.openai.com/v1/embeddings"
+	embeddingTimeout    = 10 * time.Second
+)
+
+var errEmbeddingOff = errors.New("MEMORY_EMBEDDING_MODEL is not set")
+
+// * embed calls an OpenAI compatible embeddings endpoint, off unless MEMORY_EMBEDDING_MODEL is set
+func embed(ctx context.Context, text string) ([]float32, string, error) {
+	model := os.Getenv("MEMORY_EMBEDDING_MODEL")
+	if model == "" {
+		return nil, "", errEmbeddingOff
+	}
+	url := os.Getenv("MEMORY_EMBEDDING_URL")
+	if url == "" {
+		url = defaultEmbeddingURL
+	}
+	keyName := os.Getenv("MEMORY_EMBEDDING_KEY")
+	if keyName == "" {
+		keyName = "OPENAI_API_KEY"
+	}
+	header := map[string]string{}
+	// * local servers such as Ollama need no key
+	if key := keychain.Get(keyName); key != "" {
+		header["Authorization"] = "Bearer " + key
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, embeddingTimeout)
+	defer cancel()
+
+	type response struct {
+		Data []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	result, _, err := utils.POST[response](ctx, &http.Client{}, url, header, map[string]any{
+		"model": model,
+		"input": text,
+	}, "json")
+	if err != nil {
+		return nil, "", fmt.Errorf("utils.POST: %w", err)
+	}
+	if len(result.Data) == 0 || len(result.Data[0].Embedding) == 0 {
+		return nil, "", fmt.Errorf("empty embedding")
+	}
+	return result.Data[0].Embedding, model, nil
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,259 @@
+package memory
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+)
+
+// * Memory is one long-term fact shared by every session of its principal
+type Memory struct {
+	ID        string    `json:"id"`
+	Text      string    `json:"text"`
+	Tags      []string  `json:"tags,omitempty"`
+	Source    string    `json:"source,omitempty"`    // * session that stored it
+	Principal string    `json:"principal,omitempty"` // * "frontend:user" that stored it, empty for the CLI
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// * set when MEMORY_EMBEDDING_MODEL is configured, dropped when the model changes
+	Embedding      []float32 `json:"embedding,omitempty"`
+	EmbeddingModel string    `json:"embedding_model,omitempty"`
+}
+
+// * Remember stores text for principal, the same text stored again by them only merges tags and refreshes UpdatedAt
+func Remember(ctx context.Context, text string, tags []string, source, principal string) (*Memory, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("text is required")
+	}
+	tags = normalizeTags(tags)
+	// * outside the lock, a slow embedding endpoint must not block other sessions
+	vector, model, embedErr := embed(ctx, text)
+
+	unlock, err := lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	memories, err := read()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, m := range memories {
+		if m.Principal == principal && strings.EqualFold(m.Text, text) {
+			m.Tags = normalizeTags(append(m.Tags, tags...))
+			m.UpdatedAt = now
+			if err := write(memories); err != nil {
+				return nil, err
+			}
+			return m, nil
+		}
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	m := &Memory{
+		ID:        id,
+		Text:      text,
+		Tags:      tags,
+		Source:    source,
+		Principal: principal,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	// * an embedding failure keeps the memory, it is still found by keywords
+	if embedErr == nil {
+		m.Embedding = vector
+		m.EmbeddingModel = model
+	}
+	memories = append(memories, m)
+	if err := write(memories); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// * Forget removes a memory by ID, chat principals only their own
+func Forget(id, principal string) error {
+	unlock, err := lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	memories, err := read()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(memories, func(m *Memory) bool {
+		return m.ID == id && m.Visible(principal)
+	})
+	if i < 0 {
+		return fmt.Errorf("not exist: %s", id)
+	}
+	return write(slices.Delete(memories, i, i+1))
+}
+
+// * Replace writes memories as the whole store, nil clears it
+func Replace(memories []*Memory) error {
+	unlock, err := lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return write(memories)
+}
+
+// * Merge adds memories whose ID and text are both new, returns how many were added
+func Merge(memories []*Memory) (int, error) {
+	unlock, err := lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	existing, err := read()
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, m := range memories {
+		if slices.ContainsFunc(existing, func(e *Memory) bool {
+			return e.ID == m.ID || strings.EqualFold(e.Text, m.Text)
+		}) {
+			continue
+		}
+		existing = append(existing, m)
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	return added, write(existing)
+}
+
+// * Validate checks memories edited or imported by hand
+func Validate(memories []*Memory) error {
+	var errs []error
+	seen := map[string]bool{}
+	for i, m := range memories {
+		switch {
+		case m == nil:
+			errs = append(errs, fmt.Errorf("[%d]: must be an object", i))
+			continue
+		case m.ID == "":
+			errs = append(errs, fmt.Errorf("[%d].id: required", i))
+		case seen[m.ID]:
+			errs = append(errs, fmt.Errorf("[%d].id: duplicate %q", i, m.ID))
+		}
+		seen[m.ID] = true
+		if strings.TrimSpace(m.Text) == "" {
+			errs = append(errs, fmt.Errorf("[%d].text: required", i))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// * List returns every memory, oldest first
+func List() ([]*Memory, error) {
+	unlock, err := lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return read()
+}
+
+// * Visible reports whether principal may read m, the CLI sees every memory and chat users only their own
+func (m *Memory) Visible(principal string) bool {
+	return principal == "" || m.Principal == principal
+}
+
+func (m *Memory) String() string {
+	var sb strings.Builder
+	sb.WriteString("[" + m.ID + "] " + m.Text)
+	if len(m.Tags) > 0 {
+		sb.WriteString("  #" + strings.Join(m.Tags, " #"))
+	}
+	sb.WriteString("  (" + m.UpdatedAt.Local().Format("2006-01-02") + ")")
+	return sb.String()
+}
+
+func normalizeTags(tags []string) []string {
+	var result []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag != "" && !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+func newID() (string, error) {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("rand.Read: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// * lock serializes the CLI and the servers on the store file
+func lock() (func(), error) {
+	if err := os.MkdirAll(filesystem.AgenvoyDir, 0755); err != nil {
+		return nil, fmt.Errorf("os.MkdirAll: %w", err)
+	}
+	file, err := os.OpenFile(filesystem.MemoryPath+".lock", os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("os.OpenFile: %w", err)
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("syscall.Flock: %w", err)
+	}
+	return func() {
+		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
+
+func read() ([]*Memory, error) {
+	data, err := os.ReadFile(filesystem.MemoryPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+	var memories []*Memory
+	if err := json.Unmarshal(data, &memories); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	return memories, nil
+}
+
+func write(memories []*Memory) error {
+	data, err := json.MarshalIndent(memories, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+	if err := filesystem.WriteFile(filesystem.MemoryPath, string(data), 0600); err != nil {
+		return fmt.Errorf("filesystem.WriteFile: %w", err)
+	}
+	return nil
+}
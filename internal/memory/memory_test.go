@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+)
+
+func useTempStore(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	filesystem.AgenvoyDir = dir
+	filesystem.MemoryPath = filepath.Join(dir, "memory.json")
+	t.Setenv("MEMORY_EMBEDDING_MODEL", "")
+}
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Go 1.25 偏好繁體中文, API-key")
+	want := []string{"go", "1", "25", "偏好", "好繁", "繁體", "體中", "中文", "api", "key"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := Tokenize("是"); !slices.Equal(got, []string{"是"}) {
+		t.Errorf("single CJK rune: got %v", got)
+	}
+}
+
+func TestRememberRecallForget(t *testing.T) {
+	useTempStore(t)
+	ctx := context.Background()
+
+	first, err := Remember(ctx, "使用者偏好以繁體中文回覆", []string{"Preference"}, "s1", "")
+	if err != nil {
+		t.Fatalf("Remember: %v", err)
+	}
+	Remember(ctx, "專案使用 Go 1.25 與 gofmt", []string{"project"}, "s1", "")
+	Remember(ctx, "使用者住在台北", nil, "s2", "")
+
+	// * same text merges tags instead of adding a duplicate
+	again, err := Remember(ctx, "使用者偏好以繁體中文回覆", []string{"#language"}, "s2", "")
+	if err != nil {
+		t.Fatalf("Remember again: %v", err)
+	}
+	if again.ID != first.ID || !slices.Equal(again.Tags, []string{"preference", "language"}) {
+		t.Errorf("dedup: got %s %v", again.ID, again.Tags)
+	}
+	if all, _ := List(); len(all) != 3 {
+		t.Fatalf("got %d memories, want 3", len(all))
+	}
+
+	results, err := Recall(ctx, "回覆要用什麼中文", nil, 5, "")
+	if err != nil {
+		t.Fatalf("Recall: %v", err)
+	}
+	if len(results) == 0 || results[0].Memory.ID != first.ID {
+		t.Errorf("recall ranking: %v", results)
+	}
+	for _, r := range results {
+		if r.Memory.Text == "專案使用 Go 1.25 與 gofmt" {
+			t.Errorf("unrelated memory matched")
+		}
+	}
+
+	if results, _ := Recall(ctx, "", []string{"project"}, 5, ""); len(results) != 1 {
+		t.Errorf("tag filter: got %d", len(results))
+	}
+
+	if err := Forget(first.ID, ""); err != nil {
+		t.Fatalf("Forget: %v", err)
+	}
+	if err := Forget(first.ID, ""); err == nil {
+		t.Errorf("forget twice: expected error")
+	}
+	if results, _ := Recall(ctx, "繁體中文", nil, 5, ""); len(results) != 0 {
+		t.Errorf("forgotten memory still recalled")
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	useTempStore(t)
+	if got := Prompt(context.Background(), "anything", ""); got != "" {
+		t.Errorf("empty store: got %q", got)
+	}
+	m, _ := Remember(context.Background(), "deploy target is fly.io", nil, "", "")
+	if got := Prompt(context.Background(), "where do we deploy", ""); !strings.Contains(got, m.ID) {
+		t.Errorf("prompt missing memory: %q", got)
+	}
+}
+
+func TestPrincipal(t *testing.T) {
+	useTempStore(t)
+	ctx := context.Background()
+	alice, _ := Remember(ctx, "alice deploys to fly.io", nil, "s1", "discord:alice")
+	Remember(ctx, "bob deploys to render", nil, "s2", "slack:bob")
+
+	results, _ := Recall(ctx, "deploys", nil, 5, "discord:alice")
+	if len(results) != 1 || results[0].Memory.ID != alice.ID {
+		t.Errorf("alice sees %v", results)
+	}
+	if results, _ := Recall(ctx, "deploys", nil, 5, ""); len(results) != 2 {
+		t.Errorf("CLI sees %d, want 2", len(results))
+	}
+	if got := Prompt(ctx, "where does bob deploy", "discord:alice"); strings.Contains(got, "render") {
+		t.Errorf("prompt leaks another principal: %q", got)
+	}
+	if err := Forget(alice.ID, "slack:bob"); err == nil {
+		t.Errorf("forgot another principal's memory")
+	}
+	if err := Forget(alice.ID, "discord:alice"); err != nil {
+		t.Errorf("Forget own: %v", err)
+	}
+}
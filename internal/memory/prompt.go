@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/pardnchiu/agenvoy/configs"
+)
+
+// * injectLimit caps the memories added to every turn
+const injectLimit = 5
+
+// * Prompt returns the system message with the memories of principal relevant to input, empty when none match
+func Prompt(ctx context.Context, input, principal string) string {
+	if strings.TrimSpace(input) == "" {
+		return ""
+	}
+	results, err := Recall(ctx, input, nil, injectLimit, principal)
+	if err != nil {
+		slog.Warn("memory.Recall",
+			slog.String("error", err.Error()))
+		return ""
+	}
+	if len(results) == 0 {
+		return ""
+	}
+
+	lines := make([]string, len(results))
+	for i, r := range results {
+		lines[i] = "- " + r.Memory.String()
+	}
+	return strings.NewReplacer(
+		"{{.Memories}}", strings.Join(lines, "\n"),
+	).Replace(strings.TrimSpace(configs.MemoryPrompt))
+}
@@ -0,0 +1,201 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// * reciprocal rank fusion constant, keeps a single top rank from dominating
+	rrfK = 60
+	// * embedding matches below this are noise
+	minSimilarity = 0.3
+)
+
+type Result struct {
+	Memory *Memory
+	Score  float64
+}
+
+// * Recall ranks the memories principal may see carrying every tag by BM25 over text and tags,
+// * fused with embedding similarity when configured, the newest first for an empty query
+func Recall(ctx context.Context, query string, tags []string, limit int, principal string) ([]Result, error) {
+	memories, err := List()
+	if err != nil {
+		return nil, err
+	}
+	tags = normalizeTags(tags)
+	memories = slices.DeleteFunc(memories, func(m *Memory) bool {
+		if !m.Visible(principal) {
+			return true
+		}
+		for _, tag := range tags {
+			if !slices.Contains(m.Tags, tag) {
+				return true
+			}
+		}
+		return false
+	})
+
+	var results []Result
+	if strings.TrimSpace(query) == "" {
+		for _, m := range memories {
+			results = append(results, Result{Memory: m})
+		}
+		slices.SortFunc(results, func(a, b Result) int {
+			return b.Memory.UpdatedAt.Compare(a.Memory.UpdatedAt)
+		})
+	} else {
+		results = rank(ctx, query, memories)
+	}
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func rank(ctx context.Context, query string, memories []*Memory) []Result {
+	fused := map[*Memory]float64{}
+
+	keyword := bm25(query, memories)
+	for i, r := range keyword {
+		fused[r.Memory] += 1.0 / float64(rrfK+i+1)
+	}
+
+	vector, model, err := embed(ctx, query)
+	switch {
+	case err == nil:
+		var semantic []Result
+		for _, m := range memories {
+			if m.EmbeddingModel != model {
+				continue
+			}
+			if similarity := cosine(vector, m.Embedding); similarity >= minSimilarity {
+				semantic = append(semantic, Result{Memory: m, Score: similarity})
+			}
+		}
+		sortResults(semantic)
+		for i, r := range semantic {
+			fused[r.Memory] += 1.0 / float64(rrfK+i+1)
+		}
+	case !errors.Is(err, errEmbeddingOff):
+		slog.Warn("embed",
+			slog.String("error", err.Error()))
+	}
+
+	results := make([]Result, 0, len(fused))
+	for m, score := range fused {
+		results = append(results, Result{Memory: m, Score: score})
+	}
+	sortResults(results)
+	return results
+}
+
+// * bm25 returns the memories sharing at least one term with query, best first
+func bm25(query string, memories []*Memory) []Result {
+	terms := Tokenize(query)
+	if len(terms) == 0 || len(memories) == 0 {
+		return nil
+	}
+
+	docs := make([][]string, len(memories))
+	df := map[string]int{}
+	total := 0
+	for i, m := range memories {
+		docs[i] = Tokenize(m.Text + " " + strings.Join(m.Tags, " "))
+		total += len(docs[i])
+		seen := map[string]bool{}
+		for _, token := range docs[i] {
+			if !seen[token] {
+				seen[token] = true
+				df[token]++
+			}
+		}
+	}
+	avgLen := float64(total) / float64(len(docs))
+	n := float64(len(docs))
+
+	var results []Result
+	for i, doc := range docs {
+		tf := map[string]int{}
+		for _, token := range doc {
+			tf[token]++
+		}
+		score := 0.0
+		for _, term := range slices.Compact(slices.Sorted(slices.Values(terms))) {
+			freq := float64(tf[term])
+			if freq == 0 {
+				continue
+			}
+			idf := math.Log(1 + (n-float64(df[term])+0.5)/(float64(df[term])+0.5))
+			score += idf * freq * (bm25K1 + 1) / (freq + bm25K1*(1-bm25B+bm25B*float64(len(doc))/avgLen))
+		}
+		if score > 0 {
+			results = append(results, Result{Memory: memories[i], Score: score})
+		}
+	}
+	sortResults(results)
+	return results
+}
+
+func sortResults(results []Result) {
+	slices.SortStableFunc(results, func(a, b Result) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return b.Memory.UpdatedAt.Compare(a.Memory.UpdatedAt)
+	})
+}
+
+// * Tokenize lowercases latin words and splits CJK runs into bigrams, there are no spaces to split on
+func Tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 1:
+			tokens = append(tokens, string(cjk))
+		case len(cjk) > 1:
+			for i := 0; i+1 < len(cjk); i++ {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
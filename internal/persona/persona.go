@@ -0,0 +1,116 @@
+package persona
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+	"github.com/pardnchiu/agenvoy/internal/filesystem/sessionManager"
+)
+
+// * key in session config.json holding the assigned persona name
+const sessionKey = "persona"
+
+var nameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// * one persona per ~/.config/agenvoy/personas/{name}.json
+type Persona struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	SystemPrompt string   `json:"system_prompt"`
+	Agent        string   `json:"agent"`  // * preferred AgentRegistry entry, skip agent selection when set
+	Skills       []string `json:"skills"` // * allowed skills, empty allows all
+}
+
+func Get(name string) (*Persona, error) {
+	name = strings.TrimSpace(name)
+	if !nameRegex.MatchString(name) {
+		return nil, fmt.Errorf("invalid persona name: %s", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(filesystem.PersonasDir, name+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("persona not found: %s", name)
+		}
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	var p Persona
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	// * file name wins over the name field
+	p.Name = name
+	return &p, nil
+}
+
+func List() []*Persona {
+	entries, err := os.ReadDir(filesystem.PersonasDir)
+	if err != nil {
+		return nil
+	}
+
+	var list []*Persona
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		p, err := Get(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			slog.Warn("failed to load persona",
+				slog.String("file", entry.Name()),
+				slog.String("error", err.Error()))
+			continue
+		}
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+func Assign(sessionID, name string) (*Persona, error) {
+	p, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := sessionManager.SetConfig(sessionID, sessionKey, p.Name); err != nil {
+		return nil, fmt.Errorf("sessionManager.SetConfig: %w", err)
+	}
+	return p, nil
+}
+
+func Clear(sessionID string) error {
+	if err := sessionManager.SetConfig(sessionID, sessionKey, ""); err != nil {
+		return fmt.Errorf("sessionManager.SetConfig: %w", err)
+	}
+	return nil
+}
+
+// * ForSession returns the persona assigned to session, nil when none or removed
+func ForSession(sessionID string) *Persona {
+	config, err := sessionManager.GetConfig(sessionID)
+	if err != nil || config[sessionKey] == "" {
+		return nil
+	}
+	p, err := Get(config[sessionKey])
+	if err != nil {
+		slog.Warn("assigned persona unavailable",
+			slog.String("session", sessionID),
+			slog.String("error", err.Error()))
+		return nil
+	}
+	return p
+}
+
+func (p *Persona) Prompt() string {
+	return fmt.Sprintf("以下為此對話指定的角色設定（%s），請以此角色回應：\n%s", p.Name, strings.TrimSpace(p.SystemPrompt))
+}
@@ -0,0 +1,289 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/agents/exec"
+	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+	"github.com/pardnchiu/agenvoy/internal/filesystem/sessionManager"
+	"github.com/pardnchiu/agenvoy/internal/skill"
+	"github.com/pardnchiu/agenvoy/internal/transcript"
+)
+
+const (
+	ModeRecorded = "recorded"
+	ModeLive     = "live"
+	// * answer for a call the original run never made
+	missingResult = "no recorded result for this call"
+)
+
+type Options struct {
+	Agent   agentTypes.Agent
+	Mode    string
+	WorkDir string
+	// * resolves the skill each recorded turn used, nil replays without skills
+	Scanner *skill.SkillScanner
+	// * live mode only, otherwise tools needing approval go to Confirm and are skipped without it
+	AllowAll bool
+	Confirm  func(name, args string) bool
+	Progress func(index, total int, input string)
+}
+
+// * Side is how one run answered a turn
+type Side struct {
+	Agent            string   `json:"agent,omitempty"`
+	Skill            string   `json:"skill,omitempty"`
+	Answer           string   `json:"answer"`
+	Tools            []string `json:"tools"`
+	DurationMS       int64    `json:"duration_ms,omitempty"`
+	PromptTokens     int      `json:"prompt_tokens,omitempty"`
+	CompletionTokens int      `json:"completion_tokens,omitempty"`
+	TotalTokens      int      `json:"total_tokens,omitempty"`
+}
+
+type TurnResult struct {
+	Index    int    `json:"index"`
+	Input    string `json:"input"`
+	Original Side   `json:"original"`
+	Replay   Side   `json:"replay"`
+	// * recorded mode, calls the replay made that the original did not
+	Missing int    `json:"missing,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type Report struct {
+	SessionID       string       `json:"session_id"`
+	ReplaySessionID string       `json:"replay_session_id"`
+	Mode            string       `json:"mode"`
+	Agent           string       `json:"agent"`
+	Turns           []TurnResult `json:"turns"`
+}
+
+// * Run replays every user turn of sessionID through opts.Agent in a new session, the original is left untouched
+func Run(ctx context.Context, sessionID string, opts Options) (*Report, error) {
+	if opts.Agent == nil {
+		return nil, fmt.Errorf("agent is required")
+	}
+	switch opts.Mode {
+	case "":
+		opts.Mode = ModeRecorded
+	case ModeRecorded, ModeLive:
+	default:
+		return nil, fmt.Errorf("unknown mode: %s", opts.Mode)
+	}
+
+	original, err := transcript.Load(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("transcript.Load: %w", err)
+	}
+	var turns []*transcript.Turn
+	for _, turn := range original.Turns {
+		if turn.User != "" {
+			turns = append(turns, turn)
+		}
+	}
+	if len(turns) == 0 {
+		return nil, fmt.Errorf("no user turns in session: %s", sessionID)
+	}
+
+	replayID, err := sessionManager.CreateSession()
+	if err != nil {
+		return nil, fmt.Errorf("sessionManager.CreateSession: %w", err)
+	}
+	report := &Report{
+		SessionID:       sessionID,
+		ReplaySessionID: replayID,
+		Mode:            opts.Mode,
+		Agent:           opts.Agent.Name(),
+	}
+	recorder := newRecorder(original)
+	for i, turn := range turns {
+		if ctx.Err() != nil {
+			break
+		}
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(turns), turn.User)
+		}
+		result := TurnResult{
+			Index:    turn.Index,
+			Input:    turn.User,
+			Original: originalSide(turn),
+		}
+		result.Replay, result.Missing, err = replayTurn(ctx, replayID, turn, opts, recorder)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		report.Turns = append(report.Turns, result)
+	}
+	return report, nil
+}
+
+func originalSide(turn *transcript.Turn) Side {
+	side := Side{
+		Answer: turn.Answer,
+		Tools:  toolNames(turn.Calls),
+	}
+	if turn.Meta != nil {
+		side.Agent = turn.Meta.Agent
+		side.Skill = turn.Meta.Skill
+		side.DurationMS = turn.Meta.EndedAt.Sub(turn.Meta.StartedAt).Milliseconds()
+		side.PromptTokens = turn.Meta.PromptTokens
+		side.CompletionTokens = turn.Meta.CompletionTokens
+		side.TotalTokens = turn.Meta.TotalTokens
+	}
+	return side
+}
+
+// * replayTurn runs one input on replayID, the same skill as the original when it is still installed
+func replayTurn(ctx context.Context, replayID string, turn *transcript.Turn, opts Options, recorder *recorder) (Side, int, error) {
+	data := exec.ExecData{
+		Agent:   opts.Agent,
+		WorkDir: opts.WorkDir,
+		Content: turn.User,
+	}
+	if turn.Meta != nil && turn.Meta.Skill != "" && opts.Scanner != nil {
+		data.Skill = opts.Scanner.Skills.ByName[turn.Meta.Skill]
+	}
+
+	missing := 0
+	allowAll := opts.AllowAll
+	if opts.Mode == ModeRecorded {
+		// * nothing runs, there is nothing to approve
+		allowAll = true
+		data.Recorded = func(name, args string) (string, bool) {
+			if result, ok := recorder.take(turn.Index, name, args); ok {
+				return result, true
+			}
+			missing++
+			return missingResult, true
+		}
+	}
+
+	side := Side{
+		Agent: opts.Agent.Name(),
+		Tools: []string{},
+	}
+	if data.Skill != nil {
+		side.Skill = strings.TrimSpace(data.Skill.Name)
+	}
+
+	events := make(chan agentTypes.Event, 16)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for ev := range events {
+			switch ev.Type {
+			case agentTypes.EventText:
+				side.Answer = ev.Text
+			case agentTypes.EventToolConfirm:
+				ev.ReplyCh <- opts.Confirm != nil && opts.Confirm(ev.ToolName, ev.ToolArgs)
+			}
+		}
+	}()
+
+	started := time.Now()
+	session := exec.LoadSession(data, replayID)
+	err := exec.Execute(ctx, data, session, events, allowAll)
+	close(events)
+	wg.Wait()
+	side.DurationMS = time.Since(started).Milliseconds()
+
+	// * read back like the original, from the turn record and tool log of the replay session
+	if records, _ := sessionManager.GetTurns(replayID); len(records) > 0 {
+		record := records[len(records)-1]
+		if !record.StartedAt.Before(started) {
+			side.DurationMS = record.EndedAt.Sub(record.StartedAt).Milliseconds()
+			side.PromptTokens = record.PromptTokens
+			side.CompletionTokens = record.CompletionTokens
+			side.TotalTokens = record.TotalTokens
+			if record.ToolCalls != "" {
+				side.Tools = toolNames(transcript.ReadCalls(filepath.Join(filesystem.SessionsDir, replayID, record.ToolCalls)))
+			}
+		}
+	}
+	return side, missing, err
+}
+
+func toolNames(calls []*transcript.Call) []string {
+	names := make([]string, 0, len(calls))
+	for _, call := range calls {
+		names = append(names, call.Tool)
+	}
+	return names
+}
+
+// * recorder serves each recorded result once, preferring the same turn and the same arguments
+type recorder struct {
+	turns map[int][]*transcript.Call
+	order []int
+	used  map[*transcript.Call]bool
+}
+
+func newRecorder(t *transcript.Transcript) *recorder {
+	r := &recorder{
+		turns: map[int][]*transcript.Call{},
+		used:  map[*transcript.Call]bool{},
+	}
+	for _, turn := range t.Turns {
+		if len(turn.Calls) == 0 {
+			continue
+		}
+		r.turns[turn.Index] = turn.Calls
+		r.order = append(r.order, turn.Index)
+	}
+	return r
+}
+
+// * take looks for the same call in the turn, then in any turn, then the next call of the same tool in the turn,
+// * logs from before arguments were recorded only match the last way
+func (r *recorder) take(turn int, name, args string) (string, bool) {
+	args = normalize(args)
+	match := func(calls []*transcript.Call, sameArgs bool) *transcript.Call {
+		for _, call := range calls {
+			if r.used[call] || call.Tool != name {
+				continue
+			}
+			if !sameArgs || (call.Arguments != "" && normalize(call.Arguments) == args) {
+				return call
+			}
+		}
+		return nil
+	}
+
+	call := match(r.turns[turn], true)
+	for _, index := range r.order {
+		if call != nil {
+			break
+		}
+		call = match(r.turns[index], true)
+	}
+	if call == nil {
+		call = match(r.turns[turn], false)
+	}
+	if call == nil {
+		return "", false
+	}
+	r.used[call] = true
+	return call.Result, true
+}
+
+// * normalize re-encodes JSON arguments so key order and spacing do not matter
+func normalize(args string) string {
+	var value any
+	if json.Unmarshal([]byte(args), &value) != nil {
+		return strings.TrimSpace(args)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return strings.TrimSpace(args)
+	}
+	return string(data)
+}
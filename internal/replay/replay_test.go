@@ -0,0 +1,54 @@
+package replay
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/pardnchiu/agenvoy/internal/transcript"
+)
+
+func TestRecorderTake(t *testing.T) {
+	r := newRecorder(&transcript.Transcript{Turns: []*transcript.Turn{
+		{Index: 1, Calls: []*transcript.Call{
+			{Tool: "read_file", Arguments: `{"path":"a.go"}`, Result: "a"},
+			{Tool: "read_file", Arguments: `{"path":"b.go"}`, Result: "b"},
+		}},
+		{Index: 2, Calls: []*transcript.Call{
+			{Tool: "fetch_page", Result: "legacy page"},
+			{Tool: "read_file", Arguments: `{"path":"c.go", "limit": 10}`, Result: "c"},
+		}},
+	}})
+
+	cases := []struct {
+		turn       int
+		name, args string
+		want       string
+		ok         bool
+	}{
+		// * same arguments in the same turn, regardless of order
+		{1, "read_file", `{"path":"b.go"}`, "b", true},
+		// * same arguments from another turn, key order and spacing ignored
+		{1, "read_file", `{"limit":10,"path":"c.go"}`, "c", true},
+		// * a result-only log matches by tool within the turn
+		{2, "fetch_page", `{"url":"https://example.com"}`, "legacy page", true},
+		// * each result is served once
+		{2, "fetch_page", `{}`, "", false},
+		// * different arguments fall back to the next unused call of the tool in the turn
+		{1, "read_file", `{"path":"z.go"}`, "a", true},
+		{1, "read_file", `{"path":"b.go"}`, "", false},
+	}
+	for _, c := range cases {
+		got, ok := r.take(c.turn, c.name, c.args)
+		if got != c.want || ok != c.ok {
+			t.Errorf("take(%d, %s, %s) = %q, %v, want %q, %v", c.turn, c.name, c.args, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	got := lineDiff("one\ntwo\nthree", "one\n2\nthree\nfour")
+	want := []string{"  one", "- two", "+ 2", "  three", "+ four"}
+	if !slices.Equal(got, want) {
+		t.Errorf("lineDiff = %q, want %q", got, want)
+	}
+}
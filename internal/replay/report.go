@@ -0,0 +1,164 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	FormatMarkdown = "md"
+	FormatJSON     = "json"
+)
+
+// * Write renders report in format to w
+func Write(w io.Writer, report *Report, format string) error {
+	switch format {
+	case FormatMarkdown, "":
+		_, err := io.WriteString(w, markdown(report))
+		return err
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+func markdown(report *Report) string {
+	var original, replayed Side
+	sameTools, sameAnswers, missing := 0, 0, 0
+	for _, turn := range report.Turns {
+		original.DurationMS += turn.Original.DurationMS
+		original.PromptTokens += turn.Original.PromptTokens
+		original.CompletionTokens += turn.Original.CompletionTokens
+		replayed.DurationMS += turn.Replay.DurationMS
+		replayed.PromptTokens += turn.Replay.PromptTokens
+		replayed.CompletionTokens += turn.Replay.CompletionTokens
+		if slices.Equal(turn.Original.Tools, turn.Replay.Tools) {
+			sameTools++
+		}
+		if strings.TrimSpace(turn.Original.Answer) == strings.TrimSpace(turn.Replay.Answer) {
+			sameAnswers++
+		}
+		missing += turn.Missing
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Replay of %s\n\n", report.SessionID)
+	fmt.Fprintf(&b, "Agent `%s`, %s tool results, replayed into session `%s`.\n\n", report.Agent, report.Mode, report.ReplaySessionID)
+	b.WriteString("| | Original | Replay |\n|---|---|---|\n")
+	fmt.Fprintf(&b, "| Latency | %s | %s |\n", latency(original.DurationMS), latency(replayed.DurationMS))
+	fmt.Fprintf(&b, "| Tokens in / out | %s | %s |\n", tokens(original), tokens(replayed))
+	fmt.Fprintf(&b, "\n%d turns, %d with the same tool calls, %d with the same answer", len(report.Turns), sameTools, sameAnswers)
+	if report.Mode == ModeRecorded {
+		fmt.Fprintf(&b, ", %d calls without a recorded result", missing)
+	}
+	b.WriteString(".\n")
+
+	for _, turn := range report.Turns {
+		fmt.Fprintf(&b, "\n## Turn %d\n\n", turn.Index)
+		for _, line := range strings.Split(turn.Input, "\n") {
+			fmt.Fprintf(&b, "> %s\n", line)
+		}
+		b.WriteString("\n| | Original | Replay |\n|---|---|---|\n")
+		fmt.Fprintf(&b, "| Agent | %s | %s |\n", cell(turn.Original.Agent), cell(turn.Replay.Agent))
+		fmt.Fprintf(&b, "| Skill | %s | %s |\n", cell(turn.Original.Skill), cell(turn.Replay.Skill))
+		fmt.Fprintf(&b, "| Tools | %s | %s |\n", cell(strings.Join(turn.Original.Tools, " → ")), cell(strings.Join(turn.Replay.Tools, " → ")))
+		fmt.Fprintf(&b, "| Latency | %s | %s |\n", latency(turn.Original.DurationMS), latency(turn.Replay.DurationMS))
+		fmt.Fprintf(&b, "| Tokens in / out | %s | %s |\n", tokens(turn.Original), tokens(turn.Replay))
+		if turn.Missing > 0 {
+			fmt.Fprintf(&b, "\n%d calls had no recorded result.\n", turn.Missing)
+		}
+		if turn.Error != "" {
+			fmt.Fprintf(&b, "\nReplay failed: %s\n", turn.Error)
+		}
+		b.WriteString("\n")
+		if strings.TrimSpace(turn.Original.Answer) == strings.TrimSpace(turn.Replay.Answer) {
+			b.WriteString("Same answer.\n")
+			continue
+		}
+		b.WriteString(fenced(strings.Join(lineDiff(turn.Original.Answer, turn.Replay.Answer), "\n"), "diff"))
+	}
+	return b.String()
+}
+
+// * cell keeps a value inside one table cell
+func cell(value string) string {
+	if value == "" {
+		return "-"
+	}
+	value = strings.ReplaceAll(value, "|", `\|`)
+	return strings.ReplaceAll(value, "\n", " ")
+}
+
+func latency(ms int64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	return (time.Duration(ms) * time.Millisecond).Round(100 * time.Millisecond).String()
+}
+
+func tokens(side Side) string {
+	if side.PromptTokens == 0 && side.CompletionTokens == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d / %d", side.PromptTokens, side.CompletionTokens)
+}
+
+// * fenced wraps text in a code fence longer than any backtick run inside it
+func fenced(text, lang string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + text + "\n" + fence + "\n"
+}
+
+// * lineDiff marks lines only in a with "-", only in b with "+" and shared ones with a space, by longest common subsequence
+func lineDiff(a, b string) []string {
+	before := strings.Split(strings.TrimSpace(a), "\n")
+	after := strings.Split(strings.TrimSpace(b), "\n")
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(before) && j < len(after) {
+		switch {
+		case before[i] == after[j]:
+			lines = append(lines, "  "+before[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "- "+before[i])
+			i++
+		default:
+			lines = append(lines, "+ "+after[j])
+			j++
+		}
+	}
+	for ; i < len(before); i++ {
+		lines = append(lines, "- "+before[i])
+	}
+	for ; j < len(after); j++ {
+		lines = append(lines, "+ "+after[j])
+	}
+	return lines
+}
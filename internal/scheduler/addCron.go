@@ -2,72 +2,102 @@ package scheduler
 
 import (
 	"fmt"
-	"path/filepath"
+	"log/slog"
 	"strings"
-
-	"github.com/pardnchiu/agenvoy/internal/filesystem"
+	"time"
 )
 
-type cronItem struct {
-	line       string
-	expression string
-	script     string
-	channelID  string
-	cronID     int64
-}
+// * allow: 5 fields, @daily style descriptors, `CRON_TZ=Zone` prefix
+func (s *Scheduler) AddCron(expression string, job Job) (*Job, error) {
+	tz, expression := splitCronTZ(expression)
+	if tz != "" {
+		if job.Timezone != "" && job.Timezone != tz {
+			return nil, fmt.Errorf("CRON_TZ=%s conflicts with timezone %s", tz, job.Timezone)
+		}
+		job.Timezone = tz
+	}
+	if _, err := parseCronExpr(expression); err != nil {
+		return nil, err
+	}
 
-func (s *Scheduler) AddCron(expression, script, channelID string) error {
-	line := buildLine(expression, script, channelID)
-	item, err := parseCronLine(line)
-	if err != nil {
-		return err
+	if err := validateJob(job); err != nil {
+		return nil, err
 	}
+	job.Kind = KindCron
+	job.Schedule = strings.Join(strings.Fields(expression), " ")
+	return s.add(job)
+}
 
-	id, err := s.cron.Add(item.expression, s.makeCronAction(item))
+// * setCron arms the next occurrence after from, caller holds s.mu
+func (s *Scheduler) setCron(job *Job, from time.Time) error {
+	expr, err := parseCronExpr(job.Schedule)
 	if err != nil {
-		return fmt.Errorf("cron.Add: %w", err)
+		return fmt.Errorf("parseCronExpr: %w", err)
 	}
-	item.cronID = id
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if err := appendLine(filesystem.CronsPath, line); err != nil {
-		s.cron.Remove(id)
-		return fmt.Errorf("appendLine: %w", err)
+	next := expr.Next(from.In(job.location()))
+	if next.IsZero() {
+		return fmt.Errorf("no upcoming time for %q", job.Schedule)
 	}
 
-	s.crons = append(s.crons, item)
+	id := job.ID
+	s.timers[id] = time.AfterFunc(time.Until(next), func() {
+		s.fireCron(id, next)
+	})
 	return nil
 }
 
-func parseCronLine(line string) (cronItem, error) {
-	fields := strings.Fields(line)
-	if len(fields) < 6 {
-		return cronItem{}, fmt.Errorf("at least 6 fields `{min} {hour} {dom} {mon} {dow} {script}`")
+// * fireCron re-arms the job before running it, so a slow run does not shift the schedule
+func (s *Scheduler) fireCron(id string, due time.Time) {
+	s.mu.Lock()
+	delete(s.timers, id)
+	job, _ := s.find(id)
+	if job == nil || !job.Enabled || s.stopped() {
+		s.mu.Unlock()
+		return
 	}
-
-	channelID := ""
-	if len(fields) >= 7 {
-		channelID = fields[6]
+	// * from due at the latest, an early wake-up must not pick the same minute again
+	from := time.Now()
+	if from.Before(due) {
+		from = due
+	}
+	if err := s.setCron(job, from); err != nil {
+		slog.Warn("s.setCron",
+			slog.String("id", id),
+			slog.String("error", err.Error()))
 	}
+	s.mu.Unlock()
 
-	return cronItem{
-		line:       line,
-		expression: strings.Join(fields[:5], " "),
-		script:     fields[5],
-		channelID:  channelID,
-	}, nil
+	s.runCron(id, due)
 }
 
-func (s *Scheduler) makeCronAction(item cronItem) func() {
-	return func() {
-		output := runScript("cron", filepath.Join(filesystem.ScriptsDir, item.script))
-		s.mu.Lock()
-		cb := s.OnCompleted
+func (s *Scheduler) runCron(id string, due time.Time) {
+	s.mu.Lock()
+	job, _ := s.find(id)
+	if job == nil || !job.Enabled {
 		s.mu.Unlock()
-		if item.channelID != "" && cb != nil {
-			cb(item.channelID, output)
-		}
+		return
+	}
+	job.LastDue = due
+	// * a slow run keeps its slot, the overlapping tick is dropped
+	if s.active[id] {
+		s.mu.Unlock()
+		slog.Warn("cron still running, tick skipped",
+			slog.String("id", id))
+		return
 	}
+	s.active[id] = true
+	snapshot := *job
+	s.running.Add(1)
+	defer s.running.Done()
+	s.mu.Unlock()
+
+	run, output := execute("cron", snapshot)
+	// * agent answers are already chat messages
+	Deliver(snapshot.Target, output, snapshot.Prompt != "")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.active, id)
+	s.record(id, run)
 }
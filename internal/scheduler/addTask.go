@@ -2,7 +2,6 @@ package scheduler
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -10,45 +9,28 @@ import (
 	"github.com/pardnchiu/agenvoy/internal/filesystem"
 )
 
-type taskItem struct {
-	line      string
-	at        time.Time
-	script    string
-	channelID string
-}
-
 // * allow: +5m, +1h30m, 15:04, 2006-01-02 15:04, RFC3339
-func (s *Scheduler) AddTask(text, script, channelID string) (string, error) {
-	at, err := parseTaskTime(text)
-	if err != nil {
-		return "", err
-	}
-
-	if !at.After(time.Now()) {
-		return "", fmt.Errorf("already gone")
+func (s *Scheduler) AddTask(text string, job Job) (*Job, error) {
+	// * validated first, the timezone decides how text is read
+	if err := validateJob(job); err != nil {
+		return nil, err
 	}
-
-	line := buildLine(at.UTC().Format(time.RFC3339), script, channelID)
-	item, err := parseTaskLine(line)
-	// * ensure format is correct
+	at, err := parseTaskTime(text, job.location())
 	if err != nil {
-		return "", fmt.Errorf("parseTaskLine: %w", err)
+		return nil, err
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if err := appendLine(filesystem.TasksPath, line); err != nil {
-		return "", fmt.Errorf("appendLine: %w", err)
+	if !at.After(time.Now()) {
+		return nil, fmt.Errorf("already gone")
 	}
 
-	if err := s.setTask(item); err != nil {
-		return "", fmt.Errorf("s.setTask: %w", err)
-	}
-	return fmt.Sprintf("already set up task at %s: %s", at.Local().Format("2006-01-02 15:04:05"), script), nil
+	job.Kind = KindTask
+	job.Schedule = at.UTC().Format(time.RFC3339)
+	return s.add(job)
 }
 
-func parseTaskTime(text string) (time.Time, error) {
+// * parseTaskTime reads wall clock forms in loc
+func parseTaskTime(text string, loc *time.Location) (time.Time, error) {
 	text = strings.TrimSpace(text)
 
 	if strings.HasPrefix(text, "+") {
@@ -59,7 +41,7 @@ func parseTaskTime(text string) (time.Time, error) {
 		return time.Now().Add(duration), nil
 	}
 
-	if t, err := time.ParseInLocation("2006-01-02 15:04", text, time.Local); err == nil {
+	if t, err := time.ParseInLocation("2006-01-02 15:04", text, loc); err == nil {
 		return t, nil
 	}
 
@@ -68,9 +50,9 @@ func parseTaskTime(text string) (time.Time, error) {
 	}
 
 	// * 15:04, no date, assume today
-	if t, err := time.ParseInLocation("15:04", text, time.Local); err == nil {
-		now := time.Now()
-		result := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.Local)
+	if t, err := time.ParseInLocation("15:04", text, loc); err == nil {
+		now := time.Now().In(loc)
+		result := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, loc)
 		if !result.After(now) {
 			return time.Time{}, fmt.Errorf("already gone: %q", text)
 		}
@@ -80,68 +62,55 @@ func parseTaskTime(text string) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("parseTime: %s", text)
 }
 
-func parseTaskLine(line string) (taskItem, error) {
-	fields := strings.Fields(line)
-	if len(fields) < 2 {
-		return taskItem{}, fmt.Errorf("at least 2 fields `{time} {script}`")
-	}
-
-	at, err := time.Parse(time.RFC3339, fields[0])
+// * setTask arms the timer, a task already due while the scheduler was down
+// * runs now under misfire once / all and is recorded as missed otherwise
+func (s *Scheduler) setTask(job *Job) error {
+	at, err := time.Parse(time.RFC3339, job.Schedule)
 	if err != nil {
-		return taskItem{}, fmt.Errorf("not RFC3339: %w", err)
+		return fmt.Errorf("not RFC3339: %w", err)
 	}
 
-	item := taskItem{
-		line:   line,
-		at:     at,
-		script: fields[1],
-	}
-	if len(fields) >= 3 {
-		item.channelID = fields[2]
+	now := time.Now()
+	if !at.After(now) && job.misfire() == MisfireSkip {
+		job.addRun(Run{
+			StartedAt: now,
+			EndedAt:   now,
+			ExitCode:  -1,
+			Error:     "missed: scheduler was not running at the due time",
+		})
+		return nil
 	}
-	return item, nil
+
+	id := job.ID
+	s.timers[id] = time.AfterFunc(time.Until(at), func() {
+		s.runTask(id)
+	})
+	return nil
 }
 
-func (s *Scheduler) setTask(item taskItem) error {
-	if err := os.MkdirAll(filesystem.ScriptsDir, 0755); err != nil {
-		return fmt.Errorf("os.MkdirAll: %w", err)
+func (s *Scheduler) runTask(id string) {
+	s.mu.Lock()
+	delete(s.timers, id)
+	job, _ := s.find(id)
+	if job == nil {
+		s.mu.Unlock()
+		return
 	}
+	snapshot := *job
+	s.running.Add(1)
+	defer s.running.Done()
+	s.mu.Unlock()
 
-	scriptPath := filepath.Join(filesystem.ScriptsDir, item.script)
-	delay := time.Until(item.at)
-
-	execTime := time.AfterFunc(delay, func() {
-		output := runScript("task", scriptPath)
-
-		if item.channelID != "" {
-			s.mu.Lock()
-			cb := s.OnCompleted
-			s.mu.Unlock()
-
-			if cb != nil {
-				cb(item.channelID, output)
-			}
-		}
+	run, output := execute("task", snapshot)
+	// * agent answers are already chat messages
+	Deliver(snapshot.Target, output, snapshot.Prompt != "")
 
-		s.mu.Lock()
-		defer s.mu.Unlock()
-
-		delete(s.timers, item.line)
-		removeLine(filesystem.TasksPath, item.line)
-		// * remove in memory cache, ensure completed remove
-		s.removeTask(item.line)
-		removeScript(scriptPath)
-	})
-	s.timers[item.line] = execTime
-	s.tasks = append(s.tasks, item)
-	return nil
-}
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-func (s *Scheduler) removeTask(line string) {
-	for i, task := range s.tasks {
-		if task.line == line {
-			s.tasks = append(s.tasks[:i], s.tasks[i+1:]...)
-			return
-		}
+	s.record(id, run)
+	// * one-time scripts are done once fired
+	if snapshot.Script != "" && !s.scriptInUse(snapshot.Script, id) {
+		removeScript(filepath.Join(filesystem.ScriptsDir, snapshot.Script))
 	}
 }
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+)
+
+// * defaultAlertFailures is used when SCHEDULER_ALERT_FAILURES is unset
+const defaultAlertFailures = 3
+
+// * alertFailures is the number of consecutive failures that raises an alert, 0 disables alerts
+func alertFailures() int {
+	value := os.Getenv("SCHEDULER_ALERT_FAILURES")
+	if value == "" {
+		return defaultAlertFailures
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		slog.Warn("invalid SCHEDULER_ALERT_FAILURES",
+			slog.String("value", value))
+		return defaultAlertFailures
+	}
+	return n
+}
+
+// * record stores the run and counts failures, caller holds s.mu
+func (s *Scheduler) record(id string, run Run) {
+	// * removed while running
+	job, _ := s.find(id)
+	if job == nil {
+		return
+	}
+	job.addRun(run)
+	if run.OK {
+		job.Failures = 0
+	} else {
+		job.Failures++
+		// * once per streak, a job failing forever does not alert on every run
+		if threshold := alertFailures(); threshold > 0 && job.Failures == threshold {
+			go sendAlert(*job, run)
+		}
+	}
+	if err := s.save(); err != nil {
+		slog.Warn("s.save",
+			slog.String("error", err.Error()))
+	}
+}
+
+// * sendAlert goes to SCHEDULER_ALERT_TARGET, the job's own target when unset
+func sendAlert(job Job, run Run) {
+	target := os.Getenv("SCHEDULER_ALERT_TARGET")
+	if target == "" {
+		target = job.Target
+	}
+	if target == "" {
+		return
+	}
+	slog.Warn("scheduler alert",
+		slog.String("id", job.ID),
+		slog.Int("failures", job.Failures))
+
+	what := job.Script
+	if job.Prompt != "" {
+		what = fmt.Sprintf("prompt %q", previewPrompt(job.Prompt))
+	}
+	message := fmt.Sprintf("⚠️ %s %s (%s) failed %d times in a row\nlast error: %s", job.Kind, job.ID, what, job.Failures, run.Error)
+	if job.Description != "" {
+		message += "\n# " + job.Description
+	}
+	Deliver(target, message, true)
+}
@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+)
+
+// * client talks to the scheduler owned by another process
+type client struct {
+	httpClient *http.Client
+}
+
+func newClient() *client {
+	return &client{
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", filesystem.ControlPath)
+				},
+			},
+		},
+	}
+}
+
+func (c *client) AddTask(text string, job Job) (*Job, error) {
+	var result Job
+	if err := c.do(http.MethodPost, "/tasks", addRequest{When: text, Job: job}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *client) AddCron(expression string, job Job) (*Job, error) {
+	var result Job
+	if err := c.do(http.MethodPost, "/crons", addRequest{When: expression, Job: job}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *client) Jobs(kind string) ([]Job, error) {
+	var result []Job
+	err := c.do(http.MethodGet, "/jobs?kind="+url.QueryEscape(kind), nil, &result)
+	return result, err
+}
+
+func (c *client) Remove(id string) error {
+	return c.do(http.MethodDelete, "/jobs/"+url.PathEscape(id), nil, nil)
+}
+
+func (c *client) SetEnabled(id string, enabled bool) error {
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	return c.do(http.MethodPost, "/jobs/"+url.PathEscape(id)+"/"+action, nil, nil)
+}
+
+func (c *client) do(method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("json.Marshal: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	// * host is ignored, the transport always dials the socket
+	req, err := http.NewRequest(method, "http://scheduler"+path, reader)
+	if err != nil {
+		return fmt.Errorf("http.NewRequest: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("httpClient.Do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var errResp errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil || errResp.Error == "" {
+			return fmt.Errorf("scheduler: %s", resp.Status)
+		}
+		return fmt.Errorf("%s", errResp.Error)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("json.Decode: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+)
+
+// * Manager is what tools and commands need, served in process or over the control socket
+type Manager interface {
+	AddTask(text string, job Job) (*Job, error)
+	AddCron(expression string, job Job) (*Job, error)
+	Jobs(kind string) ([]Job, error)
+	Remove(id string) error
+	SetEnabled(id string, enabled bool) error
+}
+
+type addRequest struct {
+	// * time text for tasks, cron expression for crons
+	When string `json:"when"`
+	Job  Job    `json:"job"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Scheduler) Jobs(kind string) ([]Job, error) {
+	return s.List(kind), nil
+}
+
+// * RemoveKind removes id only when it is a job of kind, so remove_task cannot drop a cron
+func RemoveKind(mgr Manager, kind, id string) error {
+	jobs, err := mgr.Jobs("")
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.ID == id && job.Kind != kind {
+			return fmt.Errorf("%s is not a %s", id, kind)
+		}
+	}
+	return mgr.Remove(id)
+}
+
+// * Current returns the scheduler of this process, or the one behind the control socket
+func Current() (Manager, error) {
+	if s := Get(); s != nil {
+		return s, nil
+	}
+	if Running() {
+		return newClient(), nil
+	}
+	return nil, fmt.Errorf("scheduler not running, start it with `agenvoy daemon` or `agenvoy-server`")
+}
+
+// * Running reports whether a process answers on the control socket
+func Running() bool {
+	conn, err := net.DialTimeout("unix", filesystem.ControlPath, time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// * Serve exposes the scheduler of this process on the control socket until ctx is done
+func Serve(ctx context.Context) error {
+	s := Get()
+	if s == nil {
+		return fmt.Errorf("scheduler not initialized")
+	}
+	if Running() {
+		return fmt.Errorf("control socket in use: %s", filesystem.ControlPath)
+	}
+	// * left behind by a process that did not shut down cleanly
+	if err := os.Remove(filesystem.ControlPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("os.Remove: %w", err)
+	}
+	if err := os.MkdirAll(filesystem.SchedulerDir, 0755); err != nil {
+		return fmt.Errorf("os.MkdirAll: %w", err)
+	}
+
+	listener, err := net.Listen("unix", filesystem.ControlPath)
+	if err != nil {
+		return fmt.Errorf("net.Listen: %w", err)
+	}
+	// * same user only, the socket can add jobs that run scripts
+	if err := os.Chmod(filesystem.ControlPath, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("os.Chmod: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /jobs", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.List(r.URL.Query().Get("kind")))
+	})
+	mux.HandleFunc("POST /tasks", func(w http.ResponseWriter, r *http.Request) {
+		handleAdd(w, r, s.AddTask)
+	})
+	mux.HandleFunc("POST /crons", func(w http.ResponseWriter, r *http.Request) {
+		handleAdd(w, r, s.AddCron)
+	})
+	mux.HandleFunc("DELETE /jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		writeResult(w, s.Remove(r.PathValue("id")))
+	})
+	mux.HandleFunc("POST /jobs/{id}/enable", func(w http.ResponseWriter, r *http.Request) {
+		writeResult(w, s.SetEnabled(r.PathValue("id"), true))
+	})
+	mux.HandleFunc("POST /jobs/{id}/disable", func(w http.ResponseWriter, r *http.Request) {
+		writeResult(w, s.SetEnabled(r.PathValue("id"), false))
+	})
+
+	server := &http.Server{Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("scheduler control socket",
+		slog.String("path", filesystem.ControlPath))
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server.Serve: %w", err)
+	}
+	os.Remove(filesystem.ControlPath)
+	return nil
+}
+
+func handleAdd(w http.ResponseWriter, r *http.Request, add func(string, Job) (*Job, error)) {
+	var req addRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	job, err := add(req.When, req.Job)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func writeResult(w http.ResponseWriter, err error) {
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
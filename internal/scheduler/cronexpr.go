@@ -0,0 +1,225 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// * nextSearchLimit stops the search for expressions that never match, e.g. `0 0 31 2 *`
+const nextSearchLimit = 5 * 366 * 24 * time.Hour
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type cronField struct {
+	values map[int]bool
+	all    bool // * `*`, matters for the day of month / day of week rule
+	// * day of month: `L` last day, `LW` last weekday (Mon-Fri)
+	lastDay     bool
+	lastWeekday bool
+	// * day of week: `5L` last Friday of the month
+	lastOf map[int]bool
+}
+
+type cronExpr struct {
+	minute, hour, dom, month, dow cronField
+}
+
+// * splitCronTZ takes a leading `CRON_TZ=` or `TZ=` off the expression
+func splitCronTZ(expression string) (tz, rest string) {
+	expression = strings.TrimSpace(expression)
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if strings.HasPrefix(expression, prefix) {
+			tz, rest, _ = strings.Cut(expression[len(prefix):], " ")
+			return tz, strings.TrimSpace(rest)
+		}
+	}
+	return "", expression
+}
+
+// * parseCronExpr reads the 5 standard fields or a descriptor such as `@daily`
+func parseCronExpr(expression string) (*cronExpr, error) {
+	expression = strings.TrimSpace(expression)
+	if strings.HasPrefix(expression, "@") {
+		spec, ok := descriptors[strings.ToLower(expression)]
+		if !ok {
+			return nil, fmt.Errorf("unknown descriptor %q", expression)
+		}
+		expression = spec
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("5 fields `{min} {hour} {dom} {mon} {dow}` or @hourly / @daily / @weekly / @monthly / @yearly")
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	parsed := make([]cronField, 5)
+	for i, field := range fields {
+		f, err := parseCronField(field, i, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("field %d %q: %w", i+1, field, err)
+		}
+		parsed[i] = f
+	}
+	// * 7 is sunday as well
+	if parsed[4].values[7] {
+		parsed[4].values[0] = true
+	}
+	if parsed[4].lastOf[7] {
+		parsed[4].lastOf[0] = true
+	}
+	return &cronExpr{
+		minute: parsed[0],
+		hour:   parsed[1],
+		dom:    parsed[2],
+		month:  parsed[3],
+		dow:    parsed[4],
+	}, nil
+}
+
+// * parseCronField handles `*`, `n`, `n-m`, `*/s`, `n-m/s`, lists, and `L` / `LW` / `nL` in the day fields
+func parseCronField(field string, index, min, max int) (cronField, error) {
+	result := cronField{
+		values: map[int]bool{},
+		lastOf: map[int]bool{},
+		all:    field == "*",
+	}
+	for _, part := range strings.Split(field, ",") {
+		switch {
+		case index == 2 && part == "L":
+			result.lastDay = true
+			continue
+		case index == 2 && part == "LW":
+			result.lastWeekday = true
+			continue
+		case index == 4 && len(part) > 1 && strings.HasSuffix(part, "L"):
+			v, err := strconv.Atoi(strings.TrimSuffix(part, "L"))
+			if err != nil || v < min || v > max {
+				return cronField{}, fmt.Errorf("invalid value %q", part)
+			}
+			result.lastOf[v] = true
+			continue
+		}
+
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			s, err := strconv.Atoi(stepPart)
+			if err != nil || s <= 0 {
+				return cronField{}, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = s
+		}
+
+		start, end := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			lo, hi, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = strconv.Atoi(lo); err != nil {
+				return cronField{}, fmt.Errorf("invalid value %q", lo)
+			}
+			if end, err = strconv.Atoi(hi); err != nil {
+				return cronField{}, fmt.Errorf("invalid value %q", hi)
+			}
+		default:
+			v, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return cronField{}, fmt.Errorf("invalid value %q", rangePart)
+			}
+			start = v
+			// * `n/s` runs from n to the end of the range
+			if !hasStep {
+				end = v
+			}
+		}
+		if start < min || end > max || start > end {
+			return cronField{}, fmt.Errorf("out of range %d-%d", min, max)
+		}
+		for v := start; v <= end; v += step {
+			result.values[v] = true
+		}
+	}
+	return result, nil
+}
+
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
+
+// * lastWeekdayOf is the day of the last Monday to Friday of the month of t
+func lastWeekdayOf(t time.Time) int {
+	last := time.Date(t.Year(), t.Month(), daysIn(t), 0, 0, 0, 0, t.Location())
+	switch last.Weekday() {
+	case time.Saturday:
+		return last.Day() - 1
+	case time.Sunday:
+		return last.Day() - 2
+	}
+	return last.Day()
+}
+
+func (c *cronExpr) domMatches(t time.Time) bool {
+	return c.dom.values[t.Day()] ||
+		(c.dom.lastDay && t.Day() == daysIn(t)) ||
+		(c.dom.lastWeekday && t.Day() == lastWeekdayOf(t))
+}
+
+func (c *cronExpr) dowMatches(t time.Time) bool {
+	weekday := int(t.Weekday())
+	return c.dow.values[weekday] ||
+		(c.dow.lastOf[weekday] && t.Day()+7 > daysIn(t))
+}
+
+// * dayMatches follows cron: when both day fields are restricted either one matching is enough
+func (c *cronExpr) dayMatches(t time.Time) bool {
+	switch {
+	case c.dom.all && c.dow.all:
+		return true
+	case c.dom.all:
+		return c.dowMatches(t)
+	case c.dow.all:
+		return c.domMatches(t)
+	default:
+		return c.domMatches(t) || c.dowMatches(t)
+	}
+}
+
+// * Next returns the first matching minute after t in the location of t,
+// * zero when nothing matches within the search limit
+func (c *cronExpr) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(nextSearchLimit)
+
+	for t.Before(limit) {
+		if !c.month.values[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.hour.values[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.minute.values[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronExprNext(t *testing.T) {
+	// * Monday
+	base := time.Date(2026, 10, 19, 8, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 19, 8, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 19, 8, 45, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 8 * * *", time.Date(2026, 10, 20, 8, 0, 0, 0, time.UTC)},
+		{"0 9 * * 0", time.Date(2026, 10, 25, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2026, 10, 25, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 8-10/2 * * 1-5", time.Date(2026, 10, 19, 10, 30, 0, 0, time.UTC)},
+		// * restricted day of month and day of week match either
+		{"0 0 25 * 3", time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+		{"@daily", time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		// * last day, last weekday (Oct 31 is Saturday), last Friday
+		{"0 18 L * *", time.Date(2026, 10, 31, 18, 0, 0, 0, time.UTC)},
+		{"0 18 LW * *", time.Date(2026, 10, 30, 18, 0, 0, 0, time.UTC)},
+		{"0 18 * * 5L", time.Date(2026, 10, 30, 18, 0, 0, 0, time.UTC)},
+		{"0 0 LW 2 *", time.Date(2027, 2, 26, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		expr, err := parseCronExpr(tt.expr)
+		if err != nil {
+			t.Fatalf("parseCronExpr(%q): %v", tt.expr, err)
+		}
+		if got := expr.Next(base); !got.Equal(tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseCronExprInvalid(t *testing.T) {
+	for _, expr := range []string{"* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@never", "0 0 * * 8L", "0 0 * L *"} {
+		if _, err := parseCronExpr(expr); err == nil {
+			t.Errorf("%q: expected error", expr)
+		}
+	}
+}
+
+func TestCronExprTimezone(t *testing.T) {
+	tz, rest := splitCronTZ("CRON_TZ=Asia/Taipei  0 9 * * *")
+	if tz != "Asia/Taipei" || rest != "0 9 * * *" {
+		t.Fatalf("splitCronTZ: got %q %q", tz, rest)
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	expr, _ := parseCronExpr(rest)
+	// * 08:30 UTC is 16:30 in Taipei, 09:00 Taipei is 01:00 UTC next day
+	got := expr.Next(time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC).In(loc))
+	if want := time.Date(2026, 10, 20, 1, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMissedSince(t *testing.T) {
+	job := Job{Kind: KindCron, Schedule: "0 * * * *"}
+	since := time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)
+
+	dues, total := job.missedSince(since, since.Add(3*time.Hour))
+	if total != 3 || len(dues) != 3 || !dues[2].Equal(time.Date(2026, 10, 19, 11, 0, 0, 0, time.UTC)) {
+		t.Errorf("3h: got %d %v", total, dues)
+	}
+
+	dues, total = job.missedSince(since, since.Add(48*time.Hour))
+	if total != 48 || len(dues) != misfireRunMax {
+		t.Errorf("48h: got total %d, %d dues", total, len(dues))
+	}
+}
@@ -0,0 +1,246 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+)
+
+const (
+	deliverAttempts = 3
+	deliverBackoff  = 5 * time.Second
+	deliverTimeout  = time.Minute
+	// * dead letters are retried on this interval and dropped once older than TTL
+	deadLetterInterval = 10 * time.Minute
+	deadLetterTTL      = 7 * 24 * time.Hour
+)
+
+// * Handler delivers output to address, the part after `kind:` in a target
+type Handler func(ctx context.Context, address, output string) error
+
+type deadLetter struct {
+	Target   string    `json:"target"`
+	Output   string    `json:"output"`
+	Final    bool      `json:"final,omitempty"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+}
+
+var errNoHandler = errors.New("no handler")
+
+type finalKey struct{}
+
+// * IsFinal reports output that is already a chat answer, handlers should not rewrite it
+func IsFinal(ctx context.Context) bool {
+	final, _ := ctx.Value(finalKey{}).(bool)
+	return final
+}
+
+var (
+	handlerMu    sync.RWMutex
+	handlers     = map[string]Handler{}
+	deadLetterMu sync.Mutex
+	// * one retry pass at a time, the pass merges back into the file it snapshotted
+	retryMu sync.Mutex
+)
+
+func init() {
+	RegisterHandler(TargetWebhook, deliverWebhook)
+	RegisterHandler(TargetEmail, deliverEmail)
+	RegisterHandler(TargetFile, deliverFile)
+	RegisterHandler(TargetStdout, deliverStdout)
+}
+
+// * RegisterHandler sets the handler of a target kind, nil removes it
+func RegisterHandler(kind string, handler Handler) {
+	handlerMu.Lock()
+	defer handlerMu.Unlock()
+
+	if handler == nil {
+		delete(handlers, kind)
+		return
+	}
+	handlers[kind] = handler
+}
+
+func getHandler(kind string) Handler {
+	handlerMu.RLock()
+	defer handlerMu.RUnlock()
+	return handlers[kind]
+}
+
+// * Deliver sends output to target with retries, failures go to the dead letter queue
+func Deliver(target, output string, final bool) {
+	if target == "" {
+		return
+	}
+	err := deliverWithRetry(target, output, final)
+	if err == nil {
+		return
+	}
+	slog.Warn("scheduler delivery failed",
+		slog.String("target", target),
+		slog.String("error", err.Error()))
+
+	now := time.Now()
+	if err := appendDeadLetter(deadLetter{
+		Target:   target,
+		Output:   output,
+		Final:    final,
+		Error:    err.Error(),
+		Attempts: deliverAttempts,
+		Created:  now,
+		Updated:  now,
+	}); err != nil {
+		slog.Warn("appendDeadLetter",
+			slog.String("error", err.Error()))
+	}
+}
+
+func deliverWithRetry(target, output string, final bool) error {
+	var err error
+	for attempt := 1; attempt <= deliverAttempts; attempt++ {
+		if err = deliverOnce(target, output, final); err == nil {
+			return nil
+		}
+		// * missing frontend will not appear within the backoff, park it right away
+		if errors.Is(err, errNoHandler) {
+			return err
+		}
+		if attempt < deliverAttempts {
+			time.Sleep(deliverBackoff * time.Duration(attempt))
+		}
+	}
+	return err
+}
+
+func deliverOnce(target, output string, final bool) error {
+	kind, address, err := ParseTarget(target)
+	if err != nil {
+		return err
+	}
+	handler := getHandler(kind)
+	if handler == nil {
+		return fmt.Errorf("%w for %s", errNoHandler, kind)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), finalKey{}, final), deliverTimeout)
+	defer cancel()
+	return handler(ctx, address, output)
+}
+
+// * RetryDeadLetters delivers queued outputs once more, keeps the ones still failing
+func RetryDeadLetters() {
+	retryMu.Lock()
+	defer retryMu.Unlock()
+
+	// * deliveries run unlocked so Deliver can still park letters meanwhile
+	deadLetterMu.Lock()
+	letters, err := readDeadLetters()
+	deadLetterMu.Unlock()
+	if err != nil {
+		slog.Warn("readDeadLetters",
+			slog.String("error", err.Error()))
+		return
+	}
+	if len(letters) == 0 {
+		return
+	}
+
+	now := time.Now()
+	var kept []deadLetter
+	for _, letter := range letters {
+		if now.Sub(letter.Created) > deadLetterTTL {
+			slog.Warn("dead letter expired",
+				slog.String("target", letter.Target),
+				slog.Time("created", letter.Created))
+			continue
+		}
+		if err := deliverOnce(letter.Target, letter.Output, letter.Final); err != nil {
+			letter.Error = err.Error()
+			letter.Attempts++
+			letter.Updated = now
+			kept = append(kept, letter)
+			continue
+		}
+		slog.Info("dead letter delivered",
+			slog.String("target", letter.Target))
+	}
+
+	deadLetterMu.Lock()
+	defer deadLetterMu.Unlock()
+
+	// * only appendDeadLetter writes while retryMu is held, anything past the snapshot was parked during the retry
+	current, err := readDeadLetters()
+	if err != nil {
+		slog.Warn("readDeadLetters",
+			slog.String("error", err.Error()))
+		return
+	}
+	if len(current) > len(letters) {
+		kept = append(kept, current[len(letters):]...)
+	}
+	if err := writeDeadLetters(kept); err != nil {
+		slog.Warn("writeDeadLetters",
+			slog.String("error", err.Error()))
+	}
+}
+
+func appendDeadLetter(letter deadLetter) error {
+	deadLetterMu.Lock()
+	defer deadLetterMu.Unlock()
+
+	letters, err := readDeadLetters()
+	if err != nil {
+		return err
+	}
+	return writeDeadLetters(append(letters, letter))
+}
+
+func readDeadLetters() ([]deadLetter, error) {
+	// * outputs can outgrow the line limit of filesystem.ReadFile
+	data, err := os.ReadFile(filesystem.DeadLetterPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	var letters []deadLetter
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var letter deadLetter
+		if err := json.Unmarshal([]byte(line), &letter); err != nil {
+			slog.Warn("json.Unmarshal",
+				slog.String("error", err.Error()))
+			continue
+		}
+		letters = append(letters, letter)
+	}
+	return letters, nil
+}
+
+func writeDeadLetters(letters []deadLetter) error {
+	lines := make([]string, 0, len(letters))
+	for _, letter := range letters {
+		data, err := json.Marshal(letter)
+		if err != nil {
+			return fmt.Errorf("json.Marshal: %w", err)
+		}
+		lines = append(lines, string(data))
+	}
+	return filesystem.WriteFileWithLines(filesystem.DeadLetterPath, lines, 0644)
+}
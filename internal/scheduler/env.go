@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pardnchiu/agenvoy/internal/keychain"
+)
+
+// * keychainPrefix marks a job env value read from the keychain at run time, e.g. `keychain:GITHUB_TOKEN`
+const keychainPrefix = "keychain:"
+
+// * fallbackPath is appended to PATH, launchd / systemd start the daemon with a minimal one
+var fallbackPath = []string{"/usr/local/bin", "/usr/bin", "/bin", "/usr/sbin", "/sbin", "/opt/homebrew/bin", "/opt/homebrew/sbin"}
+
+// * passthroughEnv is what scripts inherit, API keys loaded from .env stay out
+var passthroughEnv = []string{"HOME", "USER", "LOGNAME", "SHELL", "LANG", "TERM", "TMPDIR", "TZ", "GOPATH", "GOCACHE", "GOROOT"}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// * reservedSecrets are the keys of the agent itself, a job script must not get them back through a reference
+var (
+	reservedSecrets = []string{
+		"OPENAI_API_KEY",
+		"ANTHROPIC_API_KEY",
+		"GEMINI_API_KEY",
+		"NVIDIA_API_KEY",
+		"DISCORD_TOKEN",
+		"SLACK_BOT_TOKEN",
+		"SLACK_APP_TOKEN",
+		"TELEGRAM_BOT_TOKEN",
+		"SMTP_PASSWORD",
+	}
+	compatSecretRegex = regexp.MustCompile(`^COMPAT(_[A-Za-z0-9_]+)?_API_KEY$`)
+)
+
+func checkReference(name, ref string) error {
+	if !envNamePattern.MatchString(ref) {
+		return fmt.Errorf("env %s: invalid keychain reference %q", name, keychainPrefix+ref)
+	}
+	upper := strings.ToUpper(ref)
+	// * MEMORY_EMBEDDING_KEY names the keychain entry used for embeddings
+	embeddingKey := strings.ToUpper(os.Getenv("MEMORY_EMBEDDING_KEY"))
+	if slices.Contains(reservedSecrets, upper) || compatSecretRegex.MatchString(upper) || (embeddingKey != "" && upper == embeddingKey) {
+		return fmt.Errorf("env %s: %s is reserved for agenvoy", name, ref)
+	}
+	return nil
+}
+
+// * validateEnv checks names and keychain references, values are not resolved until the run
+func validateEnv(env map[string]string) error {
+	for name, value := range env {
+		if !envNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid env name %q", name)
+		}
+		if ref, ok := strings.CutPrefix(value, keychainPrefix); ok {
+			if err := checkReference(name, ref); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// * scriptEnv builds the environment of a script run: the allowlisted parent variables,
+// * LC_*, SCHEDULER_ENV_PASSTHROUGH, TZ of the job, then the job env with keychain references resolved
+func scriptEnv(job Job) ([]string, error) {
+	allowed := slices.Clone(passthroughEnv)
+	for _, name := range strings.Split(os.Getenv("SCHEDULER_ENV_PASSTHROUGH"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			allowed = append(allowed, name)
+		}
+	}
+
+	values := map[string]string{}
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if slices.Contains(allowed, name) || strings.HasPrefix(name, "LC_") {
+			values[name] = value
+		}
+	}
+	values["PATH"] = scriptPath(os.Getenv("PATH"))
+	if job.Timezone != "" {
+		values["TZ"] = job.Timezone
+	}
+
+	for name, value := range job.Env {
+		if ref, ok := strings.CutPrefix(value, keychainPrefix); ok {
+			// * jobs stored before a name was reserved are checked again at run time
+			if err := checkReference(name, ref); err != nil {
+				return nil, err
+			}
+			// * keychain.Get falls back to the environment, which holds the .env secrets
+			value = keychain.Stored(ref)
+			if value == "" {
+				return nil, fmt.Errorf("env %s: %s not found in keychain", name, ref)
+			}
+		}
+		values[name] = value
+	}
+
+	env := make([]string, 0, len(values))
+	for name, value := range values {
+		env = append(env, name+"="+value)
+	}
+	slices.Sort(env)
+	return env, nil
+}
+
+// * scriptPath keeps the parent PATH first and adds the fallback dirs it lacks
+func scriptPath(parent string) string {
+	var dirs []string
+	for _, dir := range strings.Split(parent, ":") {
+		if dir != "" && !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, dir := range fallbackPath {
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return strings.Join(dirs, ":")
+}
@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+
+	"github.com/pardnchiu/agenvoy/internal/keychain"
+	"github.com/pardnchiu/agenvoy/internal/utils"
+)
+
+// * webhook:<url> posts {"output", "time"} as JSON
+func deliverWebhook(ctx context.Context, address, output string) error {
+	if !strings.HasPrefix(address, "http://") && !strings.HasPrefix(address, "https://") {
+		return fmt.Errorf("webhook must be http(s): %s", address)
+	}
+	_, _, err := utils.POST[string](ctx, &http.Client{}, address, nil, map[string]any{
+		"output": output,
+		"time":   time.Now().Format(time.RFC3339),
+	}, "json")
+	if err != nil {
+		return fmt.Errorf("utils.POST: %w", err)
+	}
+	return nil
+}
+
+// * email:<addr> sends through SMTP_HOST, SMTP_PASSWORD may live in the keychain
+func deliverEmail(ctx context.Context, address, output string) error {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return fmt.Errorf("SMTP_HOST is not set")
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	username := os.Getenv("SMTP_USERNAME")
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = username
+	}
+	if from == "" {
+		return fmt.Errorf("SMTP_FROM is not set")
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, keychain.Get("SMTP_PASSWORD"), host)
+	}
+
+	subject := "agenvoy scheduled task"
+	if line, _, _ := strings.Cut(output, "\n"); strings.HasPrefix(line, "error:") {
+		subject += " failed"
+	}
+	message := strings.Join([]string{
+		"From: " + from,
+		"To: " + address,
+		"Subject: " + subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		output,
+	}, "\r\n")
+
+	// * net/smtp has no context, run it aside so the delivery timeout still applies
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(host, port), auth, from, []string{address}, []byte(message))
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("smtp.SendMail: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// * file:<path> appends a timestamped entry to a file under OutputsDir
+func deliverFile(_ context.Context, address, output string) error {
+	// * jobs stored before the restriction may still hold absolute paths
+	if !isLocalFile(address) {
+		return fmt.Errorf("file target must be a relative path without ..: %s", address)
+	}
+	path := filepath.Join(filesystem.OutputsDir, address)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("os.MkdirAll: %w", err)
+	}
+	// * a symlink planted in the outputs directory must not redirect the write
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY|syscall.O_NOFOLLOW, 0644)
+	if err != nil {
+		return fmt.Errorf("os.OpenFile: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "## %s\n%s\n\n", time.Now().Format("2006-01-02 15:04:05"), output); err != nil {
+		return fmt.Errorf("fmt.Fprintf: %w", err)
+	}
+	return nil
+}
+
+func deliverStdout(_ context.Context, _, output string) error {
+	_, err := fmt.Fprintf(os.Stdout, "[%s] %s\n", time.Now().Format("2006-01-02 15:04:05"), output)
+	return err
+}
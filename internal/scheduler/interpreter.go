@@ -0,0 +1,117 @@
+package scheduler
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	interpreterMu sync.RWMutex
+	// * keyed by extension (with dot) or by the program named in a shebang
+	interpreters = map[string][]string{
+		".sh":     {"sh"},
+		".bash":   {"bash"},
+		".py":     {"python3"},
+		".js":     {"node"},
+		".mjs":    {"node"},
+		".ts":     {"deno", "run", "--allow-all"},
+		".go":     {"go", "run"},
+		"sh":      {"sh"},
+		"bash":    {"bash"},
+		"zsh":     {"zsh"},
+		"python":  {"python3"},
+		"python3": {"python3"},
+		"node":    {"node"},
+		"deno":    {"deno", "run", "--allow-all"},
+	}
+)
+
+// * RegisterInterpreter sets the command for an extension (`.rb`) or shebang program (`ruby`), nil removes it
+func RegisterInterpreter(key string, command []string) {
+	interpreterMu.Lock()
+	defer interpreterMu.Unlock()
+
+	if len(command) == 0 {
+		delete(interpreters, key)
+		return
+	}
+	interpreters[key] = command
+}
+
+// * ScriptExtensions lists the extensions with a registered interpreter
+func ScriptExtensions() []string {
+	interpreterMu.RLock()
+	defer interpreterMu.RUnlock()
+	return extensions()
+}
+
+// * extensions is ScriptExtensions for callers holding interpreterMu
+func extensions() []string {
+	var exts []string
+	for key := range interpreters {
+		if strings.HasPrefix(key, ".") {
+			exts = append(exts, key)
+		}
+	}
+	slices.Sort(exts)
+	return exts
+}
+
+// * Interpreter picks the command for a script, a known shebang program wins over the extension,
+// * the path in the shebang itself is never executed
+func Interpreter(name, firstLine string) ([]string, error) {
+	interpreterMu.RLock()
+	defer interpreterMu.RUnlock()
+
+	if program := shebangProgram(firstLine); program != "" {
+		if command, ok := interpreters[program]; ok {
+			return slices.Clone(command), nil
+		}
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	if command, ok := interpreters[ext]; ok {
+		return slices.Clone(command), nil
+	}
+	return nil, fmt.Errorf("no interpreter for %q, supported: %s", name, strings.Join(extensions(), " "))
+}
+
+// * shebangProgram returns `node` for `#!/usr/bin/node` and `#!/usr/bin/env -S node --flag`
+func shebangProgram(line string) string {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), "#!")
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(rest)
+	if len(fields) > 0 && filepath.Base(fields[0]) == "env" {
+		fields = fields[1:]
+		for len(fields) > 0 && strings.HasPrefix(fields[0], "-") {
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return filepath.Base(fields[0])
+}
+
+// * scriptCommand reads the shebang of the script and returns the full argv
+func scriptCommand(scriptPath string) ([]string, error) {
+	file, err := os.Open(scriptPath)
+	if err != nil {
+		return nil, fmt.Errorf("os.Open: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	firstLine, _ := reader.ReadString('\n')
+	command, err := Interpreter(scriptPath, firstLine)
+	if err != nil {
+		return nil, err
+	}
+	return append(command, scriptPath), nil
+}
@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestInterpreter(t *testing.T) {
+	tests := []struct {
+		name, firstLine string
+		want            string
+	}{
+		{"a.sh", "#!/bin/sh", "sh"},
+		{"a.sh", "#!/usr/bin/env bash", "bash"},
+		{"a.py", "", "python3"},
+		{"a", "#!/usr/bin/env python3", "python3"},
+		{"a.js", "console.log(1)", "node"},
+		{"a.ts", "#!/usr/bin/env -S deno run --allow-net", "deno run --allow-all"},
+		{"a.go", "package main", "go run"},
+		// * unknown shebang programs fall back to the extension
+		{"a.sh", "#!/opt/custom/shell", "sh"},
+	}
+	for _, tt := range tests {
+		got, err := Interpreter(tt.name, tt.firstLine)
+		if err != nil {
+			t.Errorf("%s %q: %v", tt.name, tt.firstLine, err)
+			continue
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s %q: got %v, want %s", tt.name, tt.firstLine, got, tt.want)
+		}
+	}
+
+	if _, err := Interpreter("a.rb", "#!/usr/bin/ruby"); err == nil {
+		t.Errorf("a.rb: expected error")
+	}
+}
+
+func TestScriptEnv(t *testing.T) {
+	t.Setenv("HOME", "/home/test")
+	t.Setenv("OPENAI_API_KEY", "sk-secret")
+	t.Setenv("EXTRA_VAR", "kept")
+	t.Setenv("SCHEDULER_ENV_PASSTHROUGH", "EXTRA_VAR")
+	t.Setenv("PATH", "/custom/bin:/usr/bin")
+
+	env, err := scriptEnv(Job{Timezone: "Asia/Taipei", Env: map[string]string{"REPO": "a/b"}})
+	if err != nil {
+		t.Fatalf("scriptEnv: %v", err)
+	}
+	for _, want := range []string{"HOME=/home/test", "EXTRA_VAR=kept", "TZ=Asia/Taipei", "REPO=a/b"} {
+		if !slices.Contains(env, want) {
+			t.Errorf("missing %s in %v", want, env)
+		}
+	}
+	for _, kv := range env {
+		if strings.HasPrefix(kv, "OPENAI_API_KEY=") {
+			t.Errorf("parent secret leaked: %s", kv)
+		}
+		if path, ok := strings.CutPrefix(kv, "PATH="); ok && !strings.HasPrefix(path, "/custom/bin:/usr/bin:") {
+			t.Errorf("PATH order: %s", path)
+		}
+	}
+
+	if err := validateEnv(map[string]string{"1BAD": "x"}); err == nil {
+		t.Errorf("invalid name accepted")
+	}
+	if err := validateEnv(map[string]string{"TOKEN": "keychain:"}); err == nil {
+		t.Errorf("empty keychain reference accepted")
+	}
+	if err := validateEnv(map[string]string{"KEY": "keychain:OPENAI_API_KEY"}); err == nil {
+		t.Errorf("reserved keychain reference accepted")
+	}
+	if err := validateEnv(map[string]string{"KEY": "keychain:COMPAT_OLLAMA_API_KEY"}); err == nil {
+		t.Errorf("reserved compat reference accepted")
+	}
+	// * references resolve from the keychain only, the environment holds the .env secrets
+	t.Setenv("ENV_ONLY_SECRET", "from-env")
+	if _, err := scriptEnv(Job{Env: map[string]string{"X": "keychain:ENV_ONLY_SECRET"}}); err == nil {
+		t.Errorf("keychain reference resolved from the environment")
+	}
+}
@@ -0,0 +1,248 @@
+package scheduler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+	"github.com/pardnchiu/agenvoy/internal/utils"
+)
+
+const (
+	KindTask = "task"
+	KindCron = "cron"
+
+	// * runs kept per job, oldest dropped first
+	runHistoryMax = 20
+	runOutputMax  = 2000
+	// * runes of a prompt shown in one-line listings
+	promptPreviewMax = 40
+	// * finished one-time tasks stay listed this long for their run record
+	finishedTTL = 7 * 24 * time.Hour
+	// * scripts and prompts are killed once they run this long
+	defaultTimeout = 10 * time.Minute
+)
+
+type Run struct {
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at"`
+	DurationMS int64     `json:"duration_ms"`
+	OK         bool      `json:"ok"`
+	ExitCode   int       `json:"exit_code"` // * -1 when the script did not exit on its own
+	// * stdout of scripts, the answer of prompts
+	Output string `json:"output,omitempty"`
+	Stderr string `json:"stderr,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Job struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"` // * task or cron
+	// * RFC3339 time for tasks, cron expression for crons
+	Schedule string `json:"schedule"`
+	// * a job runs either a script or a prompt
+	Script string `json:"script,omitempty"`
+	Prompt string `json:"prompt,omitempty"`
+	// * pinned for prompts, selected by the planner when empty
+	Skill       string    `json:"skill,omitempty"`
+	Agent       string    `json:"agent,omitempty"`
+	Target      string    `json:"target,omitempty"`
+	Timeout     string    `json:"timeout,omitempty"` // * Go duration, defaultTimeout when empty
+	Description string    `json:"description,omitempty"`
+	SessionID   string    `json:"session_id,omitempty"`
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	Runs        []Run     `json:"runs,omitempty"`
+	// * IANA zone the schedule is read in, local zone when empty
+	Timezone string `json:"timezone,omitempty"`
+	// * skip, once or all for runs due while the scheduler was down, skip when empty
+	Misfire string `json:"misfire,omitempty"`
+	// * last cron occurrence handled, missed runs are counted from here
+	LastDue time.Time `json:"last_due,omitzero"`
+	// * extra env of scripts, `keychain:NAME` values are read from the keychain at run time
+	Env map[string]string `json:"env,omitempty"`
+	// * consecutive failed runs, reset by a success
+	Failures int `json:"failures,omitempty"`
+}
+
+// * Finished is true for one-time tasks that already fired or were missed
+func (j *Job) Finished() bool {
+	return j.Kind == KindTask && len(j.Runs) > 0
+}
+
+func (j *Job) LastRun() *Run {
+	if len(j.Runs) == 0 {
+		return nil
+	}
+	return &j.Runs[len(j.Runs)-1]
+}
+
+// * Next is the next due time after now, zero when the job will not run again
+func (j *Job) Next(now time.Time) time.Time {
+	if !j.Enabled || j.Finished() {
+		return time.Time{}
+	}
+	switch j.Kind {
+	case KindTask:
+		at, err := time.Parse(time.RFC3339, j.Schedule)
+		if err != nil || !at.After(now) {
+			return time.Time{}
+		}
+		return at
+	case KindCron:
+		expr, err := parseCronExpr(j.Schedule)
+		if err != nil {
+			return time.Time{}
+		}
+		return expr.Next(now.In(j.location()))
+	}
+	return time.Time{}
+}
+
+func (j *Job) location() *time.Location {
+	if j.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(j.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// * String is the one-line form used by list tools and commands
+func (j *Job) String() string {
+	var sb strings.Builder
+	sb.WriteString(j.ID)
+	sb.WriteString("  ")
+	if j.Kind == KindTask {
+		if at, err := time.Parse(time.RFC3339, j.Schedule); err == nil {
+			sb.WriteString(at.Local().Format("2006-01-02 15:04"))
+		} else {
+			sb.WriteString(j.Schedule)
+		}
+	} else {
+		sb.WriteString(j.Schedule)
+	}
+	if j.Timezone != "" {
+		sb.WriteString(" (" + j.Timezone + ")")
+	}
+	sb.WriteString("  ")
+	if j.Prompt != "" {
+		sb.WriteString(fmt.Sprintf("prompt %q", previewPrompt(j.Prompt)))
+		if j.Skill != "" {
+			sb.WriteString(" skill=" + j.Skill)
+		}
+		if j.Agent != "" {
+			sb.WriteString(" agent=" + j.Agent)
+		}
+	} else {
+		sb.WriteString(j.Script)
+	}
+	if j.Target != "" {
+		sb.WriteString(" → ")
+		sb.WriteString(j.Target)
+	}
+	if j.Kind == KindCron {
+		if last := j.LastRun(); last != nil {
+			status := "ok"
+			if !last.OK {
+				status = "failed"
+			}
+			sb.WriteString(fmt.Sprintf("  last %s %s %s", last.StartedAt.Local().Format("01-02 15:04"), status, time.Duration(last.DurationMS)*time.Millisecond))
+		}
+		if next := j.Next(time.Now()); !next.IsZero() {
+			sb.WriteString("  next " + next.Local().Format("01-02 15:04"))
+		}
+	}
+	switch {
+	case j.Finished():
+		if j.LastRun().OK {
+			sb.WriteString("  [done]")
+		} else {
+			sb.WriteString("  [failed]")
+		}
+	case !j.Enabled:
+		sb.WriteString("  [disabled]")
+	}
+	if j.Description != "" {
+		sb.WriteString("  # ")
+		sb.WriteString(j.Description)
+	}
+	return sb.String()
+}
+
+func previewPrompt(prompt string) string {
+	runes := []rune(strings.Join(strings.Fields(prompt), " "))
+	if len(runes) <= promptPreviewMax {
+		return string(runes)
+	}
+	return string(runes[:promptPreviewMax]) + "…"
+}
+
+func (j *Job) timeout() time.Duration {
+	if timeout, err := time.ParseDuration(j.Timeout); err == nil && timeout > 0 {
+		return timeout
+	}
+	return defaultTimeout
+}
+
+func (j *Job) addRun(run Run) {
+	run.Output = utils.TruncateUTF8(run.Output, runOutputMax)
+	run.Stderr = utils.TruncateUTF8(run.Stderr, runOutputMax)
+	j.Runs = append(j.Runs, run)
+	if len(j.Runs) > runHistoryMax {
+		j.Runs = j.Runs[len(j.Runs)-runHistoryMax:]
+	}
+}
+
+func newJobID() (string, error) {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("rand.Read: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func readJobs() ([]*Job, error) {
+	data, err := os.ReadFile(filesystem.JobsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	var jobs []*Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	return jobs, nil
+}
+
+// * save writes every job, caller holds s.mu
+func (s *Scheduler) save() error {
+	data, err := json.MarshalIndent(s.jobs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent: %w", err)
+	}
+	if err := filesystem.WriteFile(filesystem.JobsPath, string(data), 0644); err != nil {
+		return fmt.Errorf("filesystem.WriteFile: %w", err)
+	}
+	return nil
+}
+
+// * find returns the job and its index, caller holds s.mu
+func (s *Scheduler) find(id string) (*Job, int) {
+	for i, job := range s.jobs {
+		if job.ID == id {
+			return job, i
+		}
+	}
+	return nil, -1
+}
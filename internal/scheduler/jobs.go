@@ -0,0 +1,190 @@
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+)
+
+// * Load reads the job store, migrates line files of earlier versions and arms every enabled job
+func (s *Scheduler) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs, err := readJobs()
+	if err != nil {
+		return fmt.Errorf("readJobs: %w", err)
+	}
+	migrated, retired := migrateLines()
+	jobs = append(jobs, migrated...)
+
+	now := time.Now()
+	var kept []*Job
+	for _, job := range jobs {
+		if job.Finished() && now.Sub(job.LastRun().EndedAt) > finishedTTL {
+			continue
+		}
+		if job.Kind == KindCron && job.Enabled {
+			s.catchUp(job, now)
+		}
+		if err := s.schedule(job); err != nil {
+			slog.Warn("s.schedule",
+				slog.String("id", job.ID),
+				slog.String("error", err.Error()))
+		}
+		kept = append(kept, job)
+	}
+	s.jobs = kept
+
+	if err := s.save(); err != nil {
+		return fmt.Errorf("s.save: %w", err)
+	}
+	retireLines(retired)
+	return nil
+}
+
+func (s *Scheduler) add(job Job) (*Job, error) {
+	target, err := NormalizeTarget(job.Target)
+	if err != nil {
+		return nil, fmt.Errorf("NormalizeTarget: %w", err)
+	}
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+	job.ID = id
+	job.Target = target
+	job.Enabled = true
+	job.CreatedAt = time.Now()
+	job.Runs = nil
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.schedule(&job); err != nil {
+		return nil, err
+	}
+	s.jobs = append(s.jobs, &job)
+	if err := s.save(); err != nil {
+		s.unschedule(job.ID)
+		s.jobs = s.jobs[:len(s.jobs)-1]
+		return nil, fmt.Errorf("s.save: %w", err)
+	}
+	return &job, nil
+}
+
+// * Remove drops the job and its script unless another job still uses it
+func (s *Scheduler) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, index := s.find(id)
+	if job == nil {
+		return fmt.Errorf("not exist: %s", id)
+	}
+
+	s.unschedule(id)
+	s.jobs = slices.Delete(s.jobs, index, index+1)
+	if err := s.save(); err != nil {
+		return fmt.Errorf("s.save: %w", err)
+	}
+	if job.Script != "" && !s.scriptInUse(job.Script, id) {
+		removeScript(filepath.Join(filesystem.ScriptsDir, job.Script))
+	}
+	return nil
+}
+
+// * SetEnabled pauses or resumes a job without losing its history
+func (s *Scheduler) SetEnabled(id string, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, _ := s.find(id)
+	if job == nil {
+		return fmt.Errorf("not exist: %s", id)
+	}
+	if job.Finished() {
+		return fmt.Errorf("already finished: %s", id)
+	}
+	if job.Enabled == enabled {
+		return nil
+	}
+
+	job.Enabled = enabled
+	// * a paused stretch is not a misfire
+	if enabled && job.Kind == KindCron {
+		job.LastDue = time.Now()
+	}
+	s.unschedule(id)
+	if err := s.schedule(job); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// * List returns copies of jobs of kind, all kinds when empty
+func (s *Scheduler) List(kind string) []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []Job
+	for _, job := range s.jobs {
+		if kind != "" && job.Kind != kind {
+			continue
+		}
+		copied := *job
+		copied.Runs = slices.Clone(job.Runs)
+		result = append(result, copied)
+	}
+	return result
+}
+
+func (s *Scheduler) Get(id string) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, _ := s.find(id)
+	if job == nil {
+		return Job{}, false
+	}
+	copied := *job
+	copied.Runs = slices.Clone(job.Runs)
+	return copied, true
+}
+
+// * schedule arms an enabled job, caller holds s.mu
+func (s *Scheduler) schedule(job *Job) error {
+	if !job.Enabled || job.Finished() {
+		return nil
+	}
+	switch job.Kind {
+	case KindTask:
+		return s.setTask(job)
+	case KindCron:
+		return s.setCron(job, time.Now())
+	default:
+		return fmt.Errorf("unknown kind: %s", job.Kind)
+	}
+}
+
+// * unschedule disarms a job, caller holds s.mu
+func (s *Scheduler) unschedule(id string) {
+	if timer, ok := s.timers[id]; ok {
+		timer.Stop()
+		delete(s.timers, id)
+	}
+}
+
+// * scriptInUse reports whether a job other than id still runs script, caller holds s.mu
+func (s *Scheduler) scriptInUse(script, id string) bool {
+	for _, job := range s.jobs {
+		if job.ID != id && job.Script == script && !job.Finished() {
+			return true
+		}
+	}
+	return false
+}
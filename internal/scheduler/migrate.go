@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+)
+
+// * migrateLines converts the line files of earlier versions, returns the files to retire after save
+func migrateLines() ([]*Job, []string) {
+	var jobs []*Job
+	var retired []string
+	for _, legacy := range []struct {
+		path  string
+		parse func(string) (*Job, error)
+	}{
+		{filesystem.TasksPath, parseTaskLine},
+		{filesystem.CronsPath, parseCronLine},
+	} {
+		lines, err := filesystem.ReadFile(legacy.path)
+		if err != nil {
+			slog.Warn("filesystem.ReadFile",
+				slog.String("path", legacy.path),
+				slog.String("error", err.Error()))
+			continue
+		}
+		if lines == nil {
+			continue
+		}
+
+		for _, line := range lines {
+			trim := strings.TrimSpace(line)
+			if trim == "" || strings.HasPrefix(trim, "#") {
+				continue
+			}
+			job, err := legacy.parse(trim)
+			if err != nil {
+				slog.Warn("skip legacy line",
+					slog.String("line", trim),
+					slog.String("error", err.Error()))
+				continue
+			}
+			id, err := newJobID()
+			if err != nil {
+				slog.Warn("newJobID",
+					slog.String("error", err.Error()))
+				continue
+			}
+			job.ID = id
+			job.Enabled = true
+			job.CreatedAt = time.Now()
+			jobs = append(jobs, job)
+		}
+		retired = append(retired, legacy.path)
+	}
+	return jobs, retired
+}
+
+// * retireLines keeps the old files aside instead of deleting them
+func retireLines(paths []string) {
+	for _, path := range paths {
+		if err := os.Rename(path, path+".migrated"); err != nil {
+			slog.Warn("os.Rename",
+				slog.String("path", path),
+				slog.String("error", err.Error()))
+		}
+	}
+}
+
+// * `{RFC3339} {script} [channel]`
+func parseTaskLine(line string) (*Job, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("at least 2 fields `{time} {script}`")
+	}
+
+	if _, err := time.Parse(time.RFC3339, fields[0]); err != nil {
+		return nil, fmt.Errorf("not RFC3339: %w", err)
+	}
+
+	job := &Job{
+		Kind:     KindTask,
+		Schedule: fields[0],
+		Script:   fields[1],
+	}
+	if len(fields) >= 3 {
+		target, err := NormalizeTarget(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("NormalizeTarget: %w", err)
+		}
+		job.Target = target
+	}
+	return job, nil
+}
+
+// * `{min} {hour} {dom} {mon} {dow} {script} [channel]`
+func parseCronLine(line string) (*Job, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 6 {
+		return nil, fmt.Errorf("at least 6 fields `{min} {hour} {dom} {mon} {dow} {script}`")
+	}
+
+	job := &Job{
+		Kind:     KindCron,
+		Schedule: strings.Join(fields[:5], " "),
+		Script:   fields[5],
+	}
+	if len(fields) >= 7 {
+		target, err := NormalizeTarget(fields[6])
+		if err != nil {
+			return nil, fmt.Errorf("NormalizeTarget: %w", err)
+		}
+		job.Target = target
+	}
+	return job, nil
+}
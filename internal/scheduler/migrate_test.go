@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+)
+
+func newTestScheduler(t *testing.T) *Scheduler {
+	t.Helper()
+	dir := t.TempDir()
+	filesystem.TasksPath = filepath.Join(dir, "tasks")
+	filesystem.CronsPath = filepath.Join(dir, "crons")
+	filesystem.JobsPath = filepath.Join(dir, "jobs.json")
+	filesystem.ScriptsDir = filepath.Join(dir, "scripts")
+
+	return &Scheduler{
+		timers: map[string]*time.Timer{},
+		active: map[string]bool{},
+		stop:   make(chan struct{}),
+	}
+}
+
+func TestLoadMigratesLines(t *testing.T) {
+	s := newTestScheduler(t)
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	os.WriteFile(filesystem.TasksPath, []byte(future+" a.sh 123\n"+past+" b.sh\n"), 0644)
+	os.WriteFile(filesystem.CronsPath, []byte("# comment\n*/5 * * * * c.sh slack:C1\n*/5 * * * * c.sh slack:C1\n"), 0644)
+
+	if err := s.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	defer func() {
+		for _, timer := range s.timers {
+			timer.Stop()
+		}
+	}()
+
+	jobs := s.List("")
+	if len(jobs) != 4 {
+		t.Fatalf("got %d jobs, want 4", len(jobs))
+	}
+	if jobs[0].Target != "discord:123" {
+		t.Errorf("bare channel not normalized: %q", jobs[0].Target)
+	}
+	if !jobs[1].Finished() || jobs[1].LastRun().OK {
+		t.Errorf("past task should be recorded as missed")
+	}
+	// * identical lines used to collide, each is its own job with its own timer now
+	if jobs[2].ID == jobs[3].ID || len(s.timers) != 3 {
+		t.Errorf("duplicate crons not kept apart: %d timers", len(s.timers))
+	}
+	if _, err := os.Stat(filesystem.CronsPath + ".migrated"); err != nil {
+		t.Errorf("legacy file not retired: %v", err)
+	}
+
+	// * second load reads the store only
+	data, err := os.ReadFile(filesystem.JobsPath)
+	if err != nil {
+		t.Fatalf("read store: %v", err)
+	}
+	s2 := newTestScheduler(t)
+	os.WriteFile(filesystem.JobsPath, data, 0644)
+	if err := s2.Load(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	defer func() {
+		for _, timer := range s2.timers {
+			timer.Stop()
+		}
+	}()
+	if got := len(s2.List("")); got != 4 {
+		t.Errorf("reload got %d jobs, want 4", got)
+	}
+
+	if err := s2.Remove(jobs[2].ID); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, ok := s2.Get(jobs[2].ID); ok {
+		t.Errorf("job still present after Remove")
+	}
+}
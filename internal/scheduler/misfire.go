@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// * misfire policies for runs that fell due while the scheduler was down
+const (
+	MisfireSkip = "skip" // * record the miss, wait for the next occurrence
+	MisfireOnce = "once" // * run once for all missed occurrences
+	MisfireAll  = "all"  // * run every missed occurrence, up to misfireRunMax
+)
+
+const (
+	// * misfireScanMax bounds the count of missed occurrences, e.g. `* * * * *` down for a month
+	misfireScanMax = 10000
+	misfireRunMax  = 24
+)
+
+func validMisfire(policy string) bool {
+	switch policy {
+	case "", MisfireSkip, MisfireOnce, MisfireAll:
+		return true
+	}
+	return false
+}
+
+func (j *Job) misfire() string {
+	if j.Misfire == "" {
+		return MisfireSkip
+	}
+	return j.Misfire
+}
+
+// * missedSince returns the occurrences of a cron in (since, now], the newest misfireRunMax of them and the total
+func (j *Job) missedSince(since, now time.Time) ([]time.Time, int) {
+	expr, err := parseCronExpr(j.Schedule)
+	if err != nil || since.IsZero() {
+		return nil, 0
+	}
+	var dues []time.Time
+	total := 0
+	for t := expr.Next(since.In(j.location())); !t.IsZero() && !t.After(now) && total < misfireScanMax; t = expr.Next(t) {
+		total++
+		dues = append(dues, t)
+		if len(dues) > misfireRunMax {
+			dues = dues[1:]
+		}
+	}
+	return dues, total
+}
+
+// * catchUp applies the misfire policy of a cron at startup, caller holds s.mu
+func (s *Scheduler) catchUp(job *Job, now time.Time) {
+	since := job.LastDue
+	if since.IsZero() {
+		// * stores before last_due existed
+		since = job.CreatedAt
+		if last := job.LastRun(); last != nil && last.StartedAt.After(since) {
+			since = last.StartedAt
+		}
+	}
+	dues, total := job.missedSince(since, now)
+	if total == 0 {
+		return
+	}
+	last := dues[len(dues)-1]
+	slog.Warn("cron missed while scheduler was down",
+		slog.String("id", job.ID),
+		slog.Int("missed", total),
+		slog.String("misfire", job.misfire()))
+
+	id := job.ID
+	switch job.misfire() {
+	case MisfireOnce:
+		go s.runCron(id, last)
+	case MisfireAll:
+		go func() {
+			for _, due := range dues {
+				s.runCron(id, due)
+			}
+		}()
+	default:
+		job.LastDue = last
+		job.addRun(Run{
+			StartedAt: now,
+			EndedAt:   now,
+			ExitCode:  -1,
+			Error:     fmt.Sprintf("missed: %d run(s) while the scheduler was not running, last due %s", total, last.Format("2006-01-02 15:04 MST")),
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// * PromptRunner runs the prompt of a job through an agent and returns the final answer
+type PromptRunner func(ctx context.Context, job Job) (string, error)
+
+var (
+	promptMu     sync.RWMutex
+	promptRunner PromptRunner
+)
+
+// * RegisterPromptRunner is set by the process owning the agents, prompt jobs fail without it
+func RegisterPromptRunner(runner PromptRunner) {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+	promptRunner = runner
+}
+
+func runPrompt(ctx context.Context, job Job) (string, error) {
+	promptMu.RLock()
+	runner := promptRunner
+	promptMu.RUnlock()
+
+	if runner == nil {
+		return "", fmt.Errorf("no prompt runner registered")
+	}
+	return runner(ctx, job)
+}
+
+// * validateJob checks what a job runs, caller fills kind and schedule
+func validateJob(job Job) error {
+	switch {
+	case job.Script == "" && job.Prompt == "":
+		return fmt.Errorf("script or prompt is required")
+	case job.Script != "" && job.Prompt != "":
+		return fmt.Errorf("script and prompt are exclusive")
+	case job.Script != "" && (job.Skill != "" || job.Agent != ""):
+		return fmt.Errorf("skill and agent only apply to prompts")
+	case job.Prompt != "" && len(job.Env) > 0:
+		return fmt.Errorf("env only applies to scripts")
+	}
+	if err := validateEnv(job.Env); err != nil {
+		return err
+	}
+	if !validMisfire(job.Misfire) {
+		return fmt.Errorf("misfire must be skip, once or all")
+	}
+	if job.Timezone != "" {
+		if _, err := time.LoadLocation(job.Timezone); err != nil {
+			return fmt.Errorf("time.LoadLocation: %w", err)
+		}
+	}
+	if job.Timeout != "" {
+		timeout, err := time.ParseDuration(job.Timeout)
+		if err != nil {
+			return fmt.Errorf("time.ParseDuration: %w", err)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("timeout must be positive")
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"log/slog"
@@ -9,30 +10,26 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
-	goCron "github.com/pardnchiu/go-scheduler"
-
 	"github.com/pardnchiu/agenvoy/internal/filesystem"
 )
 
-type cronEngine interface {
-	Start()
-	Stop() context.Context
-	Add(spec string, action any, arg ...any) (int64, error)
-	Remove(id int64)
-}
-
 type Scheduler struct {
-	mu          sync.Mutex
-	timers      map[string]*time.Timer
-	tasks       []taskItem
-	crons       []cronItem
-	cron        cronEngine
-	OnCompleted OnCompletedFn
+	mu   sync.Mutex
+	jobs []*Job
+	// * armed jobs by job ID, crons re-arm on every fire
+	timers map[string]*time.Timer
+	stop   chan struct{}
+	// * jobs in flight, waited on by Stop
+	running sync.WaitGroup
+	// * crons currently running, by job ID
+	active map[string]bool
 }
 
-type OnCompletedFn func(channelID, output string)
+// * shutdownTimeout bounds how long Stop waits for running jobs
+const shutdownTimeout = 30 * time.Second
 
 var (
 	scheduler *Scheduler
@@ -41,22 +38,17 @@ var (
 )
 
 func New() error {
-	var initErr error
 	once.Do(func() {
-		c, err := goCron.New(goCron.Config{})
-		if err != nil {
-			initErr = err
-			return
-		}
-		c.Start()
 		mu.Lock()
 		scheduler = &Scheduler{
 			timers: make(map[string]*time.Timer),
-			cron:   c,
+			active: make(map[string]bool),
+			stop:   make(chan struct{}),
 		}
+		go scheduler.retryLoop()
 		mu.Unlock()
 	})
-	return initErr
+	return nil
 }
 
 func Get() *Scheduler {
@@ -78,63 +70,121 @@ func Stop() {
 	for _, timer := range s.timers {
 		timer.Stop()
 	}
+	// * under s.mu so a firing cron cannot re-arm after this
+	close(s.stop)
 	s.mu.Unlock()
 
-	s.cron.Stop()
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+		slog.Warn("scheduler stopped with jobs still running")
+	}
 }
 
-func runScript(caller, scriptPath string) string {
-	var cmd *exec.Cmd
-	switch strings.ToLower(filepath.Ext(scriptPath)) {
-	case ".py":
-		cmd = exec.Command("python3", scriptPath)
+// * stopped reports whether Stop was called, caller holds s.mu
+func (s *Scheduler) stopped() bool {
+	select {
+	case <-s.stop:
+		return true
 	default:
-		cmd = exec.Command("sh", scriptPath)
+		return false
 	}
-	cmd.Env = append(os.Environ(),
-		"PATH=/usr/local/bin:/usr/bin:/bin:/usr/sbin:/sbin:/opt/homebrew/bin:/opt/homebrew/sbin",
-	)
+}
 
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		slog.Error(caller,
-			slog.String("script", filepath.Base(scriptPath)),
-			slog.String("error", err.Error()))
-		return fmt.Sprintf("error: %s", err.Error())
+// * retryLoop redelivers dead letters, e.g. once a frontend that was down registers again
+func (s *Scheduler) retryLoop() {
+	ticker := time.NewTicker(deadLetterInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			RetryDeadLetters()
+		}
 	}
-	return strings.TrimSpace(string(out))
 }
 
-func appendLine(path, line string) error {
-	lines, err := filesystem.ReadFile(path)
-	if err != nil {
-		return err
+// * execute runs the script from ScriptsDir or the prompt of job within its timeout,
+// * the delivered output is "error: ..." on failure
+func execute(caller string, job Job) (Run, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), job.timeout())
+	defer cancel()
+
+	run := Run{StartedAt: time.Now()}
+	if job.Prompt != "" {
+		answer, err := runPrompt(ctx, job)
+		run.Output = answer
+		if err != nil {
+			run.Error = err.Error()
+		}
+	} else {
+		run = runScript(ctx, filepath.Join(filesystem.ScriptsDir, job.Script), job)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		run.Error = fmt.Sprintf("timeout after %s", job.timeout())
+	}
+	run.EndedAt = time.Now()
+	run.DurationMS = run.EndedAt.Sub(run.StartedAt).Milliseconds()
+	run.OK = run.Error == ""
+
+	if !run.OK {
+		slog.Error(caller,
+			slog.String("job", job.ID),
+			slog.String("error", run.Error))
+		output := fmt.Sprintf("error: %s", run.Error)
+		if stderr := strings.TrimSpace(run.Stderr); stderr != "" {
+			output += "\n" + stderr
+		}
+		return run, output
 	}
-	return filesystem.WriteFileWithLines(path, append(lines, line), 0644)
+	return run, run.Output
 }
 
-func removeLine(path, target string) {
-	lines, err := filesystem.ReadFile(path)
+// * runScript keeps stdout and stderr apart, ctx kills the whole process group
+func runScript(ctx context.Context, scriptPath string, job Job) Run {
+	command, err := scriptCommand(scriptPath)
 	if err != nil {
-		return
+		return Run{StartedAt: time.Now(), ExitCode: -1, Error: err.Error()}
 	}
-	var kept []string
-	for _, l := range lines {
-		if strings.TrimSpace(l) != target {
-			kept = append(kept, l)
-		}
+	env, err := scriptEnv(job)
+	if err != nil {
+		return Run{StartedAt: time.Now(), ExitCode: -1, Error: err.Error()}
 	}
-	filesystem.WriteFileWithLines(path, kept, 0644)
-}
 
-func buildLine(parts ...string) string {
-	var keep []string
-	for _, part := range parts {
-		if part != "" {
-			keep = append(keep, part)
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Env = env
+	// * children spawned by the script share its group and are killed with it
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	// * a grandchild holding the pipes open cannot block Wait forever
+	cmd.WaitDelay = 5 * time.Second
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	run := Run{StartedAt: time.Now()}
+	err = cmd.Run()
+	run.Output = strings.TrimSpace(stdout.String())
+	run.Stderr = strings.TrimSpace(stderr.String())
+	if cmd.ProcessState != nil {
+		run.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if err != nil {
+		run.Error = err.Error()
+		if run.ExitCode == 0 {
+			run.ExitCode = -1
 		}
 	}
-	return strings.Join(keep, " ")
+	return run
 }
 
 func removeScript(scriptPath string) {
@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+)
+
+func TestExecuteTimeoutKillsGroup(t *testing.T) {
+	filesystem.ScriptsDir = t.TempDir()
+	// * the background child keeps stdout open, only a group kill ends it early
+	script := "echo started\nsleep 30 &\nwait\n"
+	os.WriteFile(filepath.Join(filesystem.ScriptsDir, "slow.sh"), []byte(script), 0644)
+
+	start := time.Now()
+	run, output := execute("test", Job{ID: "slow", Script: "slow.sh", Timeout: "300ms"})
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("took %s, process group not killed", elapsed)
+	}
+	if run.OK || run.ExitCode != -1 {
+		t.Errorf("got ok=%v exit=%d, want failed run with exit -1", run.OK, run.ExitCode)
+	}
+	if !strings.HasPrefix(output, "error: timeout after 300ms") {
+		t.Errorf("unexpected output %q", output)
+	}
+	if run.Output != "started" {
+		t.Errorf("stdout before the kill not kept: %q", run.Output)
+	}
+}
+
+func TestExecuteSeparatesStreams(t *testing.T) {
+	filesystem.ScriptsDir = t.TempDir()
+	os.WriteFile(filepath.Join(filesystem.ScriptsDir, "fail.sh"), []byte("echo out\necho err >&2\nexit 3\n"), 0644)
+
+	run, output := execute("test", Job{ID: "fail", Script: "fail.sh"})
+	if run.OK || run.ExitCode != 3 {
+		t.Errorf("got ok=%v exit=%d, want exit 3", run.OK, run.ExitCode)
+	}
+	if run.Output != "out" || run.Stderr != "err" {
+		t.Errorf("streams mixed: stdout %q stderr %q", run.Output, run.Stderr)
+	}
+	if !strings.Contains(output, "err") {
+		t.Errorf("stderr missing from delivered output %q", output)
+	}
+}
@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// * target kinds handled in this package, chat frontends register the rest
+const (
+	TargetDiscord  = "discord"
+	TargetSlack    = "slack"
+	TargetTelegram = "telegram"
+	TargetWebhook  = "webhook"
+	TargetEmail    = "email"
+	TargetFile     = "file"
+	TargetStdout   = "stdout"
+)
+
+var targetKinds = []string{
+	TargetDiscord,
+	TargetSlack,
+	TargetTelegram,
+	TargetWebhook,
+	TargetEmail,
+	TargetFile,
+	TargetStdout,
+}
+
+// * ParseTarget splits `kind:address`, bare IDs are discord channels stored before targets existed
+func ParseTarget(target string) (kind, address string, err error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", "", fmt.Errorf("empty target")
+	}
+	if target == TargetStdout {
+		return TargetStdout, "", nil
+	}
+	if strings.ContainsAny(target, " \t\n") {
+		return "", "", fmt.Errorf("target must not contain spaces: %q", target)
+	}
+
+	kind, address, ok := strings.Cut(target, ":")
+	if !ok {
+		return TargetDiscord, target, nil
+	}
+	if !slices.Contains(targetKinds, kind) && getHandler(kind) == nil {
+		return "", "", fmt.Errorf("unknown target kind: %q", kind)
+	}
+	if address == "" {
+		return "", "", fmt.Errorf("missing address: %q", target)
+	}
+	// * file targets stay under the scheduler outputs directory
+	if kind == TargetFile && !isLocalFile(address) {
+		return "", "", fmt.Errorf("file target must be a relative path without ..: %q", target)
+	}
+	return kind, address, nil
+}
+
+// * isLocalFile reports a file target that resolves under OutputsDir, ~ is refused rather than read as a directory name
+func isLocalFile(address string) bool {
+	return filepath.IsLocal(address) && !strings.HasPrefix(address, "~")
+}
+
+// * NormalizeTarget returns the `kind:address` form, empty stays empty
+func NormalizeTarget(target string) (string, error) {
+	if strings.TrimSpace(target) == "" {
+		return "", nil
+	}
+	kind, address, err := ParseTarget(target)
+	if err != nil {
+		return "", err
+	}
+	if kind == TargetStdout {
+		return TargetStdout, nil
+	}
+	return kind + ":" + address, nil
+}
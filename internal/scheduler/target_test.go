@@ -0,0 +1,38 @@
+package scheduler
+
+import "testing"
+
+func TestNormalizeTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		want    string
+		wantErr bool
+	}{
+		{"empty stays empty", "", "", false},
+		{"bare id is discord", "1234567890", "discord:1234567890", false},
+		{"slack prefix", "slack:C0123", "slack:C0123", false},
+		{"telegram negative chat", "telegram:-1001234", "telegram:-1001234", false},
+		{"webhook keeps url", "webhook:https://example.com/hook?a=1", "webhook:https://example.com/hook?a=1", false},
+		{"email", "email:me@example.com", "email:me@example.com", false},
+		{"file", "file:reports/out.log", "file:reports/out.log", false},
+		{"absolute file rejected", "file:/tmp/out.log", "", true},
+		{"home file rejected", "file:~/.bashrc", "", true},
+		{"escaping file rejected", "file:../jobs.json", "", true},
+		{"stdout", "stdout", "stdout", false},
+		{"unknown kind", "https://example.com", "", true},
+		{"missing address", "discord:", "", true},
+		{"spaces rejected", "file:a b.log", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeTarget(tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
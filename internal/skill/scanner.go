@@ -210,3 +210,25 @@ func (s *SkillScanner) List() []string {
 	}
 	return names
 }
+
+// * Filter returns a scanner limited to names, without rescanning
+func (s *SkillScanner) Filter(names []string) *SkillScanner {
+	list := &SkillList{
+		ByName: make(map[string]*Skill),
+		ByPath: make(map[string]*Skill),
+		Paths:  s.paths,
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, name := range names {
+		if skill, ok := s.Skills.ByName[strings.TrimSpace(name)]; ok {
+			list.ByName[skill.Name] = skill
+			list.ByPath[skill.AbsPath] = skill
+		}
+	}
+	return &SkillScanner{
+		paths:  s.paths,
+		Skills: list,
+	}
+}
@@ -0,0 +1,240 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/utils"
+)
+
+const apiBase = "https://slack.com/api/"
+
+type apiResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+func (r apiResult) err() error {
+	if r.OK {
+		return nil
+	}
+	return fmt.Errorf("slack: %s", r.Error)
+}
+
+type slackResult interface {
+	err() error
+}
+
+type Message struct {
+	Type     string `json:"type"`
+	Subtype  string `json:"subtype,omitempty"`
+	User     string `json:"user,omitempty"`
+	BotID    string `json:"bot_id,omitempty"`
+	Text     string `json:"text"`
+	TS       string `json:"ts"`
+	ThreadTS string `json:"thread_ts,omitempty"`
+}
+
+type client struct {
+	botToken   string
+	appToken   string
+	httpClient *http.Client
+}
+
+// * call posts form encoded params, every web API method accepts this encoding
+func call[T slackResult](ctx context.Context, c *client, token, method string, params map[string]any) (T, error) {
+	result, _, err := utils.POST[T](ctx, c.httpClient, apiBase+method, map[string]string{
+		"Authorization": "Bearer " + token,
+	}, params, "form")
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", method, err)
+	}
+	if err := result.err(); err != nil {
+		return result, fmt.Errorf("%s: %w", method, err)
+	}
+	return result, nil
+}
+
+type authResult struct {
+	apiResult
+	UserID string `json:"user_id"`
+	TeamID string `json:"team_id"`
+	BotID  string `json:"bot_id"`
+}
+
+func (c *client) authTest(ctx context.Context) (authResult, error) {
+	return call[authResult](ctx, c, c.botToken, "auth.test", nil)
+}
+
+type connectionResult struct {
+	apiResult
+	URL string `json:"url"`
+}
+
+func (c *client) openConnection(ctx context.Context) (string, error) {
+	result, err := call[connectionResult](ctx, c, c.appToken, "apps.connections.open", nil)
+	return result.URL, err
+}
+
+type postResult struct {
+	apiResult
+	TS string `json:"ts"`
+}
+
+// * postMessage replies in thread when threadTS is set, blocks is optional
+func (c *client) postMessage(ctx context.Context, channelID, threadTS, text string, blocks []map[string]any) (string, error) {
+	params := map[string]any{
+		"channel": channelID,
+		"text":    text,
+	}
+	if threadTS != "" {
+		params["thread_ts"] = threadTS
+	}
+	if blocks != nil {
+		data, err := json.Marshal(blocks)
+		if err != nil {
+			return "", fmt.Errorf("json.Marshal: %w", err)
+		}
+		params["blocks"] = string(data)
+	}
+	result, err := call[postResult](ctx, c, c.botToken, "chat.postMessage", params)
+	return result.TS, err
+}
+
+func (c *client) postEphemeral(ctx context.Context, channelID, userID, text string) error {
+	_, err := call[apiResult](ctx, c, c.botToken, "chat.postEphemeral", map[string]any{
+		"channel": channelID,
+		"user":    userID,
+		"text":    text,
+	})
+	return err
+}
+
+// * updateMessage replaces text, nil blocks drops any buttons
+func (c *client) updateMessage(ctx context.Context, channelID, ts, text string, blocks []map[string]any) error {
+	if blocks == nil {
+		blocks = []map[string]any{}
+	}
+	data, err := json.Marshal(blocks)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+	_, err = call[apiResult](ctx, c, c.botToken, "chat.update", map[string]any{
+		"channel": channelID,
+		"ts":      ts,
+		"text":    text,
+		"blocks":  string(data),
+	})
+	return err
+}
+
+func (c *client) addReaction(ctx context.Context, channelID, ts, name string) error {
+	_, err := call[apiResult](ctx, c, c.botToken, "reactions.add", map[string]any{
+		"channel":   channelID,
+		"timestamp": ts,
+		"name":      name,
+	})
+	return err
+}
+
+func (c *client) removeReaction(ctx context.Context, channelID, ts, name string) error {
+	_, err := call[apiResult](ctx, c, c.botToken, "reactions.remove", map[string]any{
+		"channel":   channelID,
+		"timestamp": ts,
+		"name":      name,
+	})
+	return err
+}
+
+type messagesResult struct {
+	apiResult
+	Messages []Message `json:"messages"`
+}
+
+// * replies returns thread messages before latest, oldest first
+func (c *client) replies(ctx context.Context, channelID, threadTS, latest string, limit int) ([]Message, error) {
+	result, err := call[messagesResult](ctx, c, c.botToken, "conversations.replies", map[string]any{
+		"channel":   channelID,
+		"ts":        threadTS,
+		"latest":    latest,
+		"limit":     limit,
+		"inclusive": false,
+	})
+	return result.Messages, err
+}
+
+// * history returns channel messages before latest, newest first
+func (c *client) history(ctx context.Context, channelID, latest string, limit int) ([]Message, error) {
+	result, err := call[messagesResult](ctx, c, c.botToken, "conversations.history", map[string]any{
+		"channel":   channelID,
+		"latest":    latest,
+		"limit":     limit,
+		"inclusive": false,
+	})
+	return result.Messages, err
+}
+
+type uploadURLResult struct {
+	apiResult
+	UploadURL string `json:"upload_url"`
+	FileID    string `json:"file_id"`
+}
+
+// * uploadFile follows the external upload flow, files.upload is retired
+func (c *client) uploadFile(ctx context.Context, channelID, threadTS, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("os.ReadFile: %w", err)
+	}
+	name := filepath.Base(path)
+
+	target, err := call[uploadURLResult](ctx, c, c.botToken, "files.getUploadURLExternal", map[string]any{
+		"filename": name,
+		"length":   len(data),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.UploadURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("httpClient.Do: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upload %s: %s", name, resp.Status)
+	}
+
+	files, err := json.Marshal([]map[string]string{{"id": target.FileID, "title": name}})
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+	params := map[string]any{
+		"files":      string(files),
+		"channel_id": channelID,
+	}
+	if threadTS != "" {
+		params["thread_ts"] = threadTS
+	}
+	_, err = call[apiResult](ctx, c, c.botToken, "files.completeUploadExternal", params)
+	return err
+}
+
+func newClient(botToken, appToken string) *client {
+	return &client{
+		botToken:   botToken,
+		appToken:   appToken,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
@@ -0,0 +1,131 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/pardnchiu/agenvoy/internal/frontend"
+	"github.com/pardnchiu/agenvoy/internal/utils"
+)
+
+const (
+	approveAction = "tool_approve"
+	denyAction    = "tool_deny"
+	// * section text is limited to 3000 characters
+	maxArgsPreview = 2500
+)
+
+type blockActions struct {
+	Type string `json:"type"`
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+	Message struct {
+		TS   string `json:"ts"`
+		Text string `json:"text"`
+	} `json:"message"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+// * requestApproval posts Approve/Deny buttons and blocks until decided, timed out or cancelled
+func (b *Bot) requestApproval(ctx context.Context, message *receiveMessage, toolName, toolArgs string) bool {
+	a, err := frontend.NewApproval(message.UserID)
+	if err != nil {
+		return false
+	}
+	defer a.Close()
+
+	base := fmt.Sprintf("🔐 <@%s> `%s` needs approval\n```%s```",
+		message.UserID, toolName, formatArgs(toolArgs))
+	ts, err := b.client.postMessage(ctx, message.ChannelID, message.ThreadTS, base, []map[string]any{
+		sectionBlock(base),
+		{
+			"type": "context",
+			"elements": []map[string]any{
+				{"type": "mrkdwn", "text": fmt.Sprintf("denied automatically in %s", frontend.ApproveTimeout)},
+			},
+		},
+		{
+			"type": "actions",
+			"elements": []map[string]any{
+				button("Approve", "primary", approveAction, a.Token),
+				button("Deny", "danger", denyAction, a.Token),
+			},
+		},
+	})
+	if err != nil {
+		slog.Warn("failed to send approval",
+			slog.String("error", err.Error()))
+		return false
+	}
+
+	approved, status := a.Wait(ctx)
+	if status != "" {
+		b.closeApproval(message.ChannelID, ts, base+"\n"+status)
+	}
+	return approved
+}
+
+// * handleInteractive answers Approve/Deny clicks
+func (b *Bot) handleInteractive(ctx context.Context, payload json.RawMessage) {
+	var action blockActions
+	if err := json.Unmarshal(payload, &action); err != nil || action.Type != "block_actions" || len(action.Actions) == 0 {
+		return
+	}
+	actionID := action.Actions[0].ActionID
+	if actionID != approveAction && actionID != denyAction {
+		return
+	}
+
+	approved := actionID == approveAction
+	// * SLACK_ADMIN_USERS may decide for anyone
+	admin := frontend.IsAdminUser("SLACK_ADMIN_USERS", action.User.ID)
+	if notice := frontend.Decide(action.Actions[0].Value, action.User.ID, admin, approved); notice != "" {
+		b.client.postEphemeral(ctx, action.Channel.ID, action.User.ID, notice)
+		return
+	}
+
+	status := fmt.Sprintf("❌ Denied by <@%s>", action.User.ID)
+	if approved {
+		status = fmt.Sprintf("✅ Approved by <@%s>", action.User.ID)
+	}
+	b.closeApproval(action.Channel.ID, action.Message.TS, fmt.Sprintf("%s\n%s", action.Message.Text, status))
+}
+
+func (b *Bot) closeApproval(channelID, ts, text string) {
+	if err := b.client.updateMessage(context.Background(), channelID, ts, text, []map[string]any{sectionBlock(text)}); err != nil {
+		slog.Warn("client.updateMessage",
+			slog.String("error", err.Error()))
+	}
+}
+
+func sectionBlock(text string) map[string]any {
+	return map[string]any{
+		"type": "section",
+		"text": map[string]any{"type": "mrkdwn", "text": text},
+	}
+}
+
+func button(label, style, actionID, value string) map[string]any {
+	return map[string]any{
+		"type":      "button",
+		"text":      map[string]any{"type": "plain_text", "text": label},
+		"style":     style,
+		"action_id": actionID,
+		"value":     value,
+	}
+}
+
+func formatArgs(args string) string {
+	args = strings.ReplaceAll(frontend.IndentArgs(args), "```", "'''")
+	return utils.TruncateUTF8(args, maxArgsPreview)
+}
@@ -0,0 +1,141 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/pardnchiu/agenvoy/internal/access"
+	"github.com/pardnchiu/agenvoy/internal/frontend"
+)
+
+var mentionRegex = regexp.MustCompile(`<@[UW][A-Z0-9]+>`)
+
+type eventCallback struct {
+	TeamID string `json:"team_id"`
+	Event  event  `json:"event"`
+}
+
+type event struct {
+	Type        string `json:"type"` // * app_mention or message
+	Subtype     string `json:"subtype,omitempty"`
+	User        string `json:"user"`
+	BotID       string `json:"bot_id,omitempty"`
+	Text        string `json:"text"`
+	Channel     string `json:"channel"`
+	ChannelType string `json:"channel_type,omitempty"`
+	TS          string `json:"ts"`
+	ThreadTS    string `json:"thread_ts,omitempty"`
+	Files       []file `json:"files,omitempty"`
+}
+
+type file struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Mimetype   string `json:"mimetype"`
+	URLPrivate string `json:"url_private"`
+}
+
+// * receiveMessage is one incoming user turn after filtering
+type receiveMessage struct {
+	TeamID    string
+	ChannelID string
+	UserID    string
+	Content   string
+	TS        string
+	// * thread the reply goes to, empty replies in the DM itself
+	ThreadTS string
+	IsDM     bool
+	Files    []file
+	Policy   access.Policy
+}
+
+func (b *Bot) handleEvent(ctx context.Context, payload json.RawMessage) {
+	var callback eventCallback
+	if err := json.Unmarshal(payload, &callback); err != nil {
+		slog.Warn("json.Unmarshal",
+			slog.String("error", err.Error()))
+		return
+	}
+	ev := callback.Event
+
+	// * skip bots, edits and joins, keep plain messages and file shares
+	if ev.BotID != "" || ev.User == "" || ev.User == b.userID {
+		return
+	}
+	if ev.Subtype != "" && ev.Subtype != "file_share" {
+		return
+	}
+
+	message := &receiveMessage{
+		TeamID:    callback.TeamID,
+		ChannelID: ev.Channel,
+		UserID:    ev.User,
+		Content:   strings.TrimSpace(mentionRegex.ReplaceAllString(ev.Text, "")),
+		TS:        ev.TS,
+		Files:     ev.Files,
+	}
+	switch {
+	// * channel mentions always answer in a thread
+	case ev.Type == "app_mention":
+		message.ThreadTS = ev.ThreadTS
+		if message.ThreadTS == "" {
+			message.ThreadTS = ev.TS
+		}
+	case ev.Type == "message" && ev.ChannelType == "im":
+		message.IsDM = true
+		message.ThreadTS = ev.ThreadTS
+	default:
+		return
+	}
+
+	if message.Content == "" && len(message.Files) == 0 {
+		return
+	}
+
+	slog.Info("slack message received",
+		slog.String("user", message.UserID),
+		slog.String("content", message.Content),
+		slog.Int("files", len(message.Files)),
+		slog.Bool("is_dm", message.IsDM))
+
+	if !b.checkAccess(ctx, message) {
+		return
+	}
+
+	if b.PlannerAgent == nil {
+		return
+	}
+	b.client.addReaction(ctx, message.ChannelID, message.TS, "eyes")
+	if err := b.run(ctx, message); err != nil {
+		slog.Warn("run",
+			slog.String("error", err.Error()))
+	}
+	b.client.removeReaction(ctx, message.ChannelID, message.TS, "eyes")
+}
+
+// * checkAccess resolves the policy into message, replies politely and returns false when refused
+func (b *Bot) checkAccess(ctx context.Context, message *receiveMessage) bool {
+	policy, notice, ok := frontend.CheckAccess("slack", access.Principal{
+		ChannelID: message.ChannelID,
+		UserID:    message.UserID,
+		Direct:    message.IsDM,
+	})
+	if !ok {
+		if notice != "" {
+			b.notice(ctx, message, notice)
+		}
+		return false
+	}
+	message.Policy = policy
+	return true
+}
+
+func (b *Bot) notice(ctx context.Context, message *receiveMessage, text string) {
+	if _, err := b.client.postMessage(ctx, message.ChannelID, message.ThreadTS, text, nil); err != nil {
+		slog.Warn("client.postMessage",
+			slog.String("error", err.Error()))
+	}
+}
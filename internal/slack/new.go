@@ -0,0 +1,102 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
+	"github.com/pardnchiu/agenvoy/internal/frontend"
+	"github.com/pardnchiu/agenvoy/internal/skill"
+)
+
+type Bot struct {
+	client        *client
+	PlannerAgent  agentTypes.Agent
+	AgentRegistry agentTypes.AgentRegistry
+	SkillScanner  *skill.SkillScanner
+
+	// * identity of the bot, from auth.test
+	userID string
+	botID  string
+	teamID string
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func New(plannerAgent agentTypes.Agent, agentRegistry agentTypes.AgentRegistry, skillScanner *skill.SkillScanner) (*Bot, error) {
+	botToken := os.Getenv("SLACK_BOT_TOKEN")
+	if botToken == "" {
+		return nil, nil
+	}
+	appToken := os.Getenv("SLACK_APP_TOKEN")
+	if appToken == "" {
+		return nil, fmt.Errorf("SLACK_APP_TOKEN is required for socket mode")
+	}
+
+	bot := &Bot{
+		client:        newClient(botToken, appToken),
+		PlannerAgent:  plannerAgent,
+		AgentRegistry: agentRegistry,
+		SkillScanner:  skillScanner,
+		done:          make(chan struct{}),
+	}
+
+	auth, err := bot.client.authTest(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("client.authTest: %w", err)
+	}
+	bot.userID = auth.UserID
+	bot.botID = auth.BotID
+	bot.teamID = auth.TeamID
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bot.cancel = cancel
+	go bot.listen(ctx)
+
+	slog.Info("slack bot is running",
+		slog.String("user", auth.UserID),
+		slog.String("team", auth.TeamID))
+	return bot, nil
+}
+
+// * NewNotifier only posts messages, no socket mode connection
+func NewNotifier(plannerAgent agentTypes.Agent) (*Bot, error) {
+	botToken := os.Getenv("SLACK_BOT_TOKEN")
+	if botToken == "" {
+		return nil, nil
+	}
+	return &Bot{
+		client:       newClient(botToken, ""),
+		PlannerAgent: plannerAgent,
+	}, nil
+}
+
+// * Notify posts scheduler output to a Slack channel, wrap rewrites raw script output with the planner
+func Notify(bot *Bot, channelID, output string, wrap bool) error {
+	if output == "" {
+		output = "任務完成"
+	}
+	content := output
+	if wrap && !strings.HasPrefix(output, "error:") {
+		content = frontend.WrapScriptOutput(bot.PlannerAgent, output)
+	}
+	for _, chunk := range frontend.Split(content, replyMax) {
+		if _, err := bot.client.postMessage(context.Background(), channelID, "", chunk, nil); err != nil {
+			return fmt.Errorf("client.postMessage: %w", err)
+		}
+	}
+	return nil
+}
+
+func Close(b *Bot) error {
+	slog.Info("shutting down slack")
+	if b.cancel != nil {
+		b.cancel()
+		<-b.done
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/pardnchiu/agenvoy/configs"
+	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
+	"github.com/pardnchiu/agenvoy/internal/filesystem/sessionManager"
+	"github.com/pardnchiu/agenvoy/internal/frontend"
+)
+
+const (
+	// * slack truncates long text, keep each message well below the limit
+	replyMax = 3900
+	// * earlier messages loaded as history
+	historyMax = 32
+	// * footer marker, stripped when reading history back
+	footerPrefix = "\n_🤖 "
+)
+
+// * conversation adapts one Slack message to frontend.Run
+type conversation struct {
+	bot       *Bot
+	message   *receiveMessage
+	sessionID string
+	prog      *frontend.Progress
+	replied   bool
+}
+
+func (b *Bot) run(ctx context.Context, message *receiveMessage) error {
+	// * DMs outside a thread share one session per user
+	sessionID, err := sessionManager.GetSlackSession(message.TeamID, message.ChannelID, message.ThreadTS, message.UserID)
+	if err != nil {
+		return fmt.Errorf("sessionManager.GetSlackSession: %w", err)
+	}
+
+	conv := &conversation{
+		bot:       b,
+		message:   message,
+		sessionID: sessionID,
+	}
+	conv.prog = frontend.NewProgress(func(content string) (string, error) {
+		return b.client.postMessage(ctx, message.ChannelID, message.ThreadTS, content, nil)
+	}, func(messageID, content string) error {
+		return b.client.updateMessage(ctx, message.ChannelID, messageID, content, nil)
+	})
+	defer func() {
+		// * placeholder must not stay as "thinking" when no answer is delivered
+		if messageID := conv.prog.Finish(); !conv.replied && messageID != "" {
+			b.client.updateMessage(ctx, message.ChannelID, messageID, "⚠️ No reply", nil)
+		}
+	}()
+
+	var imageInputs []string
+	var fileInputs []frontend.FileInput
+	for _, f := range message.Files {
+		if strings.HasPrefix(f.Mimetype, "image/") {
+			dataURL, err := frontend.FetchImageDataURL(ctx, f.URLPrivate, b.client.botToken)
+			if err != nil {
+				slog.Warn("frontend.FetchImageDataURL",
+					slog.String("error", err.Error()))
+				continue
+			}
+			imageInputs = append(imageInputs, dataURL)
+			continue
+		}
+		text, err := frontend.FetchText(ctx, f.URLPrivate, b.client.botToken)
+		if err != nil {
+			slog.Warn("frontend.FetchText",
+				slog.String("error", err.Error()))
+			continue
+		}
+		fileInputs = append(fileInputs, frontend.FileInput{Name: f.Name, Text: text})
+	}
+
+	return frontend.Run(ctx, frontend.Bot{
+		PlannerAgent:  b.PlannerAgent,
+		AgentRegistry: b.AgentRegistry,
+		SkillScanner:  b.SkillScanner,
+	}, frontend.Request{
+		SessionID:    sessionID,
+		Frontend:     "slack",
+		ChannelID:    "slack:" + message.ChannelID,
+		UserID:       message.UserID,
+		Content:      message.Content,
+		ImageInputs:  imageInputs,
+		FileInputs:   fileInputs,
+		SystemPrompt: configs.SlackSystemPrompt,
+		Policy:       message.Policy,
+	}, conv.prog, conv)
+}
+
+// * History reads the thread, or the DM before this message
+func (c *conversation) History(ctx context.Context) []agentTypes.Message {
+	var msgs []Message
+	var err error
+	switch {
+	case c.message.ThreadTS == c.message.TS:
+		// * thread just started by this message
+		return nil
+	case c.message.ThreadTS != "":
+		msgs, err = c.bot.client.replies(ctx, c.message.ChannelID, c.message.ThreadTS, c.message.TS, historyMax)
+	default:
+		msgs, err = c.bot.client.history(ctx, c.message.ChannelID, c.message.TS, historyMax)
+		for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+			msgs[i], msgs[j] = msgs[j], msgs[i]
+		}
+	}
+	if err != nil {
+		slog.Warn("failed to load slack history",
+			slog.String("error", err.Error()))
+		return nil
+	}
+
+	var history []agentTypes.Message
+	for _, msg := range msgs {
+		if msg.TS == c.message.TS || msg.Text == "" {
+			continue
+		}
+		role := "user"
+		content := msg.Text
+		if msg.User == c.bot.userID || (msg.BotID != "" && msg.BotID == c.bot.botID) {
+			// * progress placeholders and approval prompts are not answers
+			if frontend.IsStatusMessage(content) {
+				continue
+			}
+			role = "assistant"
+			if idx := strings.LastIndex(content, footerPrefix); idx != -1 {
+				content = content[:idx]
+			}
+		} else {
+			content = strings.TrimSpace(mentionRegex.ReplaceAllString(content, ""))
+		}
+		history = append(history, agentTypes.Message{
+			Role:    role,
+			Content: content,
+		})
+	}
+	return history
+}
+
+func (c *conversation) Approve(ctx context.Context, toolName, toolArgs string) bool {
+	return c.bot.requestApproval(ctx, c.message, toolName, toolArgs)
+}
+
+// * Reply replaces the placeholder with the first chunk, the rest and files follow in thread
+func (c *conversation) Reply(ctx context.Context, answer frontend.Answer) error {
+	replyText := answer.Text
+	if len(answer.Errors) > 0 {
+		replyText = fmt.Sprintf("%s\n_errors: %s_", replyText, strings.Join(answer.Errors, ", "))
+	}
+	replyText = fmt.Sprintf("%s%s%s_", replyText, footerPrefix, answer.Footer)
+	c.replied = true
+
+	client := c.bot.client
+	channelID := c.message.ChannelID
+	chunks := frontend.Split(replyText, replyMax)
+	messageID := c.prog.Finish()
+	for i, chunk := range chunks {
+		if i == 0 && messageID != "" {
+			if err := client.updateMessage(ctx, channelID, messageID, chunk, nil); err == nil {
+				continue
+			}
+		}
+		if _, err := client.postMessage(ctx, channelID, c.message.ThreadTS, chunk, nil); err != nil {
+			return fmt.Errorf("client.postMessage: %w", err)
+		}
+	}
+
+	for _, path := range answer.FilePaths {
+		if err := client.uploadFile(ctx, channelID, c.message.ThreadTS, path); err != nil {
+			slog.Warn("client.uploadFile",
+				slog.String("file", path),
+				slog.String("error", err.Error()))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	reconnectMin = time.Second
+	reconnectMax = time.Minute
+)
+
+type envelope struct {
+	EnvelopeID string          `json:"envelope_id,omitempty"`
+	Type       string          `json:"type"` // * hello, events_api, interactive, disconnect
+	Payload    json.RawMessage `json:"payload,omitempty"`
+}
+
+// * listen keeps a socket mode connection open until ctx is cancelled
+func (b *Bot) listen(ctx context.Context) {
+	defer close(b.done)
+
+	wait := reconnectMin
+	for ctx.Err() == nil {
+		start := time.Now()
+		if err := b.connect(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("slack socket",
+				slog.String("error", err.Error()))
+		}
+		// * stable sessions reset the backoff, flapping ones grow it
+		if time.Since(start) > reconnectMax {
+			wait = reconnectMin
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, reconnectMax)
+	}
+}
+
+func (b *Bot) connect(ctx context.Context) error {
+	url, err := b.client.openConnection(ctx)
+	if err != nil {
+		return err
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return fmt.Errorf("websocket.Dial: %w", err)
+	}
+	defer conn.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	var writeMu sync.Mutex
+	for {
+		var env envelope
+		if err := conn.ReadJSON(&env); err != nil {
+			return fmt.Errorf("conn.ReadJSON: %w", err)
+		}
+
+		// * slack redelivers envelopes that are not acknowledged within 3 seconds
+		if env.EnvelopeID != "" {
+			writeMu.Lock()
+			err := conn.WriteJSON(map[string]string{"envelope_id": env.EnvelopeID})
+			writeMu.Unlock()
+			if err != nil {
+				return fmt.Errorf("conn.WriteJSON: %w", err)
+			}
+		}
+
+		switch env.Type {
+		case "hello":
+			slog.Info("slack socket connected")
+		case "disconnect":
+			return nil
+		case "events_api":
+			go b.handleEvent(ctx, env.Payload)
+		case "interactive":
+			go b.handleInteractive(ctx, env.Payload)
+		}
+	}
+}
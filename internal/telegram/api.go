@@ -0,0 +1,251 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/utils"
+)
+
+const (
+	apiBase = "https://api.telegram.org/"
+	// * getUpdates holds the request open this long when idle
+	pollTimeout = 30
+)
+
+type response[T any] struct {
+	OK          bool   `json:"ok"`
+	Result      T      `json:"result"`
+	Description string `json:"description,omitempty"`
+}
+
+type User struct {
+	ID        int64  `json:"id"`
+	IsBot     bool   `json:"is_bot"`
+	FirstName string `json:"first_name"`
+	Username  string `json:"username,omitempty"`
+}
+
+type Chat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"` // * private, group, supergroup or channel
+}
+
+type PhotoSize struct {
+	FileID   string `json:"file_id"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	FileSize int    `json:"file_size,omitempty"`
+}
+
+type Document struct {
+	FileID   string `json:"file_id"`
+	FileName string `json:"file_name,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	FileSize int    `json:"file_size,omitempty"`
+}
+
+type Message struct {
+	MessageID      int         `json:"message_id"`
+	From           *User       `json:"from,omitempty"`
+	Chat           Chat        `json:"chat"`
+	Text           string      `json:"text,omitempty"`
+	Caption        string      `json:"caption,omitempty"`
+	Photo          []PhotoSize `json:"photo,omitempty"`
+	Document       *Document   `json:"document,omitempty"`
+	ReplyToMessage *Message    `json:"reply_to_message,omitempty"`
+}
+
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	From    User     `json:"from"`
+	Message *Message `json:"message,omitempty"`
+	Data    string   `json:"data,omitempty"`
+}
+
+type Update struct {
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message,omitempty"`
+	CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
+}
+
+type client struct {
+	token      string
+	httpClient *http.Client
+	// * outlives the long poll window of getUpdates
+	pollClient *http.Client
+}
+
+// * call posts JSON params, the token is scrubbed from errors since it is part of the URL
+func call[T any](ctx context.Context, c *client, httpClient *http.Client, method string, params map[string]any) (T, error) {
+	result, _, err := utils.POST[response[T]](ctx, httpClient, apiBase+"bot"+c.token+"/"+method, nil, params, "json")
+	if err != nil {
+		return result.Result, fmt.Errorf("%s: %s", method, c.redact(err))
+	}
+	if !result.OK {
+		return result.Result, fmt.Errorf("%s: %s", method, result.Description)
+	}
+	return result.Result, nil
+}
+
+func (c *client) redact(err error) string {
+	return strings.ReplaceAll(err.Error(), c.token, "<token>")
+}
+
+func (c *client) getMe(ctx context.Context) (User, error) {
+	return call[User](ctx, c, c.httpClient, "getMe", nil)
+}
+
+func (c *client) getUpdates(ctx context.Context, offset int64) ([]Update, error) {
+	return call[[]Update](ctx, c, c.pollClient, "getUpdates", map[string]any{
+		"offset":          offset,
+		"timeout":         pollTimeout,
+		"allowed_updates": []string{"message", "callback_query"},
+	})
+}
+
+// * sendMessage sends plain text, markup is an optional inline keyboard
+func (c *client) sendMessage(ctx context.Context, chatID int64, replyTo int, text string, markup any) (int, error) {
+	params := map[string]any{
+		"chat_id": chatID,
+		"text":    text,
+	}
+	if replyTo != 0 {
+		params["reply_parameters"] = map[string]any{
+			"message_id":                  replyTo,
+			"allow_sending_without_reply": true,
+		}
+	}
+	if markup != nil {
+		params["reply_markup"] = markup
+	}
+	result, err := call[Message](ctx, c, c.httpClient, "sendMessage", params)
+	return result.MessageID, err
+}
+
+// * editMessageText replaces text, nil markup drops any buttons
+func (c *client) editMessageText(ctx context.Context, chatID int64, messageID int, text string, markup any) error {
+	params := map[string]any{
+		"chat_id":    chatID,
+		"message_id": messageID,
+		"text":       text,
+	}
+	if markup != nil {
+		params["reply_markup"] = markup
+	}
+	_, err := call[json.RawMessage](ctx, c, c.httpClient, "editMessageText", params)
+	return err
+}
+
+func (c *client) answerCallbackQuery(ctx context.Context, queryID, text string) error {
+	_, err := call[bool](ctx, c, c.httpClient, "answerCallbackQuery", map[string]any{
+		"callback_query_id": queryID,
+		"text":              text,
+	})
+	return err
+}
+
+func (c *client) sendChatAction(ctx context.Context, chatID int64, action string) error {
+	_, err := call[bool](ctx, c, c.httpClient, "sendChatAction", map[string]any{
+		"chat_id": chatID,
+		"action":  action,
+	})
+	return err
+}
+
+type fileResult struct {
+	FileID   string `json:"file_id"`
+	FilePath string `json:"file_path"`
+}
+
+// * download resolves file_id and reads the file, bots may only fetch files up to 20 MB
+func (c *client) download(ctx context.Context, fileID string) ([]byte, string, error) {
+	file, err := call[fileResult](ctx, c, c.httpClient, "getFile", map[string]any{
+		"file_id": fileID,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiBase+"file/bot"+c.token+"/"+file.FilePath, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("http.NewRequestWithContext: %s", c.redact(err))
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("httpClient.Do: %s", c.redact(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("download %s: %s", file.FilePath, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("io.ReadAll: %w", err)
+	}
+	return data, file.FilePath, nil
+}
+
+// * sendDocument uploads a local file as multipart form
+func (c *client) sendDocument(ctx context.Context, chatID int64, replyTo int, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("chat_id", strconv.FormatInt(chatID, 10))
+	if replyTo != 0 {
+		writer.WriteField("reply_to_message_id", strconv.Itoa(replyTo))
+	}
+	part, err := writer.CreateFormFile("document", filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("writer.CreateFormFile: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return fmt.Errorf("part.Write: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("writer.Close: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiBase+"bot"+c.token+"/sendDocument", &body)
+	if err != nil {
+		return fmt.Errorf("http.NewRequestWithContext: %s", c.redact(err))
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("httpClient.Do: %s", c.redact(err))
+	}
+	defer resp.Body.Close()
+
+	var result response[json.RawMessage]
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("json.Decode: %w", err)
+	}
+	if !result.OK {
+		return fmt.Errorf("sendDocument: %s", result.Description)
+	}
+	return nil
+}
+
+func newClient(token string) *client {
+	return &client{
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		pollClient: &http.Client{Timeout: (pollTimeout + 30) * time.Second},
+	}
+}
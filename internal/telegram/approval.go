@@ -0,0 +1,96 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/pardnchiu/agenvoy/internal/frontend"
+	"github.com/pardnchiu/agenvoy/internal/utils"
+)
+
+const (
+	approvePrefix = "tool_approve:"
+	denyPrefix    = "tool_deny:"
+	// * keep arguments preview inside a single message
+	maxArgsPreview = 3000
+)
+
+// * requestApproval posts Approve/Deny buttons and blocks until decided, timed out or cancelled
+func (b *Bot) requestApproval(ctx context.Context, message *receiveMessage, toolName, toolArgs string) bool {
+	a, err := frontend.NewApproval(message.UserID)
+	if err != nil {
+		return false
+	}
+	defer a.Close()
+
+	base := fmt.Sprintf("🔐 %s needs approval\n%s", toolName, formatArgs(toolArgs))
+	messageID, err := b.client.sendMessage(ctx, message.ChatID, message.MessageID,
+		fmt.Sprintf("%s\n\ndenied automatically in %s", base, frontend.ApproveTimeout),
+		map[string]any{
+			"inline_keyboard": [][]map[string]string{{
+				{"text": "✅ Approve", "callback_data": approvePrefix + a.Token},
+				{"text": "❌ Deny", "callback_data": denyPrefix + a.Token},
+			}},
+		})
+	if err != nil {
+		slog.Warn("failed to send approval",
+			slog.String("error", err.Error()))
+		return false
+	}
+
+	approved, status := a.Wait(ctx)
+	if status != "" {
+		b.closeApproval(message.ChatID, messageID, base+"\n"+status)
+	}
+	return approved
+}
+
+// * handleCallback answers Approve/Deny clicks
+func (b *Bot) handleCallback(ctx context.Context, query *CallbackQuery) {
+	var approved bool
+	var token string
+	if t, ok := strings.CutPrefix(query.Data, approvePrefix); ok {
+		approved, token = true, t
+	} else if t, ok := strings.CutPrefix(query.Data, denyPrefix); ok {
+		token = t
+	} else {
+		return
+	}
+
+	userID := strconv.FormatInt(query.From.ID, 10)
+	// * TELEGRAM_ADMIN_USERS may decide for anyone
+	admin := frontend.IsAdminUser("TELEGRAM_ADMIN_USERS", userID)
+	if notice := frontend.Decide(token, userID, admin, approved); notice != "" {
+		b.client.answerCallbackQuery(ctx, query.ID, notice)
+		return
+	}
+	b.client.answerCallbackQuery(ctx, query.ID, "")
+
+	if query.Message == nil {
+		return
+	}
+	name := query.From.FirstName
+	if query.From.Username != "" {
+		name = "@" + query.From.Username
+	}
+	status := "❌ Denied by " + name
+	if approved {
+		status = "✅ Approved by " + name
+	}
+	text, _, _ := strings.Cut(query.Message.Text, "\n\ndenied automatically")
+	b.closeApproval(query.Message.Chat.ID, query.Message.MessageID, fmt.Sprintf("%s\n%s", text, status))
+}
+
+func (b *Bot) closeApproval(chatID int64, messageID int, text string) {
+	if err := b.client.editMessageText(context.Background(), chatID, messageID, text, nil); err != nil {
+		slog.Warn("client.editMessageText",
+			slog.String("error", err.Error()))
+	}
+}
+
+func formatArgs(args string) string {
+	return utils.TruncateUTF8(frontend.IndentArgs(args), maxArgsPreview)
+}
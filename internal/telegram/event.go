@@ -0,0 +1,139 @@
+package telegram
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/pardnchiu/agenvoy/internal/access"
+	"github.com/pardnchiu/agenvoy/internal/filesystem/sessionManager"
+	"github.com/pardnchiu/agenvoy/internal/frontend"
+)
+
+// * receiveMessage is one incoming user turn after filtering
+type receiveMessage struct {
+	ChatID    int64
+	MessageID int
+	UserID    string
+	Content   string
+	IsPrivate bool
+	// * largest size of an attached photo
+	Photo    *PhotoSize
+	Document *Document
+	Policy   access.Policy
+}
+
+func (b *Bot) handleUpdate(ctx context.Context, update Update) {
+	if update.CallbackQuery != nil {
+		b.handleCallback(ctx, update.CallbackQuery)
+		return
+	}
+	msg := update.Message
+	if msg == nil || msg.From == nil || msg.From.IsBot {
+		return
+	}
+
+	text := msg.Text
+	if text == "" {
+		text = msg.Caption
+	}
+	message := &receiveMessage{
+		ChatID:    msg.Chat.ID,
+		MessageID: msg.MessageID,
+		UserID:    strconv.FormatInt(msg.From.ID, 10),
+		IsPrivate: msg.Chat.Type == "private",
+		Document:  msg.Document,
+	}
+	if len(msg.Photo) > 0 {
+		message.Photo = &msg.Photo[len(msg.Photo)-1]
+	}
+
+	// * groups need a mention or a reply to the bot, private chats are always answered
+	mention := "@" + b.username
+	if !message.IsPrivate {
+		repliesToBot := msg.ReplyToMessage != nil && msg.ReplyToMessage.From != nil && msg.ReplyToMessage.From.ID == b.userID
+		if !strings.Contains(text, mention) && !repliesToBot {
+			return
+		}
+	}
+	message.Content = strings.TrimSpace(strings.ReplaceAll(text, mention, ""))
+
+	if message.Content == "" && message.Photo == nil && message.Document == nil {
+		return
+	}
+
+	slog.Info("telegram message received",
+		slog.String("user", message.UserID),
+		slog.String("content", message.Content),
+		slog.Bool("is_private", message.IsPrivate))
+
+	if !b.checkAccess(ctx, message) {
+		return
+	}
+
+	if b.handleCommand(ctx, message) {
+		return
+	}
+
+	if b.PlannerAgent == nil {
+		return
+	}
+	b.client.sendChatAction(ctx, message.ChatID, "typing")
+	if err := b.run(ctx, message); err != nil {
+		slog.Warn("run",
+			slog.String("error", err.Error()))
+	}
+}
+
+// * handleCommand answers /start and /reset, returns false for anything else
+func (b *Bot) handleCommand(ctx context.Context, message *receiveMessage) bool {
+	command, _, _ := strings.Cut(message.Content, " ")
+	command, _, _ = strings.Cut(command, "@")
+	switch command {
+	case "/start":
+		b.notice(ctx, message, "👋 Hi, send me a message, a photo or a document to get started. /reset clears our conversation.")
+		return true
+	case "/reset":
+		sessionID, err := sessionManager.GetTelegramSession(strconv.FormatInt(message.ChatID, 10), message.UserID)
+		if err != nil {
+			slog.Warn("sessionManager.GetTelegramSession",
+				slog.String("error", err.Error()))
+			return true
+		}
+		if err := sessionManager.ResetSession(sessionID); err != nil {
+			slog.Warn("sessionManager.ResetSession",
+				slog.String("error", err.Error()))
+			b.notice(ctx, message, "⚠️ Failed to reset the conversation")
+			return true
+		}
+		b.notice(ctx, message, "🧹 Conversation cleared")
+		return true
+	}
+	return false
+}
+
+// * checkAccess resolves the policy into message, replies politely and returns false when refused
+func (b *Bot) checkAccess(ctx context.Context, message *receiveMessage) bool {
+	// * group chats are the channels, private chats skip the channel check
+	policy, notice, ok := frontend.CheckAccess("telegram", access.Principal{
+		ChannelID: strconv.FormatInt(message.ChatID, 10),
+		UserID:    message.UserID,
+		Direct:    message.IsPrivate,
+	})
+	if !ok {
+		if notice != "" {
+			b.notice(ctx, message, notice)
+		}
+		return false
+	}
+	message.Policy = policy
+	return true
+}
+
+func (b *Bot) notice(ctx context.Context, message *receiveMessage, text string) {
+	if _, err := b.client.sendMessage(ctx, message.ChatID, message.MessageID, text, nil); err != nil {
+		slog.Warn("client.sendMessage",
+			slog.String("error", err.Error()))
+	}
+}
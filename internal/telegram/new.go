@@ -0,0 +1,138 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
+	"github.com/pardnchiu/agenvoy/internal/frontend"
+	"github.com/pardnchiu/agenvoy/internal/keychain"
+	"github.com/pardnchiu/agenvoy/internal/skill"
+)
+
+const (
+	retryMin = time.Second
+	retryMax = time.Minute
+)
+
+type Bot struct {
+	client        *client
+	PlannerAgent  agentTypes.Agent
+	AgentRegistry agentTypes.AgentRegistry
+	SkillScanner  *skill.SkillScanner
+
+	// * identity of the bot, from getMe
+	userID   int64
+	username string
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func New(plannerAgent agentTypes.Agent, agentRegistry agentTypes.AgentRegistry, skillScanner *skill.SkillScanner) (*Bot, error) {
+	token := keychain.Get("TELEGRAM_BOT_TOKEN")
+	if token == "" {
+		return nil, nil
+	}
+
+	bot := &Bot{
+		client:        newClient(token),
+		PlannerAgent:  plannerAgent,
+		AgentRegistry: agentRegistry,
+		SkillScanner:  skillScanner,
+		done:          make(chan struct{}),
+	}
+
+	me, err := bot.client.getMe(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("client.getMe: %w", err)
+	}
+	bot.userID = me.ID
+	bot.username = me.Username
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bot.cancel = cancel
+	go bot.poll(ctx)
+
+	slog.Info("telegram bot is running",
+		slog.String("username", me.Username))
+	return bot, nil
+}
+
+// * poll long polls getUpdates until ctx is cancelled
+func (b *Bot) poll(ctx context.Context) {
+	defer close(b.done)
+
+	var offset int64
+	wait := retryMin
+	for ctx.Err() == nil {
+		updates, err := b.client.getUpdates(ctx, offset)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn("client.getUpdates",
+				slog.String("error", err.Error()))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+			wait = min(wait*2, retryMax)
+			continue
+		}
+		wait = retryMin
+
+		for _, update := range updates {
+			// * acknowledged on the next call, a crash may redeliver at most one batch
+			offset = update.UpdateID + 1
+			go b.handleUpdate(ctx, update)
+		}
+	}
+}
+
+// * NewNotifier only sends messages, no polling, so it does not steal updates from the bot
+func NewNotifier(plannerAgent agentTypes.Agent) (*Bot, error) {
+	token := keychain.Get("TELEGRAM_BOT_TOKEN")
+	if token == "" {
+		return nil, nil
+	}
+	return &Bot{
+		client:       newClient(token),
+		PlannerAgent: plannerAgent,
+	}, nil
+}
+
+// * Notify posts scheduler output to a Telegram chat, wrap rewrites raw script output with the planner
+func Notify(bot *Bot, chatID, output string, wrap bool) error {
+	id, err := strconv.ParseInt(chatID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("strconv.ParseInt: %w", err)
+	}
+	if output == "" {
+		output = "任務完成"
+	}
+	content := output
+	if wrap && !strings.HasPrefix(output, "error:") {
+		content = frontend.WrapScriptOutput(bot.PlannerAgent, output)
+	}
+	for _, chunk := range frontend.Split(content, replyMax) {
+		if _, err := bot.client.sendMessage(context.Background(), id, 0, chunk, nil); err != nil {
+			return fmt.Errorf("client.sendMessage: %w", err)
+		}
+	}
+	return nil
+}
+
+func Close(b *Bot) error {
+	slog.Info("shutting down telegram")
+	if b.cancel != nil {
+		b.cancel()
+		<-b.done
+	}
+	return nil
+}
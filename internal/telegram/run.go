@@ -0,0 +1,168 @@
+package telegram
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pardnchiu/agenvoy/configs"
+	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
+	"github.com/pardnchiu/agenvoy/internal/filesystem/sessionManager"
+	"github.com/pardnchiu/agenvoy/internal/frontend"
+)
+
+const (
+	// * telegram rejects text over 4096 characters
+	replyMax = 4000
+)
+
+// * conversation adapts one Telegram message to frontend.Run
+type conversation struct {
+	bot       *Bot
+	message   *receiveMessage
+	sessionID string
+	prog      *frontend.Progress
+	replied   bool
+}
+
+func (b *Bot) run(ctx context.Context, message *receiveMessage) error {
+	chatID := strconv.FormatInt(message.ChatID, 10)
+	sessionID, err := sessionManager.GetTelegramSession(chatID, message.UserID)
+	if err != nil {
+		return fmt.Errorf("sessionManager.GetTelegramSession: %w", err)
+	}
+
+	conv := &conversation{
+		bot:       b,
+		message:   message,
+		sessionID: sessionID,
+	}
+	conv.prog = frontend.NewProgress(func(content string) (string, error) {
+		messageID, err := b.client.sendMessage(ctx, message.ChatID, message.MessageID, content, nil)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(messageID), nil
+	}, func(messageID, content string) error {
+		return b.client.editMessageText(ctx, message.ChatID, atoi(messageID), content, nil)
+	})
+	defer func() {
+		// * placeholder must not stay as "thinking" when no answer is delivered
+		if messageID := conv.prog.Finish(); !conv.replied && messageID != "" {
+			b.client.editMessageText(ctx, message.ChatID, atoi(messageID), "⚠️ No reply", nil)
+		}
+	}()
+
+	var imageInputs []string
+	var fileInputs []frontend.FileInput
+	if message.Photo != nil {
+		if dataURL, err := b.imageDataURL(ctx, message.Photo.FileID, "image/jpeg"); err == nil {
+			imageInputs = append(imageInputs, dataURL)
+		} else {
+			slog.Warn("imageDataURL",
+				slog.String("error", err.Error()))
+		}
+	}
+	if doc := message.Document; doc != nil {
+		if strings.HasPrefix(doc.MimeType, "image/") {
+			if dataURL, err := b.imageDataURL(ctx, doc.FileID, doc.MimeType); err == nil {
+				imageInputs = append(imageInputs, dataURL)
+			} else {
+				slog.Warn("imageDataURL",
+					slog.String("error", err.Error()))
+			}
+		} else if data, path, err := b.client.download(ctx, doc.FileID); err == nil {
+			name := doc.FileName
+			if name == "" {
+				name = filepath.Base(path)
+			}
+			fileInputs = append(fileInputs, frontend.FileInput{Name: name, Text: string(data)})
+		} else {
+			slog.Warn("client.download",
+				slog.String("error", err.Error()))
+		}
+	}
+
+	content := message.Content
+	if content == "" {
+		content = "請查看附件"
+	}
+
+	return frontend.Run(ctx, frontend.Bot{
+		PlannerAgent:  b.PlannerAgent,
+		AgentRegistry: b.AgentRegistry,
+		SkillScanner:  b.SkillScanner,
+	}, frontend.Request{
+		SessionID:    sessionID,
+		Frontend:     "telegram",
+		ChannelID:    "telegram:" + chatID,
+		UserID:       message.UserID,
+		Content:      content,
+		ImageInputs:  imageInputs,
+		FileInputs:   fileInputs,
+		SystemPrompt: configs.TelegramSystemPrompt,
+		Policy:       message.Policy,
+		StoreHistory: true,
+	}, conv.prog, conv)
+}
+
+// * History comes from history.json, the Bot API cannot read earlier messages
+func (c *conversation) History(ctx context.Context) []agentTypes.Message {
+	return frontend.StoredHistory(c.sessionID)
+}
+
+func (c *conversation) Approve(ctx context.Context, toolName, toolArgs string) bool {
+	return c.bot.requestApproval(ctx, c.message, toolName, toolArgs)
+}
+
+// * Reply replaces the placeholder with the first chunk, the rest and files follow as replies
+func (c *conversation) Reply(ctx context.Context, answer frontend.Answer) error {
+	replyText := answer.Text
+	if len(answer.Errors) > 0 {
+		replyText = fmt.Sprintf("%s\nerrors: %s", replyText, strings.Join(answer.Errors, ", "))
+	}
+	replyText = fmt.Sprintf("%s\n\n🤖 %s", replyText, answer.Footer)
+	c.replied = true
+
+	client := c.bot.client
+	chatID := c.message.ChatID
+	chunks := frontend.Split(replyText, replyMax)
+	messageID := c.prog.Finish()
+	for i, chunk := range chunks {
+		if i == 0 && messageID != "" {
+			if err := client.editMessageText(ctx, chatID, atoi(messageID), chunk, nil); err == nil {
+				continue
+			}
+		}
+		if _, err := client.sendMessage(ctx, chatID, c.message.MessageID, chunk, nil); err != nil {
+			return fmt.Errorf("client.sendMessage: %w", err)
+		}
+	}
+
+	for _, path := range answer.FilePaths {
+		if err := client.sendDocument(ctx, chatID, c.message.MessageID, path); err != nil {
+			slog.Warn("client.sendDocument",
+				slog.String("file", path),
+				slog.String("error", err.Error()))
+		}
+	}
+	return nil
+}
+
+// * imageDataURL downloads a photo, file paths carry no reliable content type
+func (b *Bot) imageDataURL(ctx context.Context, fileID, contentType string) (string, error) {
+	data, _, err := b.client.download(ctx, fileID)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("data:%s;base64,%s", contentType, base64.StdEncoding.EncodeToString(data)), nil
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
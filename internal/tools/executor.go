@@ -13,6 +13,7 @@ import (
 	"github.com/pardnchiu/agenvoy/internal/filesystem"
 	apiAdapter "github.com/pardnchiu/agenvoy/internal/tools/apis/adapter"
 	"github.com/pardnchiu/agenvoy/internal/tools/file"
+	"github.com/pardnchiu/agenvoy/internal/tools/lsp"
 	toolRegister "github.com/pardnchiu/agenvoy/internal/tools/register"
 	toolTypes "github.com/pardnchiu/agenvoy/internal/tools/types"
 )
@@ -101,5 +102,39 @@ func Execute(ctx context.Context, e *toolTypes.Executor, name string, args json.
 		}
 		return e.APIToolbox.Execute(name, params)
 	}
-	return toolRegister.Dispatch(ctx, e, name, args)
+	result, err := toolRegister.Dispatch(ctx, e, name, args)
+	if err != nil {
+		return result, err
+	}
+
+	// * report compile errors right after edits, skipped when no language server handles the file
+	var paths []string
+	switch name {
+	case "write_file", "patch_edit":
+		var params struct {
+			Path string `json:"path"`
+		}
+		if json.Unmarshal(args, &params) == nil && params.Path != "" {
+			paths = append(paths, params.Path)
+		}
+	case "apply_patch":
+		var params struct {
+			Patch string `json:"patch"`
+		}
+		if json.Unmarshal(args, &params) == nil {
+			paths = file.PatchPaths(params.Patch)
+		}
+	}
+	for _, path := range paths {
+		report := lsp.Diagnose(ctx, e, path)
+		if report == "" {
+			continue
+		}
+		// * a patch can touch several files, name each report
+		if len(paths) > 1 {
+			report = strings.Replace(report, "Diagnostics", fmt.Sprintf("Diagnostics (%s)", path), 1)
+		}
+		result += "\n\n" + report
+	}
+	return result, nil
 }
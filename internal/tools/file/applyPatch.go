@@ -0,0 +1,460 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+	toolTypes "github.com/pardnchiu/agenvoy/internal/tools/types"
+)
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+type patchHunk struct {
+	header   string
+	oldStart int
+	oldCount int
+	newCount int
+	oldLines []string
+	newLines []string
+	// * set by "\ No newline at end of file", after the side of the line it follows
+	oldNoNewline bool
+	newNoNewline bool
+	last         byte
+}
+
+func (h *patchHunk) isComplete() bool {
+	return len(h.oldLines) >= h.oldCount && len(h.newLines) >= h.newCount
+}
+
+type patchFile struct {
+	oldPath string
+	newPath string
+	hunks   []patchHunk
+}
+
+type patchResult struct {
+	path     string
+	fullPath string
+	// * nil when the file does not exist before the patch or after it
+	original *string
+	content  *string
+	reports  []string
+}
+
+// * patchState holds each touched path once, so a later section of the same file builds on the earlier one
+type patchState struct {
+	results []*patchResult
+	byPath  map[string]*patchResult
+}
+
+func applyPatch(e *toolTypes.Executor, diff string) (string, error) {
+	files, err := parsePatch(diff)
+	if err != nil {
+		return "", err
+	}
+
+	// * resolve every hunk in memory first, nothing touches disk until all pass
+	state := &patchState{byPath: map[string]*patchResult{}}
+	for _, f := range files {
+		if err := resolvePatchFile(e, state, f); err != nil {
+			return "", err
+		}
+	}
+
+	var written []*patchResult
+	for _, r := range state.results {
+		var err error
+		switch {
+		case r.content != nil:
+			err = filesystem.WriteFile(r.fullPath, *r.content, 0644)
+		case r.original != nil:
+			err = os.Remove(r.fullPath)
+		default:
+			// * created and deleted within the same patch
+			continue
+		}
+		if err != nil {
+			rollbackPatch(written)
+			return "", fmt.Errorf("failed to write (%s), rolled back: %w", r.path, err)
+		}
+		written = append(written, r)
+	}
+
+	var sb strings.Builder
+	for _, r := range written {
+		sb.WriteString(r.path + "\n")
+		for _, line := range r.reports {
+			sb.WriteString("  " + line + "\n")
+		}
+	}
+	return strings.TrimSpace(fmt.Sprintf("Successfully applied patch to %d file(s)\n%s", len(written), sb.String())), nil
+}
+
+// * PatchPaths lists the files a patch leaves on disk, deleted files are skipped
+func PatchPaths(diff string) []string {
+	files, err := parsePatch(diff)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, f := range files {
+		if f.newPath != "" && f.newPath != "/dev/null" && !slices.Contains(paths, f.newPath) {
+			paths = append(paths, f.newPath)
+		}
+	}
+	return paths
+}
+
+func rollbackPatch(written []*patchResult) {
+	for i := len(written) - 1; i >= 0; i-- {
+		r := written[i]
+		if r.original == nil {
+			os.Remove(r.fullPath)
+			continue
+		}
+		filesystem.WriteFile(r.fullPath, *r.original, 0644)
+	}
+}
+
+// * load returns the pending state of path, read from disk the first time
+func (s *patchState) load(e *toolTypes.Executor, path string) (*patchResult, error) {
+	if isDenied(path) {
+		return nil, fmt.Errorf("access denied: %s", path)
+	}
+	fullPath, err := getFullPath(e, path)
+	if err != nil {
+		return nil, err
+	}
+	if isExclude(e, fullPath) {
+		return nil, fmt.Errorf("path is excluded: %s", path)
+	}
+	if r, ok := s.byPath[fullPath]; ok {
+		return r, nil
+	}
+
+	r := &patchResult{
+		path:     path,
+		fullPath: fullPath,
+	}
+	data, err := os.ReadFile(fullPath)
+	switch {
+	case err == nil:
+		original := string(data)
+		content := original
+		r.original = &original
+		r.content = &content
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to read file (%s): %w", path, err)
+	}
+	s.byPath[fullPath] = r
+	s.results = append(s.results, r)
+	return r, nil
+}
+
+func resolvePatchFile(e *toolTypes.Executor, state *patchState, f patchFile) error {
+	isCreate := f.oldPath == "/dev/null"
+	isDelete := f.newPath == "/dev/null"
+	srcPath, dstPath := f.oldPath, f.newPath
+	if isCreate {
+		srcPath = f.newPath
+	}
+	if isDelete {
+		dstPath = f.oldPath
+	}
+	if srcPath == "" || dstPath == "" || srcPath == "/dev/null" {
+		return fmt.Errorf("missing file path in patch")
+	}
+
+	src, err := state.load(e, srcPath)
+	if err != nil {
+		return err
+	}
+	switch {
+	case isCreate && src.content != nil:
+		return fmt.Errorf("file already exists: %s", srcPath)
+	case !isCreate && src.content == nil:
+		return fmt.Errorf("file not found: %s", srcPath)
+	}
+	dst := src
+	if dstPath != srcPath {
+		if dst, err = state.load(e, dstPath); err != nil {
+			return err
+		}
+		if dst.content != nil {
+			return fmt.Errorf("rename target already exists: %s", dstPath)
+		}
+	}
+
+	var lines []string
+	trailingNewline := true
+	if src.content != nil {
+		lines, trailingNewline = splitPatchLines(*src.content)
+	}
+
+	newLines, reports, err := applyHunks(lines, f.hunks)
+	if err != nil {
+		return fmt.Errorf("%s: %w", srcPath, err)
+	}
+	dst.reports = append(dst.reports, reports...)
+	if trailing, ok := patchTrailingNewline(f.hunks); ok {
+		trailingNewline = trailing
+	}
+
+	if isDelete {
+		if len(newLines) > 0 {
+			return fmt.Errorf("%s: delete patch leaves %d line(s)", srcPath, len(newLines))
+		}
+		src.content = nil
+		src.reports = append(src.reports, "deleted")
+		return nil
+	}
+
+	content := strings.Join(newLines, "\n")
+	if trailingNewline && len(newLines) > 0 {
+		content += "\n"
+	}
+	dst.content = &content
+	if dst != src {
+		src.content = nil
+		src.reports = append(src.reports, "renamed to "+dstPath)
+	}
+	return nil
+}
+
+// * patchTrailingNewline reads the end of file from the markers, a file the patch does not mark keeps its own
+func patchTrailingNewline(hunks []patchHunk) (bool, bool) {
+	for i := len(hunks) - 1; i >= 0; i-- {
+		if hunks[i].newNoNewline {
+			return false, true
+		}
+		if hunks[i].oldNoNewline {
+			return true, true
+		}
+	}
+	return false, false
+}
+
+func splitPatchLines(content string) ([]string, bool) {
+	if content == "" {
+		return nil, true
+	}
+	trailing := strings.HasSuffix(content, "\n")
+	content = strings.TrimSuffix(content, "\n")
+	return strings.Split(content, "\n"), trailing
+}
+
+func applyHunks(lines []string, hunks []patchHunk) ([]string, []string, error) {
+	reports := make([]string, 0, len(hunks))
+	// * hunks are applied in order, offset tracks line shifts from previous hunks
+	offset := 0
+	floor := 0
+	for i, h := range hunks {
+		expected := h.oldStart - 1 + offset
+		if len(h.oldLines) == 0 {
+			// * pure insertion, old start points at the line before the insert
+			expected = h.oldStart + offset
+		}
+		expected = max(floor, min(expected, len(lines)))
+
+		pos, mode := locateHunk(lines, h.oldLines, expected, floor)
+		if pos < 0 {
+			return nil, nil, fmt.Errorf("hunk #%d %s: context not found", i+1, h.header)
+		}
+
+		updated := make([]string, 0, len(lines)-len(h.oldLines)+len(h.newLines))
+		updated = append(updated, lines[:pos]...)
+		updated = append(updated, h.newLines...)
+		updated = append(updated, lines[pos+len(h.oldLines):]...)
+		lines = updated
+
+		shift := pos - expected
+		report := fmt.Sprintf("hunk #%d %s: applied at line %d (%s", i+1, h.header, pos+1, mode)
+		if shift != 0 {
+			report += fmt.Sprintf(", offset %+d", shift)
+		}
+		reports = append(reports, report+")")
+
+		offset += len(h.newLines) - len(h.oldLines) + shift
+		floor = pos + len(h.newLines)
+	}
+	return lines, reports, nil
+}
+
+// * exact match first, then ignore trailing spaces, then ignore indentation
+func locateHunk(lines, target []string, expected, floor int) (int, string) {
+	if len(target) == 0 {
+		return expected, "exact"
+	}
+
+	modes := []struct {
+		name string
+		norm func(string) string
+	}{
+		{"exact", func(s string) string { return s }},
+		{"fuzzy: trailing whitespace", func(s string) string { return strings.TrimRight(s, " \t\r") }},
+		{"fuzzy: whitespace", func(s string) string { return strings.Join(strings.Fields(s), " ") }},
+	}
+
+	for _, mode := range modes {
+		// * search outward from the expected position, nearest match wins
+		for distance := 0; ; distance++ {
+			before := expected - distance
+			after := expected + distance
+			if before < floor && after > len(lines)-len(target) {
+				break
+			}
+			if before >= floor && matchHunk(lines, target, before, mode.norm) {
+				return before, mode.name
+			}
+			if distance > 0 && after <= len(lines)-len(target) && matchHunk(lines, target, after, mode.norm) {
+				return after, mode.name
+			}
+		}
+	}
+	return -1, ""
+}
+
+func matchHunk(lines, target []string, pos int, norm func(string) string) bool {
+	if pos < 0 || pos+len(target) > len(lines) {
+		return false
+	}
+	for i, t := range target {
+		if norm(lines[pos+i]) != norm(t) {
+			return false
+		}
+	}
+	return true
+}
+
+func parsePatch(diff string) ([]patchFile, error) {
+	diff = strings.ReplaceAll(diff, "\r\n", "\n")
+	lines := strings.Split(diff, "\n")
+
+	var files []patchFile
+	var current *patchFile
+	var hunk *patchHunk
+
+	flushHunk := func() {
+		if current != nil && hunk != nil {
+			current.hunks = append(current.hunks, *hunk)
+		}
+		hunk = nil
+	}
+	flushFile := func() {
+		flushHunk()
+		if current != nil {
+			files = append(files, *current)
+		}
+		current = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case (hunk == nil || hunk.isComplete()) &&
+			strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			flushFile()
+			current = &patchFile{
+				oldPath: parsePatchPath(line[4:]),
+				newPath: parsePatchPath(lines[i+1][4:]),
+			}
+			i++
+
+		case strings.HasPrefix(line, "@@"):
+			if current == nil {
+				return nil, fmt.Errorf("hunk without file header at line %d", i+1)
+			}
+			flushHunk()
+			match := hunkHeaderRegex.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("invalid hunk header at line %d: %s", i+1, line)
+			}
+			hunk = &patchHunk{
+				header:   strings.TrimSpace(match[0]),
+				oldStart: atoiDefault(match[1], 0),
+				oldCount: atoiDefault(match[2], 1),
+				newCount: atoiDefault(match[4], 1),
+			}
+
+		case hunk != nil && strings.HasPrefix(line, "+"):
+			hunk.newLines = append(hunk.newLines, line[1:])
+			hunk.last = '+'
+
+		case hunk != nil && strings.HasPrefix(line, "-"):
+			hunk.oldLines = append(hunk.oldLines, line[1:])
+			hunk.last = '-'
+
+		case hunk != nil && strings.HasPrefix(line, " "):
+			hunk.oldLines = append(hunk.oldLines, line[1:])
+			hunk.newLines = append(hunk.newLines, line[1:])
+			hunk.last = ' '
+
+		case hunk != nil && strings.HasPrefix(line, `\`):
+			// * "\ No newline at end of file" belongs to the hunk, the line before it ends the file
+			switch hunk.last {
+			case '-':
+				hunk.oldNoNewline = true
+			case '+':
+				hunk.newNoNewline = true
+			case ' ':
+				hunk.oldNoNewline = true
+				hunk.newNoNewline = true
+			}
+
+		case hunk != nil && line == "" && !hunk.isComplete():
+			// * some models drop the leading space on blank context lines
+			hunk.oldLines = append(hunk.oldLines, "")
+			hunk.newLines = append(hunk.newLines, "")
+			hunk.last = ' '
+
+		default:
+			// * "diff --git", "index ..." and trailing blank
+			flushHunk()
+		}
+	}
+	flushFile()
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no file found in patch")
+	}
+	for _, f := range files {
+		if len(f.hunks) == 0 {
+			return nil, fmt.Errorf("no hunk found for file: %s", f.newPath)
+		}
+	}
+	return files, nil
+}
+
+func atoiDefault(s string, fallback int) int {
+	if s == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+func parsePatchPath(raw string) string {
+	path := strings.TrimSpace(raw)
+	// * strip timestamp after tab, e.g. "--- a/file.go\t2026-01-01 00:00:00"
+	if idx := strings.IndexByte(path, '\t'); idx != -1 {
+		path = path[:idx]
+	}
+	path = strings.Trim(path, `"`)
+	if path == "/dev/null" {
+		return path
+	}
+	for _, prefix := range []string{"a/", "b/"} {
+		if strings.HasPrefix(path, prefix) {
+			return path[len(prefix):]
+		}
+	}
+	return path
+}
@@ -0,0 +1,263 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	toolTypes "github.com/pardnchiu/agenvoy/internal/tools/types"
+)
+
+func TestParsePatch(t *testing.T) {
+	diff := `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,3 @@
+ package a
+-var x = 1
++var x = 2
+
+@@ -10 +10,2 @@
+ func f() {}
++func g() {}
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,2 @@
++hello
++world
+`
+	files, err := parsePatch(diff)
+	if err != nil {
+		t.Fatalf("parsePatch: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("files = %d, want 2", len(files))
+	}
+	if files[0].oldPath != "a.go" || files[0].newPath != "a.go" {
+		t.Errorf("paths = %q %q", files[0].oldPath, files[0].newPath)
+	}
+	if len(files[0].hunks) != 2 {
+		t.Fatalf("hunks = %d, want 2", len(files[0].hunks))
+	}
+	h := files[0].hunks[0]
+	if len(h.oldLines) != 3 || len(h.newLines) != 3 {
+		t.Errorf("hunk lines old=%d new=%d, want 3/3", len(h.oldLines), len(h.newLines))
+	}
+	if files[1].oldPath != "/dev/null" || files[1].newPath != "new.txt" {
+		t.Errorf("new file paths = %q %q", files[1].oldPath, files[1].newPath)
+	}
+}
+
+func TestParsePatchErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+	}{
+		{"empty", ""},
+		{"no header", "@@ -1 +1 @@\n-a\n+b\n"},
+		{"bad hunk", "--- a/x\n+++ b/x\n@@ bad @@\n"},
+		{"no hunk", "--- a/x\n+++ b/x\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parsePatch(tt.diff); err == nil {
+				t.Errorf("parsePatch(%q) expected error", tt.diff)
+			}
+		})
+	}
+}
+
+func TestApplyHunks(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		hunks   []patchHunk
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "exact",
+			input: "a\nb\nc",
+			hunks: []patchHunk{{oldStart: 2, oldLines: []string{"b"}, newLines: []string{"B"}}},
+			want:  "a\nB\nc",
+		},
+		{
+			name:  "offset",
+			input: "x\ny\na\nb\nc",
+			hunks: []patchHunk{{oldStart: 1, oldLines: []string{"a", "b"}, newLines: []string{"a", "B"}}},
+			want:  "x\ny\na\nB\nc",
+		},
+		{
+			name:  "trailing whitespace drift",
+			input: "a  \nb\t\nc",
+			hunks: []patchHunk{{oldStart: 1, oldLines: []string{"a", "b"}, newLines: []string{"A", "b"}}},
+			want:  "A\nb\nc",
+		},
+		{
+			name:  "indent drift",
+			input: "func f() {\n\treturn 1\n}",
+			hunks: []patchHunk{{oldStart: 2, oldLines: []string{"    return 1"}, newLines: []string{"\treturn 2"}}},
+			want:  "func f() {\n\treturn 2\n}",
+		},
+		{
+			name:  "multi hunk",
+			input: "1\n2\n3\n4\n5\n6",
+			hunks: []patchHunk{
+				{oldStart: 2, oldLines: []string{"2"}, newLines: []string{"2a", "2b"}},
+				{oldStart: 5, oldLines: []string{"5"}, newLines: []string{}},
+			},
+			want: "1\n2a\n2b\n3\n4\n6",
+		},
+		{
+			name:  "insertion",
+			input: "a\nb",
+			hunks: []patchHunk{{oldStart: 1, newLines: []string{"x"}}},
+			want:  "a\nx\nb",
+		},
+		{
+			name:    "missing context",
+			input:   "a\nb",
+			hunks:   []patchHunk{{oldStart: 1, oldLines: []string{"z"}, newLines: []string{"y"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, _ := splitPatchLines(tt.input)
+			got, reports, err := applyHunks(lines, tt.hunks)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if strings.Join(got, "\n") != tt.want {
+				t.Errorf("got %q, want %q", strings.Join(got, "\n"), tt.want)
+			}
+			if len(reports) != len(tt.hunks) {
+				t.Errorf("reports = %d, want %d", len(reports), len(tt.hunks))
+			}
+		})
+	}
+}
+
+func TestApplyPatchAtomic(t *testing.T) {
+	dir := t.TempDir()
+	e := &toolTypes.Executor{WorkPath: dir}
+
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("alpha\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// * second file fails, first file must stay untouched
+	bad := "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-one\n+ONE\n--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-missing\n+x\n"
+	if _, err := applyPatch(e, bad); err == nil {
+		t.Fatal("expected error")
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "a.txt"))
+	if string(data) != "one\ntwo\n" {
+		t.Errorf("a.txt changed after failed patch: %q", data)
+	}
+
+	good := "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-one\n+ONE\n--- /dev/null\n+++ b/c.txt\n@@ -0,0 +1 @@\n+new\n"
+	if _, err := applyPatch(e, good); err != nil {
+		t.Fatalf("applyPatch: %v", err)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "a.txt"))
+	if string(data) != "ONE\ntwo\n" {
+		t.Errorf("a.txt = %q", data)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "c.txt"))
+	if string(data) != "new\n" {
+		t.Errorf("c.txt = %q", data)
+	}
+}
+
+func TestApplyPatchNoNewline(t *testing.T) {
+	dir := t.TempDir()
+	e := &toolTypes.Executor{WorkPath: dir}
+	path := filepath.Join(dir, "a.txt")
+
+	tests := []struct {
+		name  string
+		input string
+		patch string
+		want  string
+	}{
+		{
+			name:  "both sides without newline",
+			input: "old",
+			patch: "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-old\n\\ No newline at end of file\n+new\n\\ No newline at end of file\n",
+			want:  "new",
+		},
+		{
+			name:  "newline added",
+			input: "old",
+			patch: "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-old\n\\ No newline at end of file\n+new\n",
+			want:  "new\n",
+		},
+		{
+			name:  "newline removed",
+			input: "keep\nold\n",
+			patch: "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n keep\n-old\n+new\n\\ No newline at end of file\n",
+			want:  "keep\nnew",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(path, []byte(tt.input), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := applyPatch(e, tt.patch); err != nil {
+				t.Fatalf("applyPatch: %v", err)
+			}
+			data, _ := os.ReadFile(path)
+			if string(data) != tt.want {
+				t.Errorf("got %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPatchFiles(t *testing.T) {
+	dir := t.TempDir()
+	e := &toolTypes.Executor{WorkPath: dir}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return "<missing>"
+		}
+		return string(data)
+	}
+	os.WriteFile(filepath.Join(dir, "old.txt"), []byte("a\nb\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "two.txt"), []byte("1\n2\n3\n"), 0644)
+
+	rename := "--- a/old.txt\n+++ b/new.txt\n@@ -1,2 +1,2 @@\n a\n-b\n+B\n"
+	if _, err := applyPatch(e, rename); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if read("new.txt") != "a\nB\n" || read("old.txt") != "<missing>" {
+		t.Errorf("rename: new=%q old=%q", read("new.txt"), read("old.txt"))
+	}
+
+	// * the second section of a file applies on top of the first
+	sections := "--- a/two.txt\n+++ b/two.txt\n@@ -1 +1 @@\n-1\n+one\n--- a/two.txt\n+++ b/two.txt\n@@ -3 +3 @@\n-3\n+three\n"
+	if _, err := applyPatch(e, sections); err != nil {
+		t.Fatalf("sections: %v", err)
+	}
+	if read("two.txt") != "one\n2\nthree\n" {
+		t.Errorf("sections: %q", read("two.txt"))
+	}
+
+	create := "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+clobbered\n"
+	if _, err := applyPatch(e, create); err == nil {
+		t.Error("creating an existing file should fail")
+	}
+	if read("new.txt") != "a\nB\n" {
+		t.Errorf("existing file changed: %q", read("new.txt"))
+	}
+}
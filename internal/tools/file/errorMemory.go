@@ -4,10 +4,12 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -219,3 +221,91 @@ func searchFile(toolName, keyword string, limit int) (string, error) {
 
 	return formatRecords(matched, limit), nil
 }
+
+// * ListErrorMemory returns every stored record by tool name
+func ListErrorMemory() map[string][]ErrorMemory {
+	byTool := make(map[string][]ErrorMemory)
+	paths, _ := filepath.Glob(filepath.Join(filesystem.ErrorsDir, "*.json"))
+	for _, path := range paths {
+		if filepath.Base(path) == "errors.json" {
+			continue
+		}
+		if records := getErrorMemory(path); len(records) > 0 {
+			byTool[strings.TrimSuffix(filepath.Base(path), ".json")] = records
+		}
+	}
+	return byTool
+}
+
+// * ValidateErrorMemory checks records edited or imported by hand, every problem is reported
+func ValidateErrorMemory(byTool map[string][]ErrorMemory) error {
+	var errs []error
+	for tool, records := range byTool {
+		if tool == "" || tool == "errors" || filepath.Base(tool) != tool {
+			errs = append(errs, fmt.Errorf("invalid tool name %q", tool))
+			continue
+		}
+		for i, record := range records {
+			prefix := fmt.Sprintf("%s[%d]", tool, i)
+			if record.ToolName != tool {
+				errs = append(errs, fmt.Errorf("%s.tool_name: must be %q", prefix, tool))
+			}
+			if strings.TrimSpace(record.Symptom) == "" {
+				errs = append(errs, fmt.Errorf("%s.symptom: required", prefix))
+			}
+			if strings.TrimSpace(record.Action) == "" {
+				errs = append(errs, fmt.Errorf("%s.action: required", prefix))
+			}
+		}
+	}
+	slices.SortFunc(errs, func(a, b error) int {
+		return strings.Compare(a.Error(), b.Error())
+	})
+	return errors.Join(errs...)
+}
+
+// * ReplaceErrorMemory writes byTool as the whole error memory, tools left out are removed
+func ReplaceErrorMemory(byTool map[string][]ErrorMemory) error {
+	now := time.Now()
+	for tool := range ListErrorMemory() {
+		if _, ok := byTool[tool]; ok {
+			continue
+		}
+		if err := os.Remove(filepath.Join(filesystem.ErrorsDir, tool+".json")); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("os.Remove: %w", err)
+		}
+	}
+
+	index := make(map[string]ErrorMemoryItem)
+	for tool, records := range byTool {
+		if len(records) == 0 {
+			os.Remove(filepath.Join(filesystem.ErrorsDir, tool+".json"))
+			continue
+		}
+		for i := range records {
+			// * hand-written records get an ID and a time like remember_error ones
+			if records[i].ID == "" {
+				h := sha256.Sum256([]byte(tool + strconv.FormatInt(now.UnixNano(), 10) + strconv.Itoa(i)))
+				records[i].ID = hex.EncodeToString(h[:])
+			}
+			if records[i].Timestamp == 0 {
+				records[i].Timestamp = now.Unix()
+			}
+		}
+		data, err := json.Marshal(records)
+		if err != nil {
+			return fmt.Errorf("json.Marshal: %w", err)
+		}
+		if err := filesystem.WriteFile(filepath.Join(filesystem.ErrorsDir, tool+".json"), string(data), 0644); err != nil {
+			return fmt.Errorf("filesystem.WriteFile: %w", err)
+		}
+		index[tool] = ErrorMemoryItem{
+			Count:       len(records),
+			LastUpdated: now.Unix(),
+		}
+	}
+	if err := writeErrorList(filesystem.ErrorsDir, index); err != nil {
+		return fmt.Errorf("writeErrorList: %w", err)
+	}
+	return nil
+}
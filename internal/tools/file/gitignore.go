@@ -0,0 +1,187 @@
+package file
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+type ignoreRule struct {
+	regex   *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// * rules are keyed by the relative dir of the .gitignore, "." for root
+type gitignore struct {
+	mu    sync.RWMutex
+	root  string
+	rules map[string][]ignoreRule
+}
+
+func newGitignore(root string) *gitignore {
+	g := &gitignore{
+		root:  root,
+		rules: make(map[string][]ignoreRule),
+	}
+	g.load(".")
+	return g
+}
+
+// * load reads .gitignore in dir, must be called before walking into dir
+func (g *gitignore) load(dir string) {
+	file, err := os.Open(filepath.Join(g.root, filepath.FromSlash(dir), ".gitignore"))
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return
+	}
+
+	g.mu.Lock()
+	g.rules[dir] = rules
+	g.mu.Unlock()
+}
+
+// * rel is slash separated path relative to root
+func (g *gitignore) isIgnored(rel string, isDir bool) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	// * parent ignored means child ignored, git does not re-include under ignored dir
+	dirs := ancestorDirs(rel)
+	ignored := false
+	for _, dir := range dirs {
+		rules, ok := g.rules[dir]
+		if !ok {
+			continue
+		}
+		sub := rel
+		if dir != "." {
+			sub = strings.TrimPrefix(rel, dir+"/")
+		}
+		for _, rule := range rules {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.regex.MatchString(sub) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+func ancestorDirs(rel string) []string {
+	dirs := []string{"."}
+	dir := path.Dir(rel)
+	if dir == "." {
+		return dirs
+	}
+	parts := strings.Split(dir, "/")
+	for i := range parts {
+		dirs = append(dirs, strings.Join(parts[:i+1], "/"))
+	}
+	return dirs
+}
+
+func parseIgnoreRule(raw string) (ignoreRule, bool) {
+	line := strings.TrimRight(raw, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// * pattern with slash (except trailing) is anchored to the .gitignore dir
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+	sb.WriteString(globToRegex(line))
+	sb.WriteString("$")
+
+	regex, err := regexp.Compile(sb.String())
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.regex = regex
+	return rule, true
+}
+
+func globToRegex(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				switch {
+				// * "**/" matches zero or more dirs
+				case i+2 < len(glob) && glob[i+2] == '/':
+					sb.WriteString("(?:.*/)?")
+					i += 2
+				// * trailing "/**" matches everything inside
+				default:
+					sb.WriteString(".*")
+					i++
+				}
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end == -1 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
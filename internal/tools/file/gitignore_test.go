@@ -0,0 +1,53 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitignore(t *testing.T) {
+	root := t.TempDir()
+	rootRules := "# comment\n*.log\n!keep.log\n/build\nnode_modules/\ndocs/**/*.tmp\n"
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte(rootRules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "sub", ".gitignore"), []byte("secret.txt\n/local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := newGitignore(root)
+	g.load("sub")
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"app.log", false, true},
+		{"deep/dir/app.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"src/build", true, false},
+		{"node_modules", true, true},
+		{"node_modules", false, false},
+		{"a/node_modules", true, true},
+		{"docs/x.tmp", false, true},
+		{"docs/a/b/x.tmp", false, true},
+		{"x.tmp", false, false},
+		{"sub/secret.txt", false, true},
+		{"sub/deeper/secret.txt", false, true},
+		{"secret.txt", false, false},
+		{"sub/local", true, true},
+		{"sub/a/local", true, false},
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := g.isIgnored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("isIgnored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
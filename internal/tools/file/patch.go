@@ -9,7 +9,7 @@ import (
 	toolTypes "github.com/pardnchiu/agenvoy/internal/tools/types"
 )
 
-func patch(e *toolTypes.Executor, path, oldString, newString string) (string, error) {
+func patch(e *toolTypes.Executor, path, oldString, newString string, replaceAll bool) (string, error) {
 	fullPath, err := getFullPath(e, path)
 	if err != nil {
 		return "", err
@@ -25,14 +25,21 @@ func patch(e *toolTypes.Executor, path, oldString, newString string) (string, er
 	}
 
 	content := string(data)
-	if !strings.Contains(content, oldString) {
+	count := strings.Count(content, oldString)
+	if oldString == "" || count == 0 {
 		return "", fmt.Errorf("old_string not found in file: %s", path)
 	}
 
-	newContent := strings.Replace(content, oldString, newString, 1)
+	var newContent string
+	if replaceAll {
+		newContent = strings.ReplaceAll(content, oldString, newString)
+	} else {
+		newContent = strings.Replace(content, oldString, newString, 1)
+		count = 1
+	}
 	if err := filesystem.WriteFile(fullPath, newContent, 0644); err != nil {
 		return "", fmt.Errorf("utils.WriteFile: %w", err)
 	}
 
-	return fmt.Sprintf("Successfully patched: %s", path), nil
+	return fmt.Sprintf("Successfully patched: %s (%d replaced)", path, count), nil
 }
@@ -29,6 +29,10 @@ var DeniedConfig = func() deniedConfig {
 	return cfg
 }()
 
+func IsDenied(path string) bool {
+	return isDenied(path)
+}
+
 func isDenied(path string) bool {
 	cleaned := filepath.Clean(path)
 	base := filepath.Base(cleaned)
@@ -56,7 +60,7 @@ func isDenied(path string) bool {
 	return false
 }
 
-func read(e *toolTypes.Executor, path string) (string, error) {
+func read(e *toolTypes.Executor, path string, offset, limit int) (string, error) {
 	fullPath, err := getFullPath(e, path)
 	if err != nil {
 		return "", err
@@ -70,6 +74,31 @@ func read(e *toolTypes.Executor, path string) (string, error) {
 		return "", fmt.Errorf("path is excluded: %s", path)
 	}
 
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file (%s): %w", path, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("is a directory, use list_files instead: %s", path)
+	}
+
+	mime, binary, err := detectFile(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file (%s): %w", path, err)
+	}
+	if binary {
+		return readMedia(e, path, fullPath, mime, info.Size())
+	}
+
+	if offset > 0 || limit > 0 {
+		return readRange(fullPath, offset, limit)
+	}
+
+	// * large text files only return head and tail, rest is read by range
+	if info.Size() > maxReadBytes {
+		return readPreview(fullPath, info.Size())
+	}
+
 	data, err := os.ReadFile(fullPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file (%s): %w", path, err)
@@ -77,6 +106,10 @@ func read(e *toolTypes.Executor, path string) (string, error) {
 	return string(data), nil
 }
 
+func GetFullPath(e *toolTypes.Executor, path string) (string, error) {
+	return getFullPath(e, path)
+}
+
 func getFullPath(e *toolTypes.Executor, path string) (string, error) {
 	if !filepath.IsAbs(path) {
 		return filepath.Join(e.WorkPath, path), nil
@@ -89,6 +122,10 @@ func getFullPath(e *toolTypes.Executor, path string) (string, error) {
 	return cleaned, nil
 }
 
+func IsExclude(e *toolTypes.Executor, path string) bool {
+	return isExclude(e, path)
+}
+
 func isExclude(e *toolTypes.Executor, path string) bool {
 	excluded := false
 	for _, e := range e.Exclude {
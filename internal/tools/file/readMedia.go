@@ -0,0 +1,74 @@
+package file
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	toolTypes "github.com/pardnchiu/agenvoy/internal/tools/types"
+	"github.com/pardnchiu/agenvoy/internal/utils"
+)
+
+const (
+	// * over this size, image is not attached for vision input
+	maxImageBytes = 5 << 20
+)
+
+var visionMimes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+func isMedia(mime string) bool {
+	return mime == "application/pdf" || strings.HasPrefix(mime, "image/")
+}
+
+func readMedia(e *toolTypes.Executor, path, fullPath, mime string, size int64) (string, error) {
+	switch {
+	case mime == "application/pdf":
+		return readPDF(path, fullPath, size)
+
+	// * non-vision agents reject image parts, they get the binary file text below
+	case visionMimes[mime] && e.Vision:
+		if size > maxImageBytes {
+			return fmt.Sprintf("image file (%s, %s) is too large to attach, limit is %s", mime, formatSize(size), formatSize(maxImageBytes)), nil
+		}
+		data, err := os.ReadFile(fullPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file (%s): %w", path, err)
+		}
+		// * image is sent as image_url part after tool results, tool message only accepts text
+		e.Images = append(e.Images, fmt.Sprintf("data:%s;base64,%s", mime, base64.StdEncoding.EncodeToString(data)))
+		return fmt.Sprintf("image file (%s, %s) attached as image input: %s", mime, formatSize(size), path), nil
+
+	default:
+		return fmt.Sprintf("binary file (%s, %s), content is not readable as text: %s", mime, formatSize(size), path), nil
+	}
+}
+
+func readPDF(path, fullPath string, size int64) (string, error) {
+	bin, err := exec.LookPath("pdftotext")
+	if err != nil {
+		return fmt.Sprintf("PDF file (application/pdf, %s), pdftotext is not installed to extract text: %s", formatSize(size), path), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, bin, "-layout", "-enc", "UTF-8", fullPath, "-").Output()
+	if err != nil {
+		return "", fmt.Errorf("pdftotext (%s): %w", path, err)
+	}
+
+	text := strings.TrimSpace(string(out))
+	if text == "" {
+		return fmt.Sprintf("PDF file (application/pdf, %s) has no extractable text, may be scanned images: %s", formatSize(size), path), nil
+	}
+	return utils.TruncateUTF8(text, maxReadBytes), nil
+}
@@ -0,0 +1,174 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// * over this size, read_file without range only returns head and tail
+	maxReadBytes = 256 << 10
+	// * default line count when only offset is given
+	defaultReadLimit = 2000
+	// * line count of head and tail in preview
+	previewLines = 100
+	// * single line over this is cut, avoid minified files blow up the context
+	maxLineBytes = 2000
+)
+
+func detectFile(path string) (string, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 8192)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", false, err
+	}
+	buf = buf[:n]
+
+	mime := http.DetectContentType(buf)
+	if idx := strings.Index(mime, ";"); idx != -1 {
+		mime = mime[:idx]
+	}
+	return mime, isMedia(mime) || isBinary(buf), nil
+}
+
+func isBinary(buf []byte) bool {
+	if len(buf) == 0 {
+		return false
+	}
+	if bytes.IndexByte(buf, 0) != -1 {
+		return true
+	}
+	// * allow cut at the end of buffer in the middle of a rune
+	check := buf
+	for i := 0; i < utf8.UTFMax && len(check) > 0 && !utf8.Valid(check); i++ {
+		check = check[:len(check)-1]
+	}
+	return !utf8.Valid(check)
+}
+
+func readRange(path string, offset, limit int) (string, error) {
+	if offset < 1 {
+		offset = 1
+	}
+	if limit <= 0 {
+		limit = defaultReadLimit
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("os.Open: %w", err)
+	}
+	defer f.Close()
+
+	var sb strings.Builder
+	reader := bufio.NewReader(f)
+	lineNo := 0
+	end := offset + limit - 1
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			lineNo++
+			if lineNo >= offset && lineNo <= end {
+				writeNumberedLine(&sb, lineNo, line)
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", fmt.Errorf("reader.ReadString: %w", err)
+		}
+	}
+
+	if offset > lineNo {
+		return fmt.Sprintf("offset %d is over total lines: %d", offset, lineNo), nil
+	}
+	if end < lineNo {
+		sb.WriteString(fmt.Sprintf("\n... (lines %d-%d of %d, use offset=%d to continue)\n", offset, end, lineNo, end+1))
+	}
+	return sb.String(), nil
+}
+
+func readPreview(path string, size int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("os.Open: %w", err)
+	}
+	defer f.Close()
+
+	var head []string
+	tail := make([]string, 0, previewLines)
+	reader := bufio.NewReader(f)
+	lineNo := 0
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			lineNo++
+			if len(head) < previewLines {
+				head = append(head, line)
+			} else {
+				if len(tail) == previewLines {
+					tail = tail[1:]
+				}
+				tail = append(tail, line)
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", fmt.Errorf("reader.ReadString: %w", err)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("file is too large (%s, %d lines), showing first and last %d lines. use offset/limit to read a range.\n\n", formatSize(size), lineNo, previewLines))
+	for i, line := range head {
+		writeNumberedLine(&sb, i+1, line)
+	}
+	if len(tail) > 0 {
+		start := lineNo - len(tail) + 1
+		if start > len(head)+1 {
+			sb.WriteString(fmt.Sprintf("\n... (%d lines omitted) ...\n\n", start-len(head)-1))
+		}
+		for i, line := range tail {
+			writeNumberedLine(&sb, start+i, line)
+		}
+	}
+	return sb.String(), nil
+}
+
+func writeNumberedLine(sb *strings.Builder, lineNo int, line string) {
+	line = strings.TrimRight(line, "\r\n")
+	if len(line) > maxLineBytes {
+		end := maxLineBytes
+		for end > 0 && !utf8.RuneStart(line[end]) {
+			end--
+		}
+		line = line[:end] + "...(line truncated)"
+	}
+	sb.WriteString(fmt.Sprintf("%6d\t%s\n", lineNo, line))
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
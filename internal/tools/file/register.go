@@ -12,12 +12,14 @@ import (
 func init() {
 	toolRegister.Register("read_file", func(_ context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
 		var params struct {
-			Path string `json:"path"`
+			Path   string `json:"path"`
+			Offset int    `json:"offset"`
+			Limit  int    `json:"limit"`
 		}
 		if err := json.Unmarshal(args, &params); err != nil {
 			return "", fmt.Errorf("json.Unmarshal: %w", err)
 		}
-		return read(e, params.Path)
+		return read(e, params.Path, params.Offset, params.Limit)
 	})
 
 	toolRegister.Register("list_files", func(_ context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
@@ -44,26 +46,59 @@ func init() {
 		return glob(e, params.Pattern)
 	})
 
-	toolRegister.Register("search_content", func(_ context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
+	toolRegister.Register("search_content", func(ctx context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
 		var params struct {
-			Pattern     string `json:"pattern"`
-			FilePattern string `json:"file_pattern"`
+			Pattern         string `json:"pattern"`
+			FilePattern     string `json:"file_pattern"`
+			CaseInsensitive bool   `json:"case_insensitive"`
+			Context         int    `json:"context"`
+			Before          int    `json:"before_context"`
+			After           int    `json:"after_context"`
+			MaxPerFile      int    `json:"max_per_file"`
+			MaxResults      int    `json:"max_results"`
+			FilesOnly       bool   `json:"files_only"`
 		}
 		if err := json.Unmarshal(args, &params); err != nil {
 			return "", fmt.Errorf("json.Unmarshal: %w", err)
 		}
-		return search(e, params.Pattern, params.FilePattern)
+		if params.Before == 0 {
+			params.Before = params.Context
+		}
+		if params.After == 0 {
+			params.After = params.Context
+		}
+		return search(ctx, e, searchOption{
+			Pattern:         params.Pattern,
+			FilePattern:     params.FilePattern,
+			CaseInsensitive: params.CaseInsensitive,
+			Before:          params.Before,
+			After:           params.After,
+			MaxPerFile:      params.MaxPerFile,
+			MaxResults:      params.MaxResults,
+			FilesOnly:       params.FilesOnly,
+		})
 	})
 
 	toolRegister.Register("search_history", func(_ context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
 		var params struct {
 			Keyword   string `json:"keyword"`
 			TimeRange string `json:"time_range"`
+			Since     string `json:"since"`
+			Until     string `json:"until"`
+			Scope     string `json:"scope"`
+			Limit     int    `json:"limit"`
 		}
 		if err := json.Unmarshal(args, &params); err != nil {
 			return "", fmt.Errorf("json.Unmarshal: %w", err)
 		}
-		return searchHistory(e.SessionID, params.Keyword, params.TimeRange)
+		return searchHistory(e.SessionID, e.Principal, searchHistoryParams{
+			Keyword:   params.Keyword,
+			TimeRange: params.TimeRange,
+			Since:     params.Since,
+			Until:     params.Until,
+			Scope:     params.Scope,
+			Limit:     params.Limit,
+		})
 	})
 
 	toolRegister.Register("write_file", func(_ context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
@@ -93,9 +128,10 @@ func init() {
 
 	toolRegister.Register("patch_edit", func(_ context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
 		var params struct {
-			Path      string `json:"path"`
-			OldString string `json:"old_string"`
-			NewString string `json:"new_string"`
+			Path       string `json:"path"`
+			OldString  string `json:"old_string"`
+			NewString  string `json:"new_string"`
+			ReplaceAll bool   `json:"replace_all"`
 		}
 		if err := json.Unmarshal(args, &params); err != nil {
 			return "", fmt.Errorf("json.Unmarshal: %w", err)
@@ -103,7 +139,17 @@ func init() {
 		if isDenied(params.Path) {
 			return "", fmt.Errorf("access denied: %s", params.Path)
 		}
-		return patch(e, params.Path, params.OldString, params.NewString)
+		return patch(e, params.Path, params.OldString, params.NewString, params.ReplaceAll)
+	})
+
+	toolRegister.Register("apply_patch", func(_ context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
+		var params struct {
+			Patch string `json:"patch"`
+		}
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		return applyPatch(e, params.Patch)
 	})
 
 	toolRegister.Register("get_tool_error", func(_ context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
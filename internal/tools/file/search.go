@@ -1,97 +1,286 @@
 package file
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	toolTypes "github.com/pardnchiu/agenvoy/internal/tools/types"
 )
 
-func search(e *toolTypes.Executor, pattern, filePattern string) (string, error) {
+const (
+	// * limited to 1MB
+	maxSearchBytes   = 1 << 20
+	defaultMaxResult = 200
+	defaultMaxFile   = 50
+	maxContextLines  = 10
+)
+
+var skippedExts = map[string]bool{
+	".exe":   true,
+	".bin":   true,
+	".so":    true,
+	".dylib": true,
+	".dll":   true,
+	".o":     true,
+	".a":     true,
+}
+
+type searchOption struct {
+	Pattern         string
+	FilePattern     string
+	CaseInsensitive bool
+	Before          int
+	After           int
+	MaxPerFile      int
+	MaxResults      int
+	FilesOnly       bool
+}
+
+type searchMatch struct {
+	path  string
+	lines []string
+	hits  []int
+}
+
+func search(ctx context.Context, e *toolTypes.Executor, opt searchOption) (string, error) {
+	pattern := opt.Pattern
+	if opt.CaseInsensitive {
+		pattern = "(?i)" + pattern
+	}
 	re, err := regexp.Compile(pattern)
 	if err != nil {
-		return "", fmt.Errorf("failed to compile regex pattern (%s): %w", pattern, err)
+		return "", fmt.Errorf("failed to compile regex pattern (%s): %w", opt.Pattern, err)
 	}
 
+	opt.Before = min(max(opt.Before, 0), maxContextLines)
+	opt.After = min(max(opt.After, 0), maxContextLines)
+	if opt.MaxResults <= 0 {
+		opt.MaxResults = defaultMaxResult
+	}
+	if opt.MaxPerFile <= 0 {
+		opt.MaxPerFile = defaultMaxFile
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	paths := make(chan string, 256)
+	var walkErr error
+	go func() {
+		defer close(paths)
+		walkErr = walkSearch(ctx, e, opt.FilePattern, paths)
+	}()
+
+	var (
+		mu      sync.Mutex
+		matches []searchMatch
+		total   atomic.Int64
+		wg      sync.WaitGroup
+	)
+	for range runtime.NumCPU() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				if ctx.Err() != nil {
+					continue
+				}
+				m, ok := searchInFile(re, e.WorkPath, path, opt)
+				if !ok {
+					continue
+				}
+				if total.Add(int64(len(m.hits))) >= int64(opt.MaxResults) {
+					cancel()
+				}
+				mu.Lock()
+				matches = append(matches, m)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if walkErr != nil && walkErr != context.Canceled {
+		return "", fmt.Errorf("failed to walk directory (%s): %w", opt.Pattern, walkErr)
+	}
+
+	if len(matches) == 0 {
+		return fmt.Sprintf("No fils found: %s", opt.Pattern), nil
+	}
+
+	// * workers finish in random order, keep output stable
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].path < matches[j].path
+	})
+
 	var result strings.Builder
+	remain := opt.MaxResults
+	for i, m := range matches {
+		if remain <= 0 {
+			break
+		}
+		hits := m.hits[:min(len(m.hits), remain)]
+		remain -= len(hits)
+		if opt.FilesOnly {
+			result.WriteString(fmt.Sprintf("%s (%d)\n", m.path, len(hits)))
+			continue
+		}
+		if i > 0 && (opt.Before > 0 || opt.After > 0) {
+			result.WriteString("--\n")
+		}
+		result.WriteString(formatMatch(m, hits, opt))
+		if len(m.hits) >= opt.MaxPerFile && len(hits) == len(m.hits) {
+			result.WriteString(fmt.Sprintf("%s: ... (per-file limit %d reached)\n", m.path, opt.MaxPerFile))
+		}
+	}
+	if total.Load() >= int64(opt.MaxResults) {
+		result.WriteString(fmt.Sprintf("... (result limit %d reached, narrow the pattern or use file_pattern)\n", opt.MaxResults))
+	}
+	return result.String(), nil
+}
 
-	err = filepath.Walk(e.WorkPath, func(path string, d os.FileInfo, err error) error {
+func walkSearch(ctx context.Context, e *toolTypes.Executor, filePattern string, paths chan<- string) error {
+	ignore := newGitignore(e.WorkPath)
+	return filepath.WalkDir(e.WorkPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			slog.Warn("failed to access path, just skipping",
 				slog.String("error", err.Error()))
 			return nil
 		}
-
-		if strings.HasPrefix(filepath.Base(path), ".") {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if path == e.WorkPath {
 			return nil
 		}
 
-		if d.IsDir() {
+		rel, err := filepath.Rel(e.WorkPath, path)
+		if err != nil {
 			return nil
 		}
+		rel = filepath.ToSlash(rel)
 
-		// * limited to 1MB
-		if d.Size() > 1<<20 {
+		if strings.HasPrefix(d.Name(), ".") ||
+			isExclude(e, path) ||
+			ignore.isIgnored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
-		ext := filepath.Ext(path)
-		exts := map[string]bool{
-			".exe":   true,
-			".bin":   true,
-			".so":    true,
-			".dylib": true,
-			".dll":   true,
-			".o":     true,
-			".a":     true,
+		if d.IsDir() {
+			ignore.load(rel)
+			return nil
 		}
-		if exts[ext] {
+
+		if !d.Type().IsRegular() || skippedExts[filepath.Ext(path)] || isDenied(path) {
 			return nil
 		}
 
 		if filePattern != "" {
-			matched, err := filepath.Match(filePattern, filepath.Base(path))
+			matched, err := filepath.Match(filePattern, d.Name())
 			if err != nil {
 				slog.Warn("failed to match pattern",
 					slog.String("error", err.Error()))
 			}
-			if !matched {
+			if !matched && !matchFiles(strings.Split(filepath.ToSlash(filePattern), "/"), strings.Split(rel, "/")) {
 				return nil
 			}
 		}
 
-		data, err := os.ReadFile(path)
-		if err != nil {
-			// slog.Warn("failed to read file during search",
-			// 	slog.String("error", err.Error()))
-			return nil
+		select {
+		case paths <- path:
+		case <-ctx.Done():
+			return ctx.Err()
 		}
+		return nil
+	})
+}
 
-		lines := strings.Split(string(data), "\n")
-		relPath, err := filepath.Rel(e.WorkPath, path)
-		if err != nil {
-			slog.Warn("failed to get relative path",
-				slog.String("error", err.Error()))
-			return nil
-		}
+func searchInFile(re *regexp.Regexp, root, path string, opt searchOption) (searchMatch, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxSearchBytes {
+		return searchMatch{}, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return searchMatch{}, false
+	}
+	// * skip binary files
+	if bytes.IndexByte(data[:min(len(data), 8192)], 0) != -1 {
+		return searchMatch{}, false
+	}
 
-		for i, line := range lines {
-			if re.MatchString(line) {
-				result.WriteString(fmt.Sprintf("%s:%d: %s\n", relPath, i+1, strings.TrimSpace(line)))
+	relPath, err := filepath.Rel(root, path)
+	if err != nil {
+		slog.Warn("failed to get relative path",
+			slog.String("error", err.Error()))
+		return searchMatch{}, false
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), maxSearchBytes)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	var hits []int
+	for i, line := range lines {
+		if re.MatchString(line) {
+			hits = append(hits, i)
+			if len(hits) >= opt.MaxPerFile {
+				break
 			}
 		}
-		return nil
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to walk directory (%s): %w", pattern, err)
 	}
+	if len(hits) == 0 {
+		return searchMatch{}, false
+	}
+
+	return searchMatch{
+		path:  relPath,
+		lines: lines,
+		hits:  hits,
+	}, true
+}
 
-	if result.Len() == 0 {
-		return fmt.Sprintf("No fils found: %s", pattern), nil
+func formatMatch(m searchMatch, hits []int, opt searchOption) string {
+	var sb strings.Builder
+	hitSet := make(map[int]bool, len(hits))
+	for _, h := range hits {
+		hitSet[h] = true
 	}
-	return result.String(), nil
+	last := -1
+	for _, h := range hits {
+		start := max(h-opt.Before, last+1)
+		end := min(h+opt.After, len(m.lines)-1)
+		if last >= 0 && start > last+1 && (opt.Before > 0 || opt.After > 0) {
+			sb.WriteString("--\n")
+		}
+		for i := start; i <= end; i++ {
+			if hitSet[i] {
+				sb.WriteString(fmt.Sprintf("%s:%d: %s\n", m.path, i+1, strings.TrimSpace(m.lines[i])))
+			} else {
+				sb.WriteString(fmt.Sprintf("%s-%d- %s\n", m.path, i+1, strings.TrimSpace(m.lines[i])))
+			}
+		}
+		last = max(last, end)
+	}
+	return sb.String()
 }
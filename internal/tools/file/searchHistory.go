@@ -1,103 +1,92 @@
 package file
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
 
-	"github.com/pardnchiu/agenvoy/internal/filesystem"
+	"github.com/pardnchiu/agenvoy/internal/history"
 )
 
-type historyEntry struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
+const (
+	historyDefaultLimit = 10
+	historyMaxLimit     = 50
+	// * the newest messages are still in the conversation, matching them only repeats it
+	historySkipRecent = 5
+)
 
-var historyTimeRanges = map[string]time.Duration{
-	"1d": 24 * time.Hour,
-	"7d": 7 * 24 * time.Hour,
-	"1m": 30 * 24 * time.Hour,
-	"1y": 365 * 24 * time.Hour,
+type searchHistoryParams struct {
+	Keyword   string
+	TimeRange string
+	Since     string
+	Until     string
+	Scope     string
+	Limit     int
 }
 
-func extractSec(content string) (int64, string) {
-	if !strings.HasPrefix(content, "ts:") {
-		return 0, content
-	}
-	rest := content[3:]
-	idx := strings.IndexByte(rest, '\n')
-	if idx < 0 {
-		return 0, content
-	}
-	ts, err := strconv.ParseInt(rest[:idx], 10, 64)
-	if err != nil {
-		return 0, content
-	}
-	return ts, rest[idx+1:]
-}
-
-func searchHistory(sessionID, keyword, timeRange string) (string, error) {
-	const limit = 10
-	if keyword == "" {
+// * searchHistory ranks messages and tool calls of the current session, or of every session for scope all,
+// * which only the CLI may use, chat users must not read each other's sessions
+func searchHistory(sessionID, principal string, params searchHistoryParams) (string, error) {
+	if strings.TrimSpace(params.Keyword) == "" {
 		return "", fmt.Errorf("keyword is required")
 	}
-	if sessionID == "" {
-		return "", fmt.Errorf("sessionID is required")
-	}
-
-	// configDir, err := utils.GetConfigDir("sessions")
-	// if err != nil {
-	// 	return "", fmt.Errorf("utils.ConfigDir: %w", err)
-	// }
-
-	historyPath := filepath.Join(filesystem.SessionsDir, sessionID, "history.json")
 
-	data, err := os.ReadFile(historyPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return "No history found for current session", nil
+	query := history.Query{
+		Text:       params.Keyword,
+		Limit:      historyDefaultLimit,
+		Current:    sessionID,
+		SkipRecent: historySkipRecent,
+	}
+	switch params.Scope {
+	case "", "session":
+		if sessionID == "" {
+			return "", fmt.Errorf("sessionID is required")
+		}
+		query.Session = sessionID
+	case "all":
+		if principal != "" {
+			return "", fmt.Errorf("scope all is only available in the CLI")
 		}
-		return "", fmt.Errorf("failed to read history file (%s): %w", historyPath, err)
+	default:
+		return "", fmt.Errorf("invalid scope: %s", params.Scope)
 	}
-
-	var entries []historyEntry
-	if err := json.Unmarshal(data, &entries); err != nil {
-		return "", fmt.Errorf("failed to parse history file: %w", err)
+	if params.Limit > 0 {
+		query.Limit = min(params.Limit, historyMaxLimit)
 	}
 
-	var after int64
-	if d, ok := historyTimeRanges[timeRange]; ok {
-		after = time.Now().Add(-d).Unix()
+	if params.TimeRange != "" {
+		d, ok := history.Ranges[params.TimeRange]
+		if !ok {
+			return "", fmt.Errorf("invalid time_range: %s", params.TimeRange)
+		}
+		query.Since = time.Now().Add(-d)
 	}
-
-	lower := strings.ToLower(keyword)
-	var matches []historyEntry
-
-	for i := len(entries) - 5; i >= 0; i-- {
-		entry := entries[i]
-		ts, body := extractSec(entry.Content)
-		if after > 0 && ts > 0 && ts < after {
-			continue
+	if params.Since != "" {
+		since, err := history.ParseTime(params.Since, false)
+		if err != nil {
+			return "", fmt.Errorf("since: %w", err)
 		}
-		if strings.Contains(strings.ToLower(body), lower) {
-			matches = append(matches, entry)
-			if len(matches) >= limit {
-				break
-			}
+		query.Since = since
+	}
+	if params.Until != "" {
+		until, err := history.ParseTime(params.Until, true)
+		if err != nil {
+			return "", fmt.Errorf("until: %w", err)
 		}
+		query.Until = until
 	}
 
-	if len(matches) == 0 {
-		return fmt.Sprintf("No matches found for keyword: %s", keyword), nil
+	hits, err := history.Search(query)
+	if err != nil {
+		return "", fmt.Errorf("history.Search: %w", err)
+	}
+	if len(hits) == 0 {
+		return fmt.Sprintf("No matches found for keyword: %s", params.Keyword), nil
 	}
 
 	var result strings.Builder
-	for _, m := range matches {
-		result.WriteString(fmt.Sprintf("[%s] %s\n", m.Role, m.Content))
+	for _, hit := range hits {
+		result.WriteString(hit.String() + "\n")
 	}
 	return result.String(), nil
 }
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/pardnchiu/agenvoy/internal/filesystem"
+	"github.com/pardnchiu/agenvoy/internal/scheduler"
 	toolTypes "github.com/pardnchiu/agenvoy/internal/tools/types"
 )
 
@@ -35,8 +36,9 @@ func write(e *toolTypes.Executor, path, content string) (string, error) {
 
 func writeScript(name, content string) (string, error) {
 	ext := strings.ToLower(filepath.Ext(name))
-	if ext != ".sh" && ext != ".py" {
-		return "", fmt.Errorf("scripts only support .sh or .py")
+	firstLine, _, _ := strings.Cut(content, "\n")
+	if _, err := scheduler.Interpreter(name, firstLine); err != nil {
+		return "", fmt.Errorf("scheduler.Interpreter: %w", err)
 	}
 	if filepath.Base(name) != name {
 		return "", fmt.Errorf("must not contain path separator")
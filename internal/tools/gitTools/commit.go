@@ -0,0 +1,126 @@
+package gitTools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pardnchiu/agenvoy/internal/tools/file"
+)
+
+func commit(ctx context.Context, workDir, message string, paths []string, all bool) (string, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return "", fmt.Errorf("message is required")
+	}
+
+	if len(paths) > 0 {
+		for _, p := range paths {
+			if err := checkArg("path", p); err != nil {
+				return "", err
+			}
+			if file.IsDenied(p) {
+				return "", fmt.Errorf("access denied: %s", p)
+			}
+		}
+		if _, err := run(ctx, workDir, append([]string{"add", "--"}, paths...)...); err != nil {
+			return "", err
+		}
+	}
+
+	// * whatever ends up in the commit is checked, including files staged earlier and --all
+	if err := checkStaged(ctx, workDir, all); err != nil {
+		return "", err
+	}
+
+	args := []string{"commit", "-m", message}
+	if all {
+		args = append(args, "--all")
+	}
+	if _, err := run(ctx, workDir, args...); err != nil {
+		return "", err
+	}
+
+	out, err := run(ctx, workDir, "log", "-1", "--stat", "--format=%H %s")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("committed: %s", strings.TrimSpace(out)), nil
+}
+
+func checkStaged(ctx context.Context, workDir string, all bool) error {
+	lists := [][]string{{"diff", "--cached", "--name-only", "-z"}}
+	if all {
+		lists = append(lists, []string{"diff", "--name-only", "-z"})
+	}
+	for _, args := range lists {
+		out, err := run(ctx, workDir, args...)
+		if err != nil {
+			return err
+		}
+		for _, path := range strings.Split(out, "\x00") {
+			if path != "" && file.IsDenied(path) {
+				return fmt.Errorf("access denied: %s, unstage it before committing", path)
+			}
+		}
+	}
+	return nil
+}
+
+func branch(ctx context.Context, workDir, action, name, base string) (string, error) {
+	if name != "" {
+		if err := checkArg("name", name); err != nil {
+			return "", err
+		}
+	}
+	if base != "" {
+		if err := checkArg("base", base); err != nil {
+			return "", err
+		}
+	}
+
+	switch action {
+	case "", "list":
+		out, err := run(ctx, workDir, "branch", "--all", "--format=%(HEAD) %(refname:short) %(objectname:short) %(upstream:short) %(upstream:track)")
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(out, "\n"), nil
+
+	case "create":
+		if name == "" {
+			return "", fmt.Errorf("name is required")
+		}
+		args := []string{"switch", "-c", name}
+		if base != "" {
+			args = append(args, base)
+		}
+		if _, err := run(ctx, workDir, args...); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("created and switched to branch: %s", name), nil
+
+	case "switch":
+		if name == "" {
+			return "", fmt.Errorf("name is required")
+		}
+		// * no --discard-changes / --force, git refuses when local changes would be lost
+		if _, err := run(ctx, workDir, "switch", name); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("switched to branch: %s", name), nil
+
+	case "delete":
+		if name == "" {
+			return "", fmt.Errorf("name is required")
+		}
+		// * -d only, unmerged branches are refused by git
+		if _, err := run(ctx, workDir, "branch", "-d", name); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("deleted branch: %s", name), nil
+
+	default:
+		return "", fmt.Errorf("unsupported action: %s (force push and reset --hard are not allowed)", action)
+	}
+}
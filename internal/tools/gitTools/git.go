@@ -0,0 +1,123 @@
+package gitTools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/tools/file"
+	"github.com/pardnchiu/agenvoy/internal/utils"
+)
+
+const (
+	gitTimeout = 60 * time.Second
+	// * diff and show output over this is truncated
+	maxOutputBytes = 64 << 10
+)
+
+var (
+	forcePushRegex = regexp.MustCompile(`\bgit\b.*\bpush\b.*(\s--force\b|\s--force-with-lease\b|\s-[a-zA-Z]*f[a-zA-Z]*\b|\s\+\S+)`)
+	resetHardRegex = regexp.MustCompile(`\bgit\b.*\breset\b.*\s--hard\b`)
+	cleanForce     = regexp.MustCompile(`\bgit\b.*\bclean\b.*\s-[a-zA-Z]*f`)
+	statLineRegex  = regexp.MustCompile(`^ (.+?)\s+\|\s`)
+)
+
+// * IsDestructive reports git commands that rewrite remote history or drop local work
+func IsDestructive(command string) (string, bool) {
+	switch {
+	case forcePushRegex.MatchString(command):
+		return "force push", true
+	case resetHardRegex.MatchString(command):
+		return "reset --hard", true
+	case cleanForce.MatchString(command):
+		return "clean -f", true
+	}
+	return "", false
+}
+
+func run(ctx context.Context, workDir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", workDir, "--no-pager"}, args...)...)
+	// * never wait for editor or credential prompt
+	cmd.Env = append(cmd.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_EDITOR=true",
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+func ensureRepo(ctx context.Context, workDir string) error {
+	out, err := run(ctx, workDir, "rev-parse", "--is-inside-work-tree")
+	if err != nil || strings.TrimSpace(out) != "true" {
+		return fmt.Errorf("not a git repository: %s", workDir)
+	}
+	return nil
+}
+
+// * refs and paths come from the model, reject anything git may treat as an option
+func checkArg(name, value string) error {
+	if strings.HasPrefix(value, "-") {
+		return fmt.Errorf("invalid %s: %s", name, value)
+	}
+	return nil
+}
+
+// * checkRef also rejects "rev:path", file contents go through the path parameter and its deny check
+func checkRef(ref string) error {
+	if err := checkArg("ref", ref); err != nil {
+		return err
+	}
+	if strings.Contains(ref, ":") {
+		return fmt.Errorf("invalid ref: %s (use path to read a file at a revision)", ref)
+	}
+	return nil
+}
+
+// * filterDenied drops the patch sections and stat lines of denied files from diff and show output
+func filterDenied(out string) string {
+	var b strings.Builder
+	skip := false
+	for _, line := range strings.SplitAfter(out, "\n") {
+		if header, ok := strings.CutPrefix(line, "diff --git "); ok {
+			oldPath, newPath, _ := strings.Cut(strings.TrimSpace(header), " b/")
+			oldPath = strings.TrimPrefix(oldPath, "a/")
+			skip = file.IsDenied(oldPath) || file.IsDenied(newPath)
+			if skip {
+				fmt.Fprintf(&b, "diff of %s omitted: access denied\n", newPath)
+				continue
+			}
+		}
+		if skip {
+			continue
+		}
+		if match := statLineRegex.FindStringSubmatch(line); match != nil && file.IsDenied(strings.TrimSpace(match[1])) {
+			continue
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+func truncate(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "no changes"
+	}
+	return utils.TruncateUTF8(s, maxOutputBytes)
+}
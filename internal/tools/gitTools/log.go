@@ -0,0 +1,146 @@
+package gitTools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pardnchiu/agenvoy/internal/tools/file"
+)
+
+const (
+	defaultLogLimit = 20
+	maxLogLimit     = 200
+)
+
+type logEntry struct {
+	Hash    string   `json:"hash"`
+	Parents []string `json:"parents,omitempty"`
+	Author  string   `json:"author"`
+	Email   string   `json:"email"`
+	Date    string   `json:"date"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body,omitempty"`
+}
+
+func commitLog(ctx context.Context, workDir, ref, path string, limit int) (string, error) {
+	if limit <= 0 {
+		limit = defaultLogLimit
+	}
+	limit = min(limit, maxLogLimit)
+
+	// * unit and record separators never appear in commit text
+	args := []string{
+		"log",
+		"-n", strconv.Itoa(limit),
+		"--date=iso-strict",
+		"--format=%H%x1f%P%x1f%an%x1f%ae%x1f%ad%x1f%s%x1f%b%x1e",
+	}
+	if ref != "" {
+		if err := checkRef(ref); err != nil {
+			return "", err
+		}
+		args = append(args, ref)
+	}
+	if path != "" {
+		args = append(args, "--", path)
+	}
+
+	out, err := run(ctx, workDir, args...)
+	if err != nil {
+		return "", err
+	}
+
+	entries := []logEntry{}
+	for _, record := range strings.Split(out, "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		fields := strings.Split(record, "\x1f")
+		if len(fields) < 7 {
+			continue
+		}
+		entries = append(entries, logEntry{
+			Hash:    fields[0],
+			Parents: strings.Fields(fields[1]),
+			Author:  fields[2],
+			Email:   fields[3],
+			Date:    fields[4],
+			Subject: fields[5],
+			Body:    strings.TrimSpace(fields[6]),
+		})
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return "", fmt.Errorf("json.Marshal: %w", err)
+	}
+	return string(data), nil
+}
+
+func show(ctx context.Context, workDir, ref, path string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if err := checkRef(ref); err != nil {
+		return "", err
+	}
+
+	// * with path, return file content at that revision
+	if path != "" {
+		if file.IsDenied(path) {
+			return "", fmt.Errorf("access denied: %s", path)
+		}
+		out, err := run(ctx, workDir, "show", fmt.Sprintf("%s:%s", ref, strings.TrimPrefix(path, "./")))
+		if err != nil {
+			return "", err
+		}
+		return truncate(out), nil
+	}
+
+	// * a blob hash would print file contents past the deny check
+	kind, err := run(ctx, workDir, "cat-file", "-t", ref)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(kind) == "blob" {
+		return "", fmt.Errorf("ref is a file blob: %s (use path to read a file at a revision)", ref)
+	}
+
+	out, err := run(ctx, workDir, "show", "--stat", "--patch", "--date=iso-strict", ref)
+	if err != nil {
+		return "", err
+	}
+	return truncate(filterDenied(out)), nil
+}
+
+func diff(ctx context.Context, workDir string, staged bool, ref, path string, stat bool) (string, error) {
+	args := []string{"diff"}
+	if staged {
+		args = append(args, "--cached")
+	}
+	if stat {
+		args = append(args, "--stat")
+	}
+	if ref != "" {
+		if err := checkRef(ref); err != nil {
+			return "", err
+		}
+		args = append(args, ref)
+	}
+	if path != "" {
+		if file.IsDenied(path) {
+			return "", fmt.Errorf("access denied: %s", path)
+		}
+		args = append(args, "--", path)
+	}
+
+	out, err := run(ctx, workDir, args...)
+	if err != nil {
+		return "", err
+	}
+	return truncate(filterDenied(out)), nil
+}
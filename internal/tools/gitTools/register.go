@@ -0,0 +1,94 @@
+package gitTools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	toolRegister "github.com/pardnchiu/agenvoy/internal/tools/register"
+	toolTypes "github.com/pardnchiu/agenvoy/internal/tools/types"
+)
+
+func init() {
+	toolRegister.Register("git_status", func(ctx context.Context, e *toolTypes.Executor, _ json.RawMessage) (string, error) {
+		if err := ensureRepo(ctx, e.WorkPath); err != nil {
+			return "", err
+		}
+		return status(ctx, e.WorkPath)
+	})
+
+	toolRegister.Register("git_diff", func(ctx context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
+		var params struct {
+			Staged bool   `json:"staged"`
+			Ref    string `json:"ref"`
+			Path   string `json:"path"`
+			Stat   bool   `json:"stat"`
+		}
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		if err := ensureRepo(ctx, e.WorkPath); err != nil {
+			return "", err
+		}
+		return diff(ctx, e.WorkPath, params.Staged, params.Ref, params.Path, params.Stat)
+	})
+
+	toolRegister.Register("git_log", func(ctx context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
+		var params struct {
+			Ref   string `json:"ref"`
+			Path  string `json:"path"`
+			Limit int    `json:"limit"`
+		}
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		if err := ensureRepo(ctx, e.WorkPath); err != nil {
+			return "", err
+		}
+		return commitLog(ctx, e.WorkPath, params.Ref, params.Path, params.Limit)
+	})
+
+	toolRegister.Register("git_show", func(ctx context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
+		var params struct {
+			Ref  string `json:"ref"`
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		if err := ensureRepo(ctx, e.WorkPath); err != nil {
+			return "", err
+		}
+		return show(ctx, e.WorkPath, params.Ref, params.Path)
+	})
+
+	toolRegister.Register("git_commit", func(ctx context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
+		var params struct {
+			Message string   `json:"message"`
+			Paths   []string `json:"paths"`
+			All     bool     `json:"all"`
+		}
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		if err := ensureRepo(ctx, e.WorkPath); err != nil {
+			return "", err
+		}
+		return commit(ctx, e.WorkPath, params.Message, params.Paths, params.All)
+	})
+
+	toolRegister.Register("git_branch", func(ctx context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
+		var params struct {
+			Action string `json:"action"`
+			Name   string `json:"name"`
+			Base   string `json:"base"`
+		}
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		if err := ensureRepo(ctx, e.WorkPath); err != nil {
+			return "", err
+		}
+		return branch(ctx, e.WorkPath, params.Action, params.Name, params.Base)
+	})
+}
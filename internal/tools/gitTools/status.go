@@ -0,0 +1,114 @@
+package gitTools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type statusEntry struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	From   string `json:"from,omitempty"`
+}
+
+type statusResult struct {
+	Branch    string        `json:"branch"`
+	Upstream  string        `json:"upstream,omitempty"`
+	Ahead     int           `json:"ahead"`
+	Behind    int           `json:"behind"`
+	Staged    []statusEntry `json:"staged"`
+	Unstaged  []statusEntry `json:"unstaged"`
+	Untracked []string      `json:"untracked"`
+	Conflicts []string      `json:"conflicts,omitempty"`
+}
+
+var statusNames = map[byte]string{
+	'M': "modified",
+	'T': "type_changed",
+	'A': "added",
+	'D': "deleted",
+	'R': "renamed",
+	'C': "copied",
+}
+
+func status(ctx context.Context, workDir string) (string, error) {
+	out, err := run(ctx, workDir, "status", "--porcelain=v2", "--branch", "-z")
+	if err != nil {
+		return "", err
+	}
+
+	result := statusResult{
+		Staged:    []statusEntry{},
+		Unstaged:  []statusEntry{},
+		Untracked: []string{},
+	}
+
+	records := strings.Split(out, "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if record == "" {
+			continue
+		}
+		switch record[0] {
+		case '#':
+			parseBranchHeader(&result, record)
+
+		case '1', '2':
+			fields := strings.SplitN(record, " ", 9)
+			if record[0] == '2' {
+				fields = strings.SplitN(record, " ", 10)
+			}
+			if len(fields) < 9 {
+				continue
+			}
+			xy := fields[1]
+			path := fields[len(fields)-1]
+			from := ""
+			// * renamed and copied entries carry the original path as next record
+			if record[0] == '2' && i+1 < len(records) {
+				from = records[i+1]
+				i++
+			}
+			if xy[0] != '.' {
+				result.Staged = append(result.Staged, statusEntry{Path: path, Status: statusNames[xy[0]], From: from})
+			}
+			if xy[1] != '.' {
+				result.Unstaged = append(result.Unstaged, statusEntry{Path: path, Status: statusNames[xy[1]], From: from})
+			}
+
+		case 'u':
+			fields := strings.SplitN(record, " ", 11)
+			result.Conflicts = append(result.Conflicts, fields[len(fields)-1])
+
+		case '?':
+			result.Untracked = append(result.Untracked, strings.TrimPrefix(record, "? "))
+		}
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("json.Marshal: %w", err)
+	}
+	return string(data), nil
+}
+
+func parseBranchHeader(result *statusResult, record string) {
+	fields := strings.Fields(record)
+	if len(fields) < 3 {
+		return
+	}
+	switch fields[1] {
+	case "branch.head":
+		result.Branch = fields[2]
+	case "branch.upstream":
+		result.Upstream = fields[2]
+	case "branch.ab":
+		if len(fields) >= 4 {
+			result.Ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[2], "+"))
+			result.Behind, _ = strconv.Atoi(strings.TrimPrefix(fields[3], "-"))
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	initTimeout    = 60 * time.Second
+	requestTimeout = 30 * time.Second
+)
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type client struct {
+	name    string
+	root    string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	writeMu sync.Mutex
+
+	nextID  atomic.Int64
+	mu      sync.Mutex
+	pending map[int64]chan rpcMessage
+	// * uri to opened document version
+	opened map[string]int
+	// * uri to latest published diagnostics, signal closed and renewed on every publish
+	diagnostics map[string][]diagnostic
+	published   map[string]chan struct{}
+
+	lastUsed atomic.Int64
+	done     chan struct{}
+}
+
+func start(ctx context.Context, name string, cfg serverConfig, root string) (*client, error) {
+	if _, err := exec.LookPath(cfg.Command); err != nil {
+		return nil, fmt.Errorf("language server not found: %s", cfg.Command)
+	}
+
+	// * server outlives the tool call, not bound to request context
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Dir = root
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("cmd.StdinPipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("cmd.StdoutPipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("cmd.Start: %w", err)
+	}
+
+	c := &client{
+		name:        name,
+		root:        root,
+		cmd:         cmd,
+		stdin:       stdin,
+		pending:     make(map[int64]chan rpcMessage),
+		opened:      make(map[string]int),
+		diagnostics: make(map[string][]diagnostic),
+		published:   make(map[string]chan struct{}),
+		done:        make(chan struct{}),
+	}
+	c.touch()
+	go c.readLoop(stdout)
+
+	initCtx, cancel := context.WithTimeout(ctx, initTimeout)
+	defer cancel()
+
+	params := map[string]any{
+		"processId": nil,
+		"rootUri":   toURI(root),
+		"workspaceFolders": []map[string]string{
+			{"uri": toURI(root), "name": filepath.Base(root)},
+		},
+		"capabilities": map[string]any{
+			"textDocument": map[string]any{
+				"synchronization":    map[string]any{"didSave": true},
+				"definition":         map[string]any{"linkSupport": false},
+				"references":         map[string]any{},
+				"documentSymbol":     map[string]any{"hierarchicalDocumentSymbolSupport": true},
+				"publishDiagnostics": map[string]any{"relatedInformation": false},
+			},
+			"workspace": map[string]any{
+				"workspaceFolders": true,
+			},
+		},
+	}
+	if _, err := c.request(initCtx, "initialize", params); err != nil {
+		c.close()
+		return nil, fmt.Errorf("failed to initialize %s: %w", name, err)
+	}
+	if err := c.notify("initialized", map[string]any{}); err != nil {
+		c.close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *client) touch() {
+	c.lastUsed.Store(time.Now().Unix())
+}
+
+func (c *client) alive() bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (c *client) write(msg rpcMessage) error {
+	msg.JSONRPC = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := fmt.Fprintf(c.stdin, "Content-Length: %d\r\n\r\n%s", len(data), data); err != nil {
+		return fmt.Errorf("failed to write to %s: %w", c.name, err)
+	}
+	return nil
+}
+
+func (c *client) request(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	id := c.nextID.Add(1)
+	ch := make(chan rpcMessage, 1)
+	c.mu.Lock()
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(rpcMessage{ID: &id, Method: method, Params: raw}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg := <-ch:
+		if msg.Error != nil {
+			return nil, fmt.Errorf("%s: %s", method, msg.Error.Message)
+		}
+		return msg.Result, nil
+	case <-c.done:
+		return nil, fmt.Errorf("%s: language server %s exited", method, c.name)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", method, ctx.Err())
+	}
+}
+
+func (c *client) notify(method string, params any) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+	return c.write(rpcMessage{Method: method, Params: raw})
+}
+
+func (c *client) readLoop(r io.Reader) {
+	defer close(c.done)
+
+	reader := bufio.NewReader(r)
+	for {
+		msg, err := readMessage(reader)
+		if err != nil {
+			if err != io.EOF {
+				slog.Warn("language server stream closed",
+					slog.String("server", c.name),
+					slog.String("error", err.Error()))
+			}
+			return
+		}
+		c.handle(msg)
+	}
+}
+
+func (c *client) handle(msg rpcMessage) {
+	switch {
+	// * response to our request
+	case msg.ID != nil && msg.Method == "":
+		c.mu.Lock()
+		ch, ok := c.pending[*msg.ID]
+		c.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+
+	// * server to client request, e.g. workspace/configuration, answer with empty result
+	case msg.ID != nil:
+		result := json.RawMessage("null")
+		if msg.Method == "workspace/configuration" {
+			var params struct {
+				Items []json.RawMessage `json:"items"`
+			}
+			json.Unmarshal(msg.Params, &params)
+			items := make([]any, len(params.Items))
+			if data, err := json.Marshal(items); err == nil {
+				result = data
+			}
+		}
+		c.write(rpcMessage{ID: msg.ID, Result: result})
+
+	case msg.Method == "textDocument/publishDiagnostics":
+		var params struct {
+			URI         string       `json:"uri"`
+			Diagnostics []diagnostic `json:"diagnostics"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return
+		}
+		c.mu.Lock()
+		c.diagnostics[params.URI] = params.Diagnostics
+		if ch, ok := c.published[params.URI]; ok {
+			close(ch)
+		}
+		c.published[params.URI] = make(chan struct{})
+		c.mu.Unlock()
+	}
+}
+
+func readMessage(r *bufio.Reader) (rpcMessage, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return rpcMessage{}, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(key), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return rpcMessage{}, fmt.Errorf("invalid Content-Length: %s", value)
+			}
+		}
+	}
+	if length < 0 {
+		return rpcMessage{}, fmt.Errorf("missing Content-Length")
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return rpcMessage{}, err
+	}
+
+	var msg rpcMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return rpcMessage{}, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	return msg, nil
+}
+
+func (c *client) close() {
+	if c.alive() {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		c.request(ctx, "shutdown", nil)
+		cancel()
+		c.notify("exit", nil)
+	}
+	c.stdin.Close()
+
+	select {
+	case <-c.done:
+	case <-time.After(3 * time.Second):
+		c.cmd.Process.Kill()
+	}
+	c.cmd.Wait()
+}
+
+func toURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+func fromURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
@@ -0,0 +1,137 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+	toolTypes "github.com/pardnchiu/agenvoy/internal/tools/types"
+)
+
+// * test binary doubles as a minimal language server
+func TestMain(m *testing.M) {
+	if os.Getenv("AGENVOY_FAKE_LSP") == "1" {
+		fakeServer()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func fakeServer() {
+	reader := bufio.NewReader(os.Stdin)
+	send := func(msg map[string]any) {
+		msg["jsonrpc"] = "2.0"
+		data, _ := json.Marshal(msg)
+		fmt.Fprintf(os.Stdout, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	}
+	for {
+		msg, err := readMessage(reader)
+		if err != nil {
+			return
+		}
+		switch msg.Method {
+		case "initialize":
+			send(map[string]any{"id": msg.ID, "result": map[string]any{"capabilities": map[string]any{}}})
+		case "textDocument/didOpen", "textDocument/didChange":
+			var params struct {
+				TextDocument struct {
+					URI string `json:"uri"`
+				} `json:"textDocument"`
+			}
+			json.Unmarshal(msg.Params, &params)
+			send(map[string]any{
+				"method": "textDocument/publishDiagnostics",
+				"params": map[string]any{
+					"uri": params.TextDocument.URI,
+					"diagnostics": []map[string]any{{
+						"range":    map[string]any{"start": map[string]int{"line": 1, "character": 1}, "end": map[string]int{"line": 1, "character": 2}},
+						"severity": 1,
+						"source":   "fake",
+						"message":  "undefined: bar",
+					}},
+				},
+			})
+		case "textDocument/definition":
+			var params struct {
+				TextDocument struct {
+					URI string `json:"uri"`
+				} `json:"textDocument"`
+				Position position `json:"position"`
+			}
+			json.Unmarshal(msg.Params, &params)
+			send(map[string]any{"id": msg.ID, "result": []map[string]any{{
+				"uri":   params.TextDocument.URI,
+				"range": map[string]any{"start": map[string]int{"line": 0, "character": params.Position.Character}, "end": map[string]int{"line": 0, "character": params.Position.Character}},
+			}}})
+		case "shutdown":
+			send(map[string]any{"id": msg.ID, "result": nil})
+		case "exit":
+			return
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	t.Setenv("AGENVOY_FAKE_LSP", "1")
+
+	dir := t.TempDir()
+	filesystem.LSPPath = filepath.Join(dir, "lsp.json")
+	cfg, _ := json.Marshal(map[string]serverConfig{
+		"fake": {Command: os.Args[0], Extensions: map[string]string{".fake": "fake"}},
+	})
+	if err := os.WriteFile(filesystem.LSPPath, cfg, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.fake"), []byte("var 名稱 = foo\n\tbar()\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer Shutdown()
+
+	e := &toolTypes.Executor{WorkPath: dir}
+	ctx := context.Background()
+
+	got, err := definition(ctx, e, target{Path: "main.fake", Line: 1, Symbol: "foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// * foo is the 10th rune, multi-byte name must not shift the column
+	if want := "main.fake:1:10: var 名稱 = foo\n"; got != want {
+		t.Errorf("definition = %q, want %q", got, want)
+	}
+
+	report := Diagnose(ctx, e, "main.fake")
+	if !strings.Contains(report, "main.fake:2:2: error: undefined: bar (fake)") {
+		t.Errorf("Diagnose = %q", report)
+	}
+
+	if _, err := definition(ctx, e, target{Path: "main.fake", Line: 1, Symbol: "missing"}); err == nil {
+		t.Error("expected error for missing symbol")
+	}
+
+	// * an edit in a new workdir does not wait for the server, a cancelled caller does not fail the start
+	other := t.TempDir()
+	if err := os.WriteFile(filepath.Join(other, "main.fake"), []byte("\tbar()\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	e = &toolTypes.Executor{WorkPath: other}
+	if report := Diagnose(ctx, e, "main.fake"); report != "" {
+		t.Errorf("Diagnose while starting = %q", report)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, _, err := getClient(cancelled, other, filepath.Join(other, "main.fake")); err == nil {
+		t.Error("expected error for cancelled caller")
+	}
+	if _, _, err := getClient(ctx, other, filepath.Join(other, "main.fake")); err != nil {
+		t.Fatalf("getClient after cancelled caller: %v", err)
+	}
+	if report := Diagnose(ctx, e, "main.fake"); !strings.Contains(report, "error: undefined: bar") {
+		t.Errorf("Diagnose once started = %q", report)
+	}
+}
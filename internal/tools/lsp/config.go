@@ -0,0 +1,99 @@
+package lsp
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+)
+
+type serverConfig struct {
+	Command    string            `json:"command"`
+	Args       []string          `json:"args"`
+	Extensions map[string]string `json:"extensions"` // * file extension to LSP languageId
+}
+
+// * overridden or extended by ~/.config/agenvoy/lsp.json, keyed by server name
+var defaultServers = map[string]serverConfig{
+	"gopls": {
+		Command: "gopls",
+		Extensions: map[string]string{
+			".go": "go",
+		},
+	},
+	"pyright": {
+		Command: "pyright-langserver",
+		Args:    []string{"--stdio"},
+		Extensions: map[string]string{
+			".py": "python",
+		},
+	},
+	"tsserver": {
+		Command: "typescript-language-server",
+		Args:    []string{"--stdio"},
+		Extensions: map[string]string{
+			".ts":  "typescript",
+			".tsx": "typescriptreact",
+			".js":  "javascript",
+			".jsx": "javascriptreact",
+		},
+	},
+}
+
+func loadServers() map[string]serverConfig {
+	servers := make(map[string]serverConfig, len(defaultServers))
+	for name, cfg := range defaultServers {
+		servers[name] = cfg
+	}
+
+	data, err := os.ReadFile(filesystem.LSPPath)
+	if err != nil {
+		return servers
+	}
+
+	var custom map[string]serverConfig
+	if err := json.Unmarshal(data, &custom); err != nil {
+		slog.Warn("failed to parse lsp config",
+			slog.String("path", filesystem.LSPPath),
+			slog.String("error", err.Error()))
+		return servers
+	}
+	for name, cfg := range custom {
+		// * empty command disables the server
+		if cfg.Command == "" {
+			delete(servers, name)
+			continue
+		}
+		servers[name] = cfg
+	}
+	return servers
+}
+
+// * serverFor returns server name, config and languageId for path
+func serverFor(path string) (string, serverConfig, string, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	servers := loadServers()
+	// * stable pick when several servers claim the same extension
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cfg := servers[name]
+		if id, ok := cfg.Extensions[ext]; ok {
+			return name, cfg, id, true
+		}
+	}
+	return "", serverConfig{}, "", false
+}
+
+func installed(cfg serverConfig) bool {
+	_, err := exec.LookPath(cfg.Command)
+	return err == nil
+}
@@ -0,0 +1,181 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	diagnosticsTimeout = 10 * time.Second
+	// * servers may publish several times in a row, wait until quiet
+	diagnosticsSettle = 500 * time.Millisecond
+)
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type location struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type diagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+// * sync opens or refreshes the document, returns a channel closed on the next diagnostics publish
+func (c *client) sync(fullPath, languageID string) (chan struct{}, error) {
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file (%s): %w", fullPath, err)
+	}
+	uri := toURI(fullPath)
+
+	c.mu.Lock()
+	ch, ok := c.published[uri]
+	if !ok {
+		ch = make(chan struct{})
+		c.published[uri] = ch
+	}
+	version, opened := c.opened[uri]
+	version++
+	c.opened[uri] = version
+	c.mu.Unlock()
+
+	if !opened {
+		err = c.notify("textDocument/didOpen", map[string]any{
+			"textDocument": map[string]any{
+				"uri":        uri,
+				"languageId": languageID,
+				"version":    version,
+				"text":       string(data),
+			},
+		})
+	} else {
+		err = c.notify("textDocument/didChange", map[string]any{
+			"textDocument": map[string]any{
+				"uri":     uri,
+				"version": version,
+			},
+			"contentChanges": []map[string]any{
+				{"text": string(data)},
+			},
+		})
+		if err == nil {
+			err = c.notify("textDocument/didSave", map[string]any{
+				"textDocument": map[string]any{"uri": uri},
+			})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+func (c *client) waitDiagnostics(ctx context.Context, uri string, ch chan struct{}) ([]diagnostic, bool) {
+	select {
+	case <-ch:
+	case <-time.After(diagnosticsTimeout):
+		return nil, false
+	case <-ctx.Done():
+		return nil, false
+	case <-c.done:
+		return nil, false
+	}
+
+	for {
+		c.mu.Lock()
+		next := c.published[uri]
+		c.mu.Unlock()
+
+		select {
+		case <-next:
+			continue
+		case <-time.After(diagnosticsSettle):
+		case <-ctx.Done():
+		}
+		break
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.diagnostics[uri], true
+}
+
+func readLines(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(data), "\n")
+}
+
+// * toPosition converts 1-based line and rune column to LSP position in UTF-16 units
+func toPosition(lines []string, line, column int) (position, error) {
+	if line < 1 || line > len(lines) {
+		return position{}, fmt.Errorf("line out of range: %d (file has %d lines)", line, len(lines))
+	}
+	runes := []rune(lines[line-1])
+	column = min(max(column, 1), len(runes)+1)
+	return position{
+		Line:      line - 1,
+		Character: len(utf16.Encode(runes[:column-1])),
+	}, nil
+}
+
+// * toColumn converts LSP character offset back to 1-based rune column
+func toColumn(lines []string, pos position) int {
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return pos.Character + 1
+	}
+	units := 0
+	for i, r := range []rune(lines[pos.Line]) {
+		if units >= pos.Character {
+			return i + 1
+		}
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return len([]rune(lines[pos.Line])) + 1
+}
+
+// * findSymbol returns the 1-based rune column of symbol as a whole word in line
+func findSymbol(text, symbol string) (int, bool) {
+	runes := []rune(text)
+	target := []rune(symbol)
+	for i := 0; i+len(target) <= len(runes); i++ {
+		if string(runes[i:i+len(target)]) != symbol {
+			continue
+		}
+		if i > 0 && isIdentRune(runes[i-1]) {
+			continue
+		}
+		if end := i + len(target); end < len(runes) && isIdentRune(runes[end]) {
+			continue
+		}
+		return i + 1, true
+	}
+	return 0, false
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '$' ||
+		(r >= 'a' && r <= 'z') ||
+		(r >= 'A' && r <= 'Z') ||
+		(r >= '0' && r <= '9') ||
+		r > 0x7f
+}
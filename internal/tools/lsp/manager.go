@@ -0,0 +1,169 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	idleTimeout  = 10 * time.Minute
+	reapInterval = time.Minute
+)
+
+var (
+	mu       sync.Mutex
+	clients  = map[string]*client{}
+	starting = map[string]*pending{}
+	reapOnce sync.Once
+)
+
+// * pending is a server being started, later callers for the same key wait on it instead of the lock
+type pending struct {
+	done   chan struct{}
+	client *client
+	err    error
+}
+
+// * one server per workdir and server name
+func getClient(ctx context.Context, root, path string) (*client, string, error) {
+	p, languageID, err := lookup(root, path)
+	if err != nil {
+		return nil, "", err
+	}
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return nil, "", ctx.Err()
+	}
+	if p.err != nil {
+		return nil, "", p.err
+	}
+	return p.client, languageID, nil
+}
+
+// * runningClient returns the server only once it is up, a missing one is started in the background
+func runningClient(root, path string) (*client, string, bool) {
+	p, languageID, err := lookup(root, path)
+	if err != nil {
+		return nil, "", false
+	}
+	select {
+	case <-p.done:
+		return p.client, languageID, p.err == nil
+	default:
+		return nil, "", false
+	}
+}
+
+// * lookup returns the running server as a finished entry or the in-flight start, starting one when neither exists
+func lookup(root, path string) (*pending, string, error) {
+	name, cfg, languageID, ok := serverFor(path)
+	if !ok {
+		return nil, "", fmt.Errorf("no language server configured for: %s", path)
+	}
+
+	key := root + "\x00" + name
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if c, ok := clients[key]; ok {
+		if c.alive() {
+			c.touch()
+			p := &pending{done: make(chan struct{}), client: c}
+			close(p.done)
+			return p, languageID, nil
+		}
+		// * crashed server is restarted on next use
+		delete(clients, key)
+	}
+	if p, ok := starting[key]; ok {
+		return p, languageID, nil
+	}
+
+	p := &pending{done: make(chan struct{})}
+	starting[key] = p
+	go startPending(key, p, name, cfg, root)
+	return p, languageID, nil
+}
+
+// * startPending initializes outside the lock and detached from the request, a cancelled caller must not fail the others waiting
+func startPending(key string, p *pending, name string, cfg serverConfig, root string) {
+	p.client, p.err = start(context.Background(), name, cfg, root)
+
+	mu.Lock()
+	// * Shutdown takes the entry away and closes the client itself
+	if starting[key] == p {
+		delete(starting, key)
+		if p.err == nil {
+			clients[key] = p.client
+		}
+	}
+	mu.Unlock()
+	close(p.done)
+
+	if p.err == nil {
+		reapOnce.Do(func() {
+			go reapIdle()
+		})
+	}
+}
+
+func reapIdle() {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		var idle []*client
+		mu.Lock()
+		for key, c := range clients {
+			if time.Since(time.Unix(c.lastUsed.Load(), 0)) > idleTimeout || !c.alive() {
+				idle = append(idle, c)
+				delete(clients, key)
+			}
+		}
+		mu.Unlock()
+
+		for _, c := range idle {
+			slog.Info("stopping idle language server",
+				slog.String("server", c.name),
+				slog.String("root", c.root))
+			c.close()
+		}
+	}
+}
+
+// * Shutdown stops every running language server
+func Shutdown() {
+	mu.Lock()
+	list := make([]*client, 0, len(clients))
+	for key, c := range clients {
+		list = append(list, c)
+		delete(clients, key)
+	}
+	inFlight := make([]*pending, 0, len(starting))
+	for key, p := range starting {
+		inFlight = append(inFlight, p)
+		delete(starting, key)
+	}
+	mu.Unlock()
+
+	for _, p := range inFlight {
+		<-p.done
+		if p.err == nil {
+			list = append(list, p.client)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, c := range list {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.close()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,392 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/tools/file"
+	toolTypes "github.com/pardnchiu/agenvoy/internal/tools/types"
+)
+
+const (
+	maxLocations   = 200
+	maxDiagnostics = 50
+	// * auto diagnostics only report this many, keep write results short
+	maxAutoDiagnostics = 20
+	// * edits wait at most this long for diagnostics
+	autoDiagnoseTimeout = 5 * time.Second
+)
+
+var severityNames = map[int]string{
+	1: "error",
+	2: "warning",
+	3: "info",
+	4: "hint",
+}
+
+var symbolKinds = map[int]string{
+	1: "file", 2: "module", 3: "namespace", 4: "package", 5: "class",
+	6: "method", 7: "property", 8: "field", 9: "constructor", 10: "enum",
+	11: "interface", 12: "function", 13: "variable", 14: "constant", 15: "string",
+	16: "number", 17: "boolean", 18: "array", 19: "object", 20: "key",
+	21: "null", 22: "enum_member", 23: "struct", 24: "event", 25: "operator",
+	26: "type_parameter",
+}
+
+type target struct {
+	Path   string
+	Line   int
+	Column int
+	Symbol string
+}
+
+type documentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail"`
+	Kind           int              `json:"kind"`
+	Range          lspRange         `json:"range"`
+	SelectionRange lspRange         `json:"selectionRange"`
+	Children       []documentSymbol `json:"children"`
+	// * SymbolInformation form
+	Location      location `json:"location"`
+	ContainerName string   `json:"containerName"`
+}
+
+func resolve(e *toolTypes.Executor, path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+	if file.IsDenied(path) {
+		return "", fmt.Errorf("access denied: %s", path)
+	}
+	fullPath, err := file.GetFullPath(e, path)
+	if err != nil {
+		return "", err
+	}
+	if file.IsExclude(e, fullPath) {
+		return "", fmt.Errorf("path is excluded: %s", path)
+	}
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file (%s): %w", path, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("path is a directory: %s", path)
+	}
+	return fullPath, nil
+}
+
+// * open resolves path, starts the server and syncs the document
+func open(ctx context.Context, e *toolTypes.Executor, path string) (*client, string, chan struct{}, error) {
+	fullPath, err := resolve(e, path)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	c, languageID, err := getClient(ctx, e.WorkPath, fullPath)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	ch, err := c.sync(fullPath, languageID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return c, fullPath, ch, nil
+}
+
+func positionParams(fullPath string, t target) (map[string]any, error) {
+	lines := readLines(fullPath)
+	column := t.Column
+	if t.Symbol != "" && column <= 0 {
+		if t.Line < 1 || t.Line > len(lines) {
+			return nil, fmt.Errorf("line out of range: %d (file has %d lines)", t.Line, len(lines))
+		}
+		col, ok := findSymbol(lines[t.Line-1], t.Symbol)
+		if !ok {
+			return nil, fmt.Errorf("symbol %q not found on line %d: %s", t.Symbol, t.Line, strings.TrimSpace(lines[t.Line-1]))
+		}
+		column = col
+	}
+	pos, err := toPosition(lines, t.Line, column)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"textDocument": map[string]any{"uri": toURI(fullPath)},
+		"position":     pos,
+	}, nil
+}
+
+func definition(ctx context.Context, e *toolTypes.Executor, t target) (string, error) {
+	c, fullPath, _, err := open(ctx, e, t.Path)
+	if err != nil {
+		return "", err
+	}
+	params, err := positionParams(fullPath, t)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	result, err := c.request(ctx, "textDocument/definition", params)
+	if err != nil {
+		return "", err
+	}
+
+	locations := parseLocations(result)
+	if len(locations) == 0 {
+		return "No definition found", nil
+	}
+	return formatLocations(e.WorkPath, locations), nil
+}
+
+func references(ctx context.Context, e *toolTypes.Executor, t target, includeDeclaration bool) (string, error) {
+	c, fullPath, _, err := open(ctx, e, t.Path)
+	if err != nil {
+		return "", err
+	}
+	params, err := positionParams(fullPath, t)
+	if err != nil {
+		return "", err
+	}
+	params["context"] = map[string]bool{"includeDeclaration": includeDeclaration}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	result, err := c.request(ctx, "textDocument/references", params)
+	if err != nil {
+		return "", err
+	}
+
+	locations := parseLocations(result)
+	if len(locations) == 0 {
+		return "No references found", nil
+	}
+	return formatLocations(e.WorkPath, locations), nil
+}
+
+func symbols(ctx context.Context, e *toolTypes.Executor, path string) (string, error) {
+	c, fullPath, _, err := open(ctx, e, path)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	result, err := c.request(ctx, "textDocument/documentSymbol", map[string]any{
+		"textDocument": map[string]any{"uri": toURI(fullPath)},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var list []documentSymbol
+	if err := json.Unmarshal(result, &list); err != nil {
+		return "", fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	if len(list) == 0 {
+		return "No symbols found", nil
+	}
+
+	var sb strings.Builder
+	var walk func(items []documentSymbol, depth int)
+	walk = func(items []documentSymbol, depth int) {
+		for _, s := range items {
+			line := s.SelectionRange.Start.Line
+			if s.Location.URI != "" {
+				line = s.Location.Range.Start.Line
+			}
+			sb.WriteString(fmt.Sprintf("%s%s %s", strings.Repeat("  ", depth), symbolKinds[s.Kind], s.Name))
+			if s.Detail != "" {
+				sb.WriteString(" " + s.Detail)
+			}
+			if s.ContainerName != "" {
+				sb.WriteString(" (in " + s.ContainerName + ")")
+			}
+			sb.WriteString(fmt.Sprintf(" :%d\n", line+1))
+			walk(s.Children, depth+1)
+		}
+	}
+	walk(list, 0)
+	return sb.String(), nil
+}
+
+func diagnostics(ctx context.Context, e *toolTypes.Executor, path string) (string, error) {
+	c, fullPath, ch, err := open(ctx, e, path)
+	if err != nil {
+		return "", err
+	}
+	list, ok := c.waitDiagnostics(ctx, toURI(fullPath), ch)
+	if !ok {
+		return fmt.Sprintf("No diagnostics published by %s within timeout", c.name), nil
+	}
+	if len(list) == 0 {
+		return fmt.Sprintf("No problems: %s", path), nil
+	}
+	return formatDiagnostics(e.WorkPath, fullPath, list, 0, maxDiagnostics), nil
+}
+
+// * Diagnose is run after file writes and best effort: empty when no server handles the file,
+// * while the server is still starting, or when diagnostics miss autoDiagnoseTimeout
+func Diagnose(ctx context.Context, e *toolTypes.Executor, path string) string {
+	fullPath, err := resolve(e, path)
+	if err != nil {
+		return ""
+	}
+	if _, cfg, _, ok := serverFor(fullPath); !ok || !installed(cfg) {
+		return ""
+	}
+
+	c, languageID, ok := runningClient(e.WorkPath, fullPath)
+	if !ok {
+		return ""
+	}
+	ch, err := c.sync(fullPath, languageID)
+	if err != nil {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, autoDiagnoseTimeout)
+	defer cancel()
+	list, ok := c.waitDiagnostics(ctx, toURI(fullPath), ch)
+	if !ok {
+		return ""
+	}
+
+	// * only errors and warnings, hints are noise after every edit
+	if len(list) == 0 || !hasSeverity(list, 2) {
+		return "Diagnostics: no errors or warnings"
+	}
+	return "Diagnostics:\n" + formatDiagnostics(e.WorkPath, fullPath, list, 2, maxAutoDiagnostics)
+}
+
+func hasSeverity(list []diagnostic, maxSeverity int) bool {
+	for _, d := range list {
+		if d.Severity <= maxSeverity {
+			return true
+		}
+	}
+	return false
+}
+
+func parseLocations(raw json.RawMessage) []location {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+
+	type item struct {
+		location
+		TargetURI            string   `json:"targetUri"`
+		TargetSelectionRange lspRange `json:"targetSelectionRange"`
+	}
+
+	var items []item
+	if err := json.Unmarshal(raw, &items); err != nil {
+		var single item
+		if err := json.Unmarshal(raw, &single); err != nil {
+			return nil
+		}
+		items = []item{single}
+	}
+
+	locations := make([]location, 0, len(items))
+	for _, it := range items {
+		// * LocationLink form
+		if it.TargetURI != "" {
+			locations = append(locations, location{URI: it.TargetURI, Range: it.TargetSelectionRange})
+			continue
+		}
+		locations = append(locations, it.location)
+	}
+	return locations
+}
+
+func formatLocations(root string, locations []location) string {
+	sort.SliceStable(locations, func(i, j int) bool {
+		if locations[i].URI != locations[j].URI {
+			return locations[i].URI < locations[j].URI
+		}
+		return locations[i].Range.Start.Line < locations[j].Range.Start.Line
+	})
+
+	var sb strings.Builder
+	cache := map[string][]string{}
+	for i, loc := range locations {
+		if i >= maxLocations {
+			sb.WriteString(fmt.Sprintf("... (%d more)\n", len(locations)-maxLocations))
+			break
+		}
+		path := fromURI(loc.URI)
+		lines, ok := cache[path]
+		if !ok {
+			lines = readLines(path)
+			cache[path] = lines
+		}
+
+		preview := ""
+		if loc.Range.Start.Line < len(lines) {
+			preview = strings.TrimSpace(lines[loc.Range.Start.Line])
+		}
+		sb.WriteString(fmt.Sprintf("%s:%d:%d: %s\n",
+			relPath(root, path),
+			loc.Range.Start.Line+1,
+			toColumn(lines, loc.Range.Start),
+			preview))
+	}
+	return sb.String()
+}
+
+func formatDiagnostics(root, fullPath string, list []diagnostic, maxSeverity, limit int) string {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Severity != list[j].Severity {
+			return list[i].Severity < list[j].Severity
+		}
+		return list[i].Range.Start.Line < list[j].Range.Start.Line
+	})
+
+	lines := readLines(fullPath)
+	path := relPath(root, fullPath)
+
+	var sb strings.Builder
+	count := 0
+	for _, d := range list {
+		if maxSeverity > 0 && d.Severity > maxSeverity {
+			continue
+		}
+		if count >= limit {
+			sb.WriteString(fmt.Sprintf("... (limit %d reached)\n", limit))
+			break
+		}
+		count++
+
+		severity := severityNames[d.Severity]
+		if severity == "" {
+			severity = "error"
+		}
+		sb.WriteString(fmt.Sprintf("%s:%d:%d: %s: %s",
+			path,
+			d.Range.Start.Line+1,
+			toColumn(lines, d.Range.Start),
+			severity,
+			strings.ReplaceAll(d.Message, "\n", " ")))
+		if d.Source != "" {
+			sb.WriteString(fmt.Sprintf(" (%s)", d.Source))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func relPath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,67 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	toolRegister "github.com/pardnchiu/agenvoy/internal/tools/register"
+	toolTypes "github.com/pardnchiu/agenvoy/internal/tools/types"
+)
+
+type positionArgs struct {
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Symbol string `json:"symbol"`
+}
+
+func (p positionArgs) target() target {
+	return target{
+		Path:   p.Path,
+		Line:   p.Line,
+		Column: p.Column,
+		Symbol: p.Symbol,
+	}
+}
+
+func init() {
+	toolRegister.Register("find_definition", func(ctx context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
+		var params positionArgs
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		return definition(ctx, e, params.target())
+	})
+
+	toolRegister.Register("find_references", func(ctx context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
+		var params struct {
+			positionArgs
+			IncludeDeclaration bool `json:"include_declaration"`
+		}
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		return references(ctx, e, params.target(), params.IncludeDeclaration)
+	})
+
+	toolRegister.Register("document_symbols", func(ctx context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
+		var params struct {
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		return symbols(ctx, e, params.Path)
+	})
+
+	toolRegister.Register("diagnostics", func(ctx context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
+		var params struct {
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		return diagnostics(ctx, e, params.Path)
+	})
+}
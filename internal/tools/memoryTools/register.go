@@ -0,0 +1,75 @@
+package memoryTools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pardnchiu/agenvoy/internal/memory"
+	toolRegister "github.com/pardnchiu/agenvoy/internal/tools/register"
+	toolTypes "github.com/pardnchiu/agenvoy/internal/tools/types"
+)
+
+const (
+	defaultRecallLimit = 5
+	maxRecallLimit     = 20
+)
+
+func init() {
+	toolRegister.Register("remember", func(ctx context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
+		var params struct {
+			Text string   `json:"text"`
+			Tags []string `json:"tags"`
+		}
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		m, err := memory.Remember(ctx, params.Text, params.Tags, e.SessionID, e.Principal)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("remembered: %s", m.String()), nil
+	})
+
+	toolRegister.Register("recall", func(ctx context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
+		var params struct {
+			Query string   `json:"query"`
+			Tags  []string `json:"tags"`
+			Limit int      `json:"limit"`
+		}
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		if params.Limit <= 0 {
+			params.Limit = defaultRecallLimit
+		}
+		params.Limit = min(params.Limit, maxRecallLimit)
+
+		results, err := memory.Recall(ctx, params.Query, params.Tags, params.Limit, e.Principal)
+		if err != nil {
+			return "", err
+		}
+		if len(results) == 0 {
+			return "no memories found", nil
+		}
+		lines := make([]string, len(results))
+		for i, r := range results {
+			lines[i] = r.Memory.String()
+		}
+		return strings.Join(lines, "\n"), nil
+	})
+
+	toolRegister.Register("forget", func(_ context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
+		var params struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		if err := memory.Forget(params.ID, e.Principal); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("memory %s forgotten", params.ID), nil
+	})
+}
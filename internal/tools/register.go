@@ -14,6 +14,9 @@ import (
 	_ "github.com/pardnchiu/agenvoy/internal/tools/browser"
 	_ "github.com/pardnchiu/agenvoy/internal/tools/calculator"
 	_ "github.com/pardnchiu/agenvoy/internal/tools/file"
+	_ "github.com/pardnchiu/agenvoy/internal/tools/gitTools"
+	_ "github.com/pardnchiu/agenvoy/internal/tools/lsp"
+	_ "github.com/pardnchiu/agenvoy/internal/tools/memoryTools"
 	_ "github.com/pardnchiu/agenvoy/internal/tools/schedulerTools"
 )
 
@@ -15,109 +15,177 @@ import (
 func init() {
 	toolRegister.Register("add_cron", func(_ context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
 		var params struct {
-			CronExpr  string `json:"cron_expr"`
-			Script    string `json:"script"`
-			ChannelID string `json:"channel_id"`
+			CronExpr    string            `json:"cron_expr"`
+			Script      string            `json:"script"`
+			Prompt      string            `json:"prompt"`
+			Skill       string            `json:"skill"`
+			Agent       string            `json:"agent"`
+			Target      string            `json:"target"`
+			Timeout     string            `json:"timeout"`
+			Timezone    string            `json:"timezone"`
+			Misfire     string            `json:"misfire"`
+			Env         map[string]string `json:"env"`
+			Description string            `json:"description"`
 		}
 		if err := json.Unmarshal(args, &params); err != nil {
 			return "", fmt.Errorf("json.Unmarshal: %w", err)
 		}
-		if params.ChannelID == "" {
-			channelID, err := sessionManager.GetChannelID(e.SessionID)
+		if params.Target == "" {
+			target, err := sessionTarget(e.SessionID)
 			if err != nil {
-				return "", fmt.Errorf("GetChannelID: %w", err)
+				return "", err
 			}
-			params.ChannelID = channelID
+			params.Target = target
 		}
 
-		mgr := scheduler.Get()
-		if mgr == nil {
-			return "", fmt.Errorf("scheduler not initialized")
+		mgr, err := scheduler.Current()
+		if err != nil {
+			return "", err
 		}
-		if err := mgr.AddCron(params.CronExpr, params.Script, params.ChannelID); err != nil {
+		job, err := mgr.AddCron(params.CronExpr, scheduler.Job{
+			Script:      params.Script,
+			Prompt:      params.Prompt,
+			Skill:       params.Skill,
+			Agent:       params.Agent,
+			Target:      params.Target,
+			Timeout:     params.Timeout,
+			Timezone:    params.Timezone,
+			Misfire:     params.Misfire,
+			Env:         params.Env,
+			Description: params.Description,
+			SessionID:   e.SessionID,
+		})
+		if err != nil {
 			return "", err
 		}
-		return fmt.Sprintf("cron task added: %s %s", params.CronExpr, params.Script), nil
+		return fmt.Sprintf("cron added: %s", job.String()), nil
 	})
 
 	toolRegister.Register("list_crons", func(_ context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
-		mgr := scheduler.Get()
-		if mgr == nil {
-			return "", fmt.Errorf("scheduler not initialized")
+		mgr, err := scheduler.Current()
+		if err != nil {
+			return "", err
+		}
+		jobs, err := mgr.Jobs(scheduler.KindCron)
+		if err != nil {
+			return "", err
 		}
-		tasks := mgr.ListCronTasks()
-		if len(tasks) == 0 {
+		if len(jobs) == 0 {
 			return "no cron tasks", nil
 		}
-		return strings.Join(tasks, "\n"), nil
+		return formatJobs(jobs), nil
 	})
 
 	toolRegister.Register("remove_cron", func(_ context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
 		var params struct {
-			Index int `json:"index"`
+			ID string `json:"id"`
 		}
 		if err := json.Unmarshal(args, &params); err != nil {
 			return "", fmt.Errorf("json.Unmarshal: %w", err)
 		}
-		mgr := scheduler.Get()
-		if mgr == nil {
-			return "", fmt.Errorf("scheduler not initialized")
+		mgr, err := scheduler.Current()
+		if err != nil {
+			return "", err
 		}
-		if err := mgr.RemoveCronTask(params.Index); err != nil {
+		if err := scheduler.RemoveKind(mgr, scheduler.KindCron, params.ID); err != nil {
 			return "", err
 		}
-		return fmt.Sprintf("cron task #%d removed", params.Index), nil
+		return fmt.Sprintf("cron task %s removed", params.ID), nil
 	})
 
 	toolRegister.Register("add_task", func(_ context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
 		var params struct {
-			At        string `json:"at"`
-			Script    string `json:"script"`
-			ChannelID string `json:"channel_id"`
+			At          string            `json:"at"`
+			Script      string            `json:"script"`
+			Prompt      string            `json:"prompt"`
+			Skill       string            `json:"skill"`
+			Agent       string            `json:"agent"`
+			Target      string            `json:"target"`
+			Timeout     string            `json:"timeout"`
+			Timezone    string            `json:"timezone"`
+			Misfire     string            `json:"misfire"`
+			Env         map[string]string `json:"env"`
+			Description string            `json:"description"`
 		}
 		if err := json.Unmarshal(args, &params); err != nil {
 			return "", fmt.Errorf("json.Unmarshal: %w", err)
 		}
-		if params.ChannelID == "" {
-			channelID, err := sessionManager.GetChannelID(e.SessionID)
+		if params.Target == "" {
+			target, err := sessionTarget(e.SessionID)
 			if err != nil {
-				return "", fmt.Errorf("GetChannelID: %w", err)
+				return "", err
 			}
-			params.ChannelID = channelID
+			params.Target = target
+		}
+		mgr, err := scheduler.Current()
+		if err != nil {
+			return "", err
 		}
-		mgr := scheduler.Get()
-		if mgr == nil {
-			return "", fmt.Errorf("scheduler not initialized")
+		job, err := mgr.AddTask(params.At, scheduler.Job{
+			Script:      params.Script,
+			Prompt:      params.Prompt,
+			Skill:       params.Skill,
+			Agent:       params.Agent,
+			Target:      params.Target,
+			Timeout:     params.Timeout,
+			Timezone:    params.Timezone,
+			Misfire:     params.Misfire,
+			Env:         params.Env,
+			Description: params.Description,
+			SessionID:   e.SessionID,
+		})
+		if err != nil {
+			return "", err
 		}
-		return mgr.AddTask(params.At, params.Script, params.ChannelID)
+		return fmt.Sprintf("task set up: %s", job.String()), nil
 	})
 
 	toolRegister.Register("list_tasks", func(_ context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
-		mgr := scheduler.Get()
-		if mgr == nil {
-			return "", fmt.Errorf("scheduler not initialized")
+		mgr, err := scheduler.Current()
+		if err != nil {
+			return "", err
+		}
+		jobs, err := mgr.Jobs(scheduler.KindTask)
+		if err != nil {
+			return "", err
 		}
-		tasks := mgr.ListTasks()
-		if len(tasks) == 0 {
+		if len(jobs) == 0 {
 			return "no onetime tasks", nil
 		}
-		return strings.Join(tasks, "\n"), nil
+		return formatJobs(jobs), nil
 	})
 
 	toolRegister.Register("remove_task", func(_ context.Context, e *toolTypes.Executor, args json.RawMessage) (string, error) {
 		var params struct {
-			Index int `json:"index"`
+			ID string `json:"id"`
 		}
 		if err := json.Unmarshal(args, &params); err != nil {
 			return "", fmt.Errorf("json.Unmarshal: %w", err)
 		}
-		mgr := scheduler.Get()
-		if mgr == nil {
-			return "", fmt.Errorf("scheduler not initialized")
+		mgr, err := scheduler.Current()
+		if err != nil {
+			return "", err
 		}
-		if err := mgr.RemoveTask(params.Index); err != nil {
+		if err := scheduler.RemoveKind(mgr, scheduler.KindTask, params.ID); err != nil {
 			return "", err
 		}
-		return fmt.Sprintf("onetime task #%d removed", params.Index), nil
+		return fmt.Sprintf("onetime task %s removed", params.ID), nil
 	})
 }
+
+func formatJobs(jobs []scheduler.Job) string {
+	lines := make([]string, len(jobs))
+	for i, job := range jobs {
+		lines[i] = job.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// * sessionTarget defaults delivery to the chat the job was created from
+func sessionTarget(sessionID string) (string, error) {
+	channelID, err := sessionManager.GetChannelID(sessionID)
+	if err != nil {
+		return "", fmt.Errorf("GetChannelID: %w", err)
+	}
+	return channelID, nil
+}
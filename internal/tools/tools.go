@@ -10,14 +10,56 @@ import (
 	"time"
 
 	"github.com/pardnchiu/agenvoy/internal/tools/file"
+	"github.com/pardnchiu/agenvoy/internal/tools/gitTools"
 	toolTypes "github.com/pardnchiu/agenvoy/internal/tools/types"
 )
 
 var (
-// * template allow all for testing
-// disallowed = regexp.MustCompile(`[;&|` + "`" + `$(){}!<>\\]`)
+	// * template allow all for testing
+	// disallowed = regexp.MustCompile(`[;&|` + "`" + `$(){}!<>\\]`)
+
+	// * always ask before running, even when all tools are allowed
+	alwaysConfirm = map[string]bool{
+		"git_commit": true,
+	}
 )
 
+func NeedConfirm(name string, allowAll bool) bool {
+	return !allowAll || alwaysConfirm[name]
+}
+
+// * tools without side effects, frontends may approve them without asking,
+// * search_history stays here as frontends are limited to their own session
+var readOnly = map[string]bool{
+	"read_file":        true,
+	"list_files":       true,
+	"glob_files":       true,
+	"search_content":   true,
+	"search_history":   true,
+	"get_tool_error":   true,
+	"search_errors":    true,
+	"recall":           true,
+	"fetch_google_rss": true,
+	"search_web":       true,
+	"fetch_page":       true,
+	"list_tools":       true,
+	"calculate":        true,
+	"list_tasks":       true,
+	"list_crons":       true,
+	"git_status":       true,
+	"git_diff":         true,
+	"git_log":          true,
+	"git_show":         true,
+	"find_definition":  true,
+	"find_references":  true,
+	"document_symbols": true,
+	"diagnostics":      true,
+}
+
+func IsReadOnly(name string) bool {
+	return readOnly[name]
+}
+
 func runCommand(ctx context.Context, e *toolTypes.Executor, command string) (string, error) {
 	command = strings.TrimSpace(command)
 	if command == "" {
@@ -36,6 +78,10 @@ func runCommand(ctx context.Context, e *toolTypes.Executor, command string) (str
 		}
 	}
 
+	if op, ok := gitTools.IsDestructive(command); ok {
+		return "", fmt.Errorf("failed to run command: git %s is not allowed", op)
+	}
+
 	// * template allow all for testing
 	// if disallowed.MatchString(command) {
 	// 	return "", fmt.Errorf("failed to run command: disallowed characters")
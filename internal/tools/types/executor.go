@@ -9,11 +9,16 @@ import (
 type Executor struct {
 	WorkPath       string
 	SessionID      string
+	Principal      string   // * "frontend:user" of a chat request, empty for the CLI
 	Allowed        []string // * limit to these folders to use
 	AllowedCommand map[string]bool
 	Exclude        []Exclude
 	Tools          []Tool
 	APIToolbox     *apiAdapter.Translator
+	Images         []string // * data URLs read by tools, sent as image_url after tool results
+	Vision         bool     // * the agent takes image input, otherwise images are not read
+	// * set by replays, a call it answers is not executed
+	Recorded func(name, args string) (string, bool)
 }
 
 type Exclude struct {
@@ -0,0 +1,225 @@
+package transcript
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatJSONL    = "jsonl"
+)
+
+//go:embed embed/transcript.html
+var htmlTemplate string
+
+var pageTemplate = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"stamp":  stamp,
+	"pretty": prettyJSON,
+}).Parse(htmlTemplate))
+
+type Options struct {
+	// * replace tool results with their size, arguments are kept
+	Redact bool
+	// * show the agent, skill, tokens and duration of each turn
+	Meta bool
+}
+
+// * Write renders t in format to w
+func Write(w io.Writer, t *Transcript, format string, opts Options) error {
+	if opts.Redact {
+		t = t.redacted()
+	}
+	switch format {
+	case FormatMarkdown, "":
+		return writeMarkdown(w, t, opts)
+	case FormatHTML:
+		return pageTemplate.Execute(w, struct {
+			*Transcript
+			Options
+			SummaryJSON string
+		}{t, opts, summaryJSON(t.Summary)})
+	case FormatJSONL:
+		return writeJSONL(w, t, opts)
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+func (t *Transcript) redacted() *Transcript {
+	copied := *t
+	copied.Turns = make([]*Turn, len(t.Turns))
+	for i, turn := range t.Turns {
+		turnCopy := *turn
+		turnCopy.Calls = make([]*Call, len(turn.Calls))
+		for j, call := range turn.Calls {
+			callCopy := *call
+			callCopy.Result = fmt.Sprintf("[redacted, %d bytes]", len(call.Result))
+			turnCopy.Calls[j] = &callCopy
+		}
+		copied.Turns[i] = &turnCopy
+	}
+	return &copied
+}
+
+func writeMarkdown(w io.Writer, t *Transcript, opts Options) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Session %s\n", t.SessionID)
+	for _, turn := range t.Turns {
+		fmt.Fprintf(&b, "\n## Turn %d", turn.Index)
+		if !turn.Time.IsZero() {
+			fmt.Fprintf(&b, " · %s", stamp(turn.Time))
+		}
+		b.WriteString("\n\n")
+		if opts.Meta && turn.Meta != nil {
+			fmt.Fprintf(&b, "> %s\n\n", turn.MetaLine())
+		}
+		if turn.User != "" {
+			fmt.Fprintf(&b, "**User**\n\n%s\n\n", turn.User)
+		}
+		for i, call := range turn.Calls {
+			fmt.Fprintf(&b, "**Tool %d: `%s`**\n\n", i+1, call.Tool)
+			if call.Arguments != "" {
+				b.WriteString(fenced(prettyJSON(call.Arguments), "json"))
+			}
+			b.WriteString("<details><summary>Result</summary>\n\n")
+			b.WriteString(fenced(call.Result, ""))
+			b.WriteString("</details>\n\n")
+		}
+		if turn.Answer != "" {
+			fmt.Fprintf(&b, "**Assistant**\n\n%s\n", turn.Answer)
+		}
+	}
+	if len(t.Summary) > 0 {
+		b.WriteString("\n## Summary\n\n")
+		b.WriteString(fenced(summaryJSON(t.Summary), "json"))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// * jsonlEntry is one line of the JSONL export, a turn line leads each turn when Meta is set
+type jsonlEntry struct {
+	Type             string         `json:"type"`
+	Turn             int            `json:"turn,omitempty"`
+	Time             *time.Time     `json:"time,omitempty"`
+	Content          string         `json:"content,omitempty"`
+	Tool             string         `json:"tool,omitempty"`
+	CallID           string         `json:"call_id,omitempty"`
+	Arguments        string         `json:"arguments,omitempty"`
+	Agent            string         `json:"agent,omitempty"`
+	Skill            string         `json:"skill,omitempty"`
+	DurationMS       int64          `json:"duration_ms,omitempty"`
+	PromptTokens     int            `json:"prompt_tokens,omitempty"`
+	CompletionTokens int            `json:"completion_tokens,omitempty"`
+	TotalTokens      int            `json:"total_tokens,omitempty"`
+	Summary          map[string]any `json:"summary,omitempty"`
+}
+
+func writeJSONL(w io.Writer, t *Transcript, opts Options) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, turn := range t.Turns {
+		var at *time.Time
+		if !turn.Time.IsZero() {
+			at = &turn.Time
+		}
+		var entries []jsonlEntry
+		if opts.Meta && turn.Meta != nil {
+			entries = append(entries, jsonlEntry{
+				Type:             "turn",
+				Turn:             turn.Index,
+				Time:             &turn.Meta.StartedAt,
+				Agent:            turn.Meta.Agent,
+				Skill:            turn.Meta.Skill,
+				DurationMS:       turn.Meta.EndedAt.Sub(turn.Meta.StartedAt).Milliseconds(),
+				PromptTokens:     turn.Meta.PromptTokens,
+				CompletionTokens: turn.Meta.CompletionTokens,
+				TotalTokens:      turn.Meta.TotalTokens,
+			})
+		}
+		if turn.User != "" {
+			entries = append(entries, jsonlEntry{Type: "user", Turn: turn.Index, Time: at, Content: turn.User})
+		}
+		for _, call := range turn.Calls {
+			entries = append(entries,
+				jsonlEntry{Type: "tool_call", Turn: turn.Index, Tool: call.Tool, CallID: call.ID, Arguments: call.Arguments},
+				jsonlEntry{Type: "tool_result", Turn: turn.Index, Tool: call.Tool, CallID: call.ID, Content: call.Result},
+			)
+		}
+		if turn.Answer != "" {
+			entries = append(entries, jsonlEntry{Type: "assistant", Turn: turn.Index, Content: turn.Answer})
+		}
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return fmt.Errorf("encoder.Encode: %w", err)
+			}
+		}
+	}
+	if len(t.Summary) > 0 {
+		if err := encoder.Encode(jsonlEntry{Type: "summary", Summary: t.Summary}); err != nil {
+			return fmt.Errorf("encoder.Encode: %w", err)
+		}
+	}
+	return nil
+}
+
+// * MetaLine reads like "agent a · skill s · 1200 tokens (1000 in / 200 out) · 3.2s", empty without a record
+func (turn *Turn) MetaLine() string {
+	if turn.Meta == nil {
+		return ""
+	}
+	parts := []string{"agent " + turn.Meta.Agent}
+	if turn.Meta.Skill != "" {
+		parts = append(parts, "skill "+turn.Meta.Skill)
+	}
+	if turn.Meta.TotalTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%d tokens (%d in / %d out)", turn.Meta.TotalTokens, turn.Meta.PromptTokens, turn.Meta.CompletionTokens))
+	}
+	if !turn.Meta.EndedAt.IsZero() {
+		parts = append(parts, turn.Meta.EndedAt.Sub(turn.Meta.StartedAt).Round(100*time.Millisecond).String())
+	}
+	return strings.Join(parts, " · ")
+}
+
+func stamp(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// * fenced wraps text in a code fence longer than any backtick run inside it
+func fenced(text, lang string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + strings.TrimRight(text, "\n") + "\n" + fence + "\n\n"
+}
+
+func prettyJSON(text string) string {
+	var buf bytes.Buffer
+	if json.Indent(&buf, []byte(text), "", "  ") != nil {
+		return text
+	}
+	return buf.String()
+}
+
+func summaryJSON(summary map[string]any) string {
+	if len(summary) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(summary); err != nil {
+		return ""
+	}
+	return buf.String()
+}
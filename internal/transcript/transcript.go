@@ -0,0 +1,188 @@
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	agentTypes "github.com/pardnchiu/agenvoy/internal/agents/types"
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+	"github.com/pardnchiu/agenvoy/internal/filesystem/sessionManager"
+	"github.com/pardnchiu/agenvoy/internal/history"
+)
+
+const toolCallFileLayout = "2006-01-02-15-04-05"
+
+// * Transcript joins history.json, turns.jsonl, tool_calls/ and summary.json of one session
+type Transcript struct {
+	SessionID string
+	Turns     []*Turn
+	Summary   map[string]any
+}
+
+// * Turn is one user input with the tool calls and answer it led to
+type Turn struct {
+	Index  int
+	Time   time.Time
+	User   string
+	Answer string
+	Calls  []*Call
+	// * nil for turns recorded before turns.jsonl existed
+	Meta *sessionManager.Turn
+}
+
+type Call struct {
+	ID        string
+	Tool      string
+	Arguments string
+	Result    string
+}
+
+// * Load builds the transcript of sessionID, oldest turn first
+func Load(sessionID string) (*Transcript, error) {
+	sessionDir := filepath.Join(filesystem.SessionsDir, sessionID)
+	if _, err := os.Stat(sessionDir); err != nil {
+		return nil, fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	messages, _ := sessionManager.GetHistory(sessionID)
+	t := &Transcript{SessionID: sessionID}
+	for _, message := range messages {
+		at, text := history.SplitHeader(history.MessageText(message.Content))
+		text = strings.TrimSpace(strings.TrimPrefix(text, "...\n"))
+		switch message.Role {
+		case "user":
+			t.Turns = append(t.Turns, &Turn{
+				Index: len(t.Turns) + 1,
+				Time:  at,
+				User:  text,
+			})
+		case "assistant":
+			if len(t.Turns) == 0 {
+				t.Turns = append(t.Turns, &Turn{Index: 1, Time: at})
+			}
+			turn := t.Turns[len(t.Turns)-1]
+			if turn.Answer != "" {
+				turn.Answer += "\n\n"
+			}
+			turn.Answer += text
+		}
+	}
+
+	records, err := sessionManager.GetTurns(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("sessionManager.GetTurns: %w", err)
+	}
+	linked := map[string]bool{}
+	last := -1
+	for _, record := range records {
+		index := t.recordTurn(record.StartedAt, last)
+		if index < 0 {
+			continue
+		}
+		last = index
+		turn := t.Turns[index]
+		turn.Meta = &record
+		if record.ToolCalls != "" {
+			linked[filepath.ToSlash(record.ToolCalls)] = true
+			turn.Calls = ReadCalls(filepath.Join(sessionDir, record.ToolCalls))
+		}
+	}
+
+	// * older sessions have no turn records, their tool logs are placed by file time
+	paths, _ := filepath.Glob(filepath.Join(sessionDir, "tool_calls", "*", "*.json"))
+	slices.Sort(paths)
+	for _, path := range paths {
+		rel, _ := filepath.Rel(sessionDir, path)
+		if linked[filepath.ToSlash(rel)] {
+			continue
+		}
+		at, err := time.ParseInLocation(toolCallFileLayout, strings.TrimSuffix(filepath.Base(path), ".json"), time.Local)
+		if err != nil {
+			continue
+		}
+		if turn := t.at(at); turn != nil {
+			turn.Calls = append(turn.Calls, ReadCalls(path)...)
+		}
+	}
+
+	_, t.Summary = sessionManager.GetSummary(sessionID)
+	return t, nil
+}
+
+// * at returns the last turn started at or before moment, the first one when every turn is later
+func (t *Transcript) at(moment time.Time) *Turn {
+	if len(t.Turns) == 0 {
+		return nil
+	}
+	found := t.Turns[0]
+	for _, turn := range t.Turns {
+		if turn.Time.IsZero() || turn.Time.After(moment) {
+			continue
+		}
+		found = turn
+	}
+	return found
+}
+
+// * recordTurn finds the turn a record started at start belongs to among the turns after last,
+// * headers only keep seconds so of turns sharing the latest time the earliest one is taken,
+// * a turn that failed before its input was saved has no match
+func (t *Transcript) recordTurn(start time.Time, last int) int {
+	found := -1
+	for i := last + 1; i < len(t.Turns); i++ {
+		at := t.Turns[i].Time
+		if at.IsZero() || at.After(start) {
+			continue
+		}
+		if found < 0 || at.After(t.Turns[found].Time) {
+			found = i
+		}
+	}
+	return found
+}
+
+// * ReadCalls pairs the calls of a tool log with their results by call ID
+func ReadCalls(path string) []*Call {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var messages []agentTypes.Message
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil
+	}
+
+	var calls []*Call
+	byID := map[string]*Call{}
+	for _, message := range messages {
+		for _, toolCall := range message.ToolCalls {
+			call := &Call{
+				ID:        toolCall.ID,
+				Tool:      toolCall.Function.Name,
+				Arguments: toolCall.Function.Arguments,
+			}
+			calls = append(calls, call)
+			byID[call.ID] = call
+		}
+		if message.Role != "tool" {
+			continue
+		}
+		name, result := history.SplitToolResult(history.MessageText(message.Content))
+		if call, ok := byID[message.ToolCallID]; ok {
+			call.Result = result
+			continue
+		}
+		// * logs written before calls were recorded hold results only
+		calls = append(calls, &Call{
+			ID:     message.ToolCallID,
+			Tool:   name,
+			Result: result,
+		})
+	}
+	return calls
+}
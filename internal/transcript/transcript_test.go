@@ -0,0 +1,75 @@
+package transcript
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pardnchiu/agenvoy/internal/filesystem"
+	"github.com/pardnchiu/agenvoy/internal/filesystem/sessionManager"
+)
+
+func TestLoad(t *testing.T) {
+	filesystem.SessionsDir = t.TempDir()
+	dir := filepath.Join(filesystem.SessionsDir, "s1")
+	os.MkdirAll(filepath.Join(dir, "tool_calls", "2026-01-05"), 0755)
+
+	os.WriteFile(filepath.Join(dir, "history.json"), []byte(`[
+		{"role":"user","content":"---\n當前時間: 2026-01-05 10:00:00\n---\nfirst"},
+		{"role":"assistant","content":"---\n當前時間: 2026-01-05 10:00:04\n---\nanswer one"},
+		{"role":"user","content":"---\n當前時間: 2026-01-05 11:00:00\n---\nsecond"},
+		{"role":"assistant","content":"answer two"}
+	]`), 0644)
+	// * a legacy log of results only and without a turn record, placed by its file time
+	os.WriteFile(filepath.Join(dir, "tool_calls", "2026-01-05", "2026-01-05-10-00-03.json"), []byte(`[
+		{"role":"tool","tool_call_id":"a","content":"[read_file] secret contents"}
+	]`), 0644)
+	os.WriteFile(filepath.Join(dir, "tool_calls", "2026-01-05", "2026-01-05-11-00-02.json"), []byte(`[
+		{"role":"assistant","tool_calls":[{"id":"b","type":"function","function":{"name":"calculate","arguments":"{}"}}]},
+		{"role":"tool","tool_call_id":"b","content":"[calculate] 42"}
+	]`), 0644)
+	started := time.Date(2026, 1, 5, 11, 0, 0, int(500*time.Millisecond), time.Local)
+	sessionManager.SaveTurn("s1", sessionManager.Turn{
+		StartedAt:   started,
+		EndedAt:     started.Add(2 * time.Second),
+		Agent:       "openai@gpt",
+		ToolCalls:   filepath.Join("tool_calls", "2026-01-05", "2026-01-05-11-00-02.json"),
+		TotalTokens: 10,
+	})
+
+	tr, err := Load("s1")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(tr.Turns) != 2 {
+		t.Fatalf("got %d turns, want 2", len(tr.Turns))
+	}
+	first, second := tr.Turns[0], tr.Turns[1]
+	if first.User != "first" || first.Answer != "answer one" || len(first.Calls) != 1 || first.Calls[0].Tool != "read_file" || first.Calls[0].Result != "secret contents" {
+		t.Errorf("first turn: %+v", first)
+	}
+	if first.Meta != nil {
+		t.Errorf("first turn has no record")
+	}
+	if second.Meta == nil || second.Meta.Agent != "openai@gpt" || len(second.Calls) != 1 || second.Calls[0].Tool != "calculate" || second.Calls[0].Result != "42" {
+		t.Errorf("second turn: %+v", second)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, tr, FormatMarkdown, Options{Redact: true, Meta: true}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "secret contents") || !strings.Contains(out, "[redacted, 15 bytes]") {
+		t.Errorf("tool output not redacted:\n%s", out)
+	}
+	if !strings.Contains(out, "agent openai@gpt") {
+		t.Errorf("agent missing:\n%s", out)
+	}
+	if tr.Turns[0].Calls[0].Result != "secret contents" {
+		t.Errorf("redaction changed the loaded transcript")
+	}
+}